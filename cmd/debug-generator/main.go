@@ -13,6 +13,7 @@ import (
 func main() {
 	// Flags
 	outputDir := flag.String("output", "./debug-output", "Output directory for generated app")
+	templateDir := flag.String("templates", "", "Directory of templates overriding the embedded package templates")
 	flag.Parse()
 
 	// Configure logging
@@ -74,7 +75,7 @@ func main() {
 	fmt.Println()
 
 	// Create generator (uses template engine internally)
-	generator, err := fpkgen.NewGenerator()
+	generator, err := fpkgen.NewGenerator(fpkgen.Options{TemplateDir: *templateDir})
 	if err != nil {
 		slog.Error("Failed to create generator", "error", err)
 		os.Exit(1)
@@ -107,6 +108,7 @@ func printUsage() {
 	fmt.Println()
 	fmt.Println("Flags:")
 	fmt.Println("  -output string   Output directory (default \"./debug-output\")")
+	fmt.Println("  -templates dir   Directory of templates overriding the embedded ones")
 	fmt.Println()
 	fmt.Println("Supported keys (following fnOS manifest conventions):")
 	fmt.Println("  appname        - App identifier (e.g., watchcow.myapp)")
@@ -114,6 +116,7 @@ func printUsage() {
 	fmt.Println("  desc           - Description")
 	fmt.Println("  version        - Version (e.g., 1.0.0)")
 	fmt.Println("  maintainer     - Maintainer name")
+	fmt.Println("  homepage       - Project homepage URL")
 	fmt.Println("  service_port   - Service port")
 	fmt.Println("  protocol       - http or https")
 	fmt.Println("  path           - URL path")
@@ -121,6 +124,7 @@ func printUsage() {
 	fmt.Println("  icon           - Icon URL")
 	fmt.Println("  image          - Docker image name")
 	fmt.Println("  container_name - Container name")
+	fmt.Println("  template_set   - Template set (see -templates)")
 	fmt.Println()
 	fmt.Println("Example:")
 	fmt.Println("  debug-generator appname=watchcow.nginx display_name=\"Nginx Server\" service_port=80")
@@ -138,6 +142,8 @@ func applyConfig(config *fpkgen.AppConfig, key, value string) {
 		config.Version = value
 	case "maintainer":
 		config.Maintainer = value
+	case "homepage":
+		config.Homepage = value
 	case "service_port":
 		config.Port = value
 	case "protocol":
@@ -152,6 +158,8 @@ func applyConfig(config *fpkgen.AppConfig, key, value string) {
 		config.Image = value
 	case "container_name":
 		config.ContainerName = value
+	case "template_set":
+		config.TemplateSet = value
 	default:
 		fmt.Printf("Unknown key: %s\n", key)
 	}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"watchcow/internal/fpkgen"
+)
+
+// runIconsCommand runs the "watchcow icons" maintenance subcommands
+func runIconsCommand(args []string) int {
+	if len(args) == 0 || args[0] != "prune" {
+		fmt.Fprintln(os.Stderr, "Usage: watchcow icons prune [-icon-cache DIR] [-unused-for DURATION] [-icon-cache-max-size BYTES]")
+		return 2
+	}
+
+	fs := flag.NewFlagSet("icons prune", flag.ExitOnError)
+	cacheDir := fs.String("icon-cache", fpkgen.DefaultIconCacheDir(), "Icon cache directory")
+	unusedFor := fs.Duration("unused-for", 30*24*time.Hour, "Remove icons not used for this long (0 keeps all)")
+	maxSize := fs.Int64("icon-cache-max-size", fpkgen.DefaultIconCacheMaxSize, "Total size cap of the icon cache in bytes (0 = unlimited)")
+	fs.Parse(args[1:])
+
+	if *cacheDir == "" {
+		fmt.Fprintln(os.Stderr, "No icon cache directory, use -icon-cache")
+		return 1
+	}
+
+	cache, err := fpkgen.NewIconCache(*cacheDir, fpkgen.IconCacheOptions{MaxSize: *maxSize})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open icon cache: %v\n", err)
+		return 1
+	}
+
+	stats, err := cache.Prune(*unusedFor)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to prune icon cache: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Pruned icon cache %s: removed %d entries and %d files (%d bytes), %d bytes in cache\n",
+		*cacheDir, stats.Entries, stats.Blobs, stats.BytesFreed, stats.BytesInCache)
+	return 0
+}
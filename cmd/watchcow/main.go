@@ -6,14 +6,35 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"watchcow/internal/docker"
+	"watchcow/internal/fpkgen"
 )
 
 func main() {
+	// Maintenance subcommands
+	if len(os.Args) > 1 && os.Args[1] == "icons" {
+		os.Exit(runIconsCommand(os.Args[2:]))
+	}
+
 	// Parse command line flags
 	debug := flag.Bool("debug", false, "Enable debug mode")
+	presetFile := flag.String("presets", "", "YAML file extending/overriding the built-in image presets")
+	discoverIcons := flag.Bool("discover-icons", true, "Discover icons from the running web app when no icon is configured")
+	iconCacheDir := flag.String("icon-cache", fpkgen.DefaultIconCacheDir(), "Icon cache directory (empty disables caching)")
+	iconCacheMaxAge := flag.Duration("icon-cache-max-age", fpkgen.DefaultIconCacheMaxAge, "Serve cached icons without revalidation for this long")
+	iconCacheMaxSize := flag.Int64("icon-cache-max-size", fpkgen.DefaultIconCacheMaxSize, "Total size cap of the icon cache in bytes (0 = unlimited)")
+	offline := flag.Bool("offline", false, "Do not download or discover icons, use cached icons only")
+	iconMirrors := flag.String("icon-mirrors", strings.Join(fpkgen.DefaultIconMirrors, ","), "Comma-separated dashboard-icons base URLs, tried in order")
+	iconFonts := flag.String("icon-fonts", "", "Comma-separated TTF/OTF/TTC fallback fonts for generated icons (e.g. CJK fonts)")
+	iconRoots := flag.String("icon-roots", strings.Join(fpkgen.DefaultIconRoots(), ","), "Comma-separated directories file:// icons, description files and hooks may be read from, including compose projects (/ allows any path)")
+	iconWait := flag.Duration("icon-wait", 10*time.Second, "How long to wait for icons before installing with placeholder icons (0 waits for all icons)")
+	iconRefresh := flag.Duration("icon-refresh", fpkgen.DefaultIconRefreshInterval, "How often to re-check the icons of installed apps (0 disables)")
+	templateDir := flag.String("templates", "", "Directory of templates overriding the embedded package templates, with template sets in subdirectories")
+	stateDir := flag.String("state-dir", fpkgen.DefaultStateDir(), "Directory of files shared with the scripts of generated apps, e.g. apps uninstalled from App Center (empty disables)")
 	flag.Parse()
 
 	// Configure slog
@@ -43,7 +64,23 @@ func main() {
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
 	// Create and start Docker monitor
-	monitor, err := docker.NewMonitor()
+	monitor, err := docker.NewMonitor(fpkgen.Options{
+		PresetFile:    *presetFile,
+		DiscoverIcons: *discoverIcons,
+		IconCacheDir:  *iconCacheDir,
+		IconCache: fpkgen.IconCacheOptions{
+			MaxAge:  *iconCacheMaxAge,
+			MaxSize: *iconCacheMaxSize,
+			Offline: *offline,
+		},
+		IconMirrors: strings.Split(*iconMirrors, ","),
+		IconFonts:   strings.Split(*iconFonts, ","),
+		IconRoots:   strings.Split(*iconRoots, ","),
+		IconWait:    *iconWait,
+		IconRefresh: *iconRefresh,
+		TemplateDir: *templateDir,
+		StateDir:    *stateDir,
+	})
 	if err != nil {
 		slog.Error("Failed to create Docker monitor", "error", err)
 		os.Exit(1)
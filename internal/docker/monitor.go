@@ -47,25 +47,35 @@ type ContainerState struct {
 	AppName       string
 	Installed     bool
 	Labels        map[string]string
+
+	upgradeDir string // Package with late icons waiting for the initial install to finish
 }
 
 // NewMonitor creates a new Docker monitor
-func NewMonitor() (*Monitor, error) {
+func NewMonitor(opts fpkgen.Options) (*Monitor, error) {
 	// Connect to Docker daemon
 	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Docker client: %w", err)
 	}
 
-	// Create generator
-	generator, err := fpkgen.NewGenerator()
+	m := &Monitor{
+		cli:        cli,
+		stopCh:     make(chan struct{}),
+		containers: make(map[string]*ContainerState),
+		opQueue:    make(chan *AppOperation, 100),
+	}
+
+	// Create generator; apps installed with placeholder icons are upgraded once the icons arrive
+	opts.OnIconsReady = m.handleIconsReady
+	m.generator, err = fpkgen.NewGenerator(opts)
 	if err != nil {
 		cli.Close()
 		return nil, fmt.Errorf("failed to create generator: %w", err)
 	}
 
 	// Try to create installer (may fail if appcenter-cli not available)
-	installer, err := fpkgen.NewInstaller()
+	m.installer, err = fpkgen.NewInstaller()
 	if err != nil {
 		slog.Warn("appcenter-cli not available, will only generate app packages", "error", err)
 		// Continue without installer - useful for development/testing
@@ -73,14 +83,7 @@ func NewMonitor() (*Monitor, error) {
 		slog.Info("Installer ready, apps will be auto-installed via appcenter-cli")
 	}
 
-	return &Monitor{
-		cli:        cli,
-		generator:  generator,
-		installer:  installer,
-		stopCh:     make(chan struct{}),
-		containers: make(map[string]*ContainerState),
-		opQueue:    make(chan *AppOperation, 100),
-	}, nil
+	return m, nil
 }
 
 // runOperationWorker processes appcenter-cli operations sequentially
@@ -145,6 +148,14 @@ func (m *Monitor) Start(ctx context.Context) {
 
 	// Start listening to Docker events for real-time updates
 	go m.listenToDockerEvents(ctx)
+
+	// Re-check icons of installed apps; changed icons are upgraded via handleIconsReady
+	go m.generator.RunIconRefresh(ctx)
+
+	// Recreate containers whose config wizard was saved
+	if m.installer != nil {
+		go m.watchConfigRequests(ctx)
+	}
 }
 
 // listenToDockerEvents listens to Docker daemon events
@@ -239,6 +250,21 @@ func shouldInstall(labels map[string]string) bool {
 func (m *Monitor) handleContainerStart(ctx context.Context, containerID, containerName string, labels map[string]string) {
 	appName := getAppNameFromLabels(labels, containerName)
 
+	// The user uninstalled the app from App Center but kept the container
+	if m.generator.UninstalledByUser(appName, containerID) {
+		slog.Info("App was uninstalled from App Center, not reinstalling", "app", appName, "container", containerName)
+		m.mu.Lock()
+		m.containers[containerID] = &ContainerState{
+			ContainerID:   containerID,
+			ContainerName: containerName,
+			AppName:       appName,
+			Installed:     false,
+			Labels:        labels,
+		}
+		m.mu.Unlock()
+		return
+	}
+
 	// Check if already installed in fnOS
 	if m.installer != nil && m.installer.IsAppInstalled(appName) {
 		// Already installed, just start it
@@ -257,42 +283,107 @@ func (m *Monitor) handleContainerStart(ctx context.Context, containerID, contain
 			Labels:        labels,
 		}
 		m.mu.Unlock()
+
+		// Installed by an earlier run: refresh its icons like those of new installs
+		if _, err := m.generator.TrackInstalled(ctx, containerID); err != nil {
+			slog.Warn("Failed to track installed fnOS app", "app", appName, "error", err)
+		}
 		return
 	}
 
 	// Not installed yet, generate and install
 	time.Sleep(2 * time.Second)
 
-	config, appDir, err := m.generator.GenerateFromContainer(ctx, containerID)
-	if err != nil {
-		slog.Error("Failed to generate fnOS app", "container", containerName, "error", err)
-		return
-	}
-
-	// Record state
+	// Record state before generating, icons may arrive while the package is generated
 	m.mu.Lock()
 	m.containers[containerID] = &ContainerState{
 		ContainerID:   containerID,
 		ContainerName: containerName,
-		AppName:       config.AppName,
+		AppName:       appName,
 		Installed:     false,
 		Labels:        labels,
 	}
 	m.mu.Unlock()
 
-	// Install via queue (serialized)
-	if err := m.queueOperation("install", config.AppName, appDir); err != nil {
-		slog.Error("Failed to install fnOS app", "app", config.AppName, "error", err)
+	config, appDir, err := m.generator.GenerateFromContainer(ctx, containerID)
+	if err != nil {
+		slog.Error("Failed to generate fnOS app", "container", containerName, "error", err)
+		m.mu.Lock()
+		delete(m.containers, containerID)
+		m.mu.Unlock()
 		return
 	}
 
+	// Install via queue (serialized)
+	err = m.queueOperation("install", config.AppName, appDir)
+
 	m.mu.Lock()
+	var upgradeDir string
 	if state, exists := m.containers[containerID]; exists {
-		state.Installed = true
+		state.AppName = config.AppName
+		state.Installed = err == nil
+		upgradeDir, state.upgradeDir = state.upgradeDir, ""
 	}
 	m.mu.Unlock()
+
+	if err != nil {
+		slog.Error("Failed to install fnOS app", "app", config.AppName, "error", err)
+		if upgradeDir != "" {
+			os.RemoveAll(upgradeDir)
+		}
+		return
+	}
 	slog.Info("Successfully installed fnOS app", "app", config.AppName, "container", containerName)
 	m.generator.MarkInstalled(containerID, config)
+
+	// Icons arrived during the install
+	if upgradeDir != "" {
+		m.upgradeIcons(config, upgradeDir)
+	}
+}
+
+// handleIconsReady upgrades an app installed with placeholder icons once its icons have
+// arrived, or whose icons changed. Returns an error if the upgrade failed.
+func (m *Monitor) handleIconsReady(config *fpkgen.AppConfig, appDir string) error {
+	m.mu.Lock()
+	state, exists := m.containers[config.ContainerID]
+	if !exists || m.installer == nil {
+		m.mu.Unlock()
+		os.RemoveAll(appDir)
+		if m.installer == nil {
+			return fmt.Errorf("appcenter-cli not available")
+		}
+		return fmt.Errorf("container %s not tracked", config.ContainerID)
+	}
+	if state.Installed && m.generator.UninstalledByUser(state.AppName, state.ContainerID) {
+		// Uninstalled from App Center since the package was generated
+		m.mu.Unlock()
+		m.markUserUninstalled(state.ContainerID)
+		os.RemoveAll(appDir)
+		return nil
+	}
+	if !state.Installed {
+		// Upgrade after the initial install
+		if state.upgradeDir != "" {
+			os.RemoveAll(state.upgradeDir)
+		}
+		state.upgradeDir = appDir
+		m.mu.Unlock()
+		return nil
+	}
+	m.mu.Unlock()
+
+	return m.upgradeIcons(config, appDir)
+}
+
+// upgradeIcons reinstalls an app from a package with its real icons
+func (m *Monitor) upgradeIcons(config *fpkgen.AppConfig, appDir string) error {
+	slog.Info("Upgrading fnOS app with loaded icons", "app", config.AppName)
+	err := m.queueOperation("install", config.AppName, appDir)
+	if err != nil {
+		slog.Warn("Failed to upgrade fnOS app icons", "app", config.AppName, "error", err)
+	}
+	return err
 }
 
 // handleContainerStop handles container stop event (stop app, keep installed)
@@ -304,6 +395,10 @@ func (m *Monitor) handleContainerStop(ctx context.Context, containerID, containe
 	if !exists || !state.Installed {
 		return
 	}
+	if m.generator.UninstalledByUser(state.AppName, containerID) {
+		m.markUserUninstalled(containerID)
+		return
+	}
 
 	// Stop via queue (serialized)
 	if err := m.queueOperation("stop", state.AppName, ""); err != nil {
@@ -321,8 +416,11 @@ func (m *Monitor) handleContainerDestroy(ctx context.Context, containerID, conta
 		return
 	}
 
-	// Uninstall via queue (serialized)
-	if state.Installed {
+	// Uninstall via queue (serialized), unless the user already uninstalled the app
+	// from App Center. The marker is no longer needed once the container is gone.
+	if m.generator.UninstalledByUser(state.AppName, containerID) {
+		slog.Info("App was already uninstalled from App Center", "app", state.AppName)
+	} else if state.Installed {
 		if err := m.queueOperation("uninstall", state.AppName, ""); err != nil {
 			slog.Warn("Failed to uninstall fnOS app", "app", state.AppName, "error", err)
 		}
@@ -330,9 +428,25 @@ func (m *Monitor) handleContainerDestroy(ctx context.Context, containerID, conta
 
 	// Remove from tracking
 	m.mu.Lock()
+	if state.upgradeDir != "" {
+		os.RemoveAll(state.upgradeDir)
+	}
 	delete(m.containers, containerID)
 	m.mu.Unlock()
 	m.generator.MarkUninstalled(containerID)
+	m.generator.ClearUninstalled(state.AppName)
+}
+
+// markUserUninstalled stops tracking a container as installed after the user
+// uninstalled its app from App Center
+func (m *Monitor) markUserUninstalled(containerID string) {
+	m.mu.Lock()
+	if state, exists := m.containers[containerID]; exists {
+		slog.Info("App was uninstalled from App Center", "app", state.AppName, "container", state.ContainerName)
+		state.Installed = false
+	}
+	m.mu.Unlock()
+	m.generator.MarkUninstalled(containerID)
 }
 
 // scanContainers scans all running containers
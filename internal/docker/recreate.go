@@ -0,0 +1,271 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+
+	"watchcow/internal/fpkgen"
+)
+
+// configRequestInterval is how often config wizard requests are checked
+const configRequestInterval = 2 * time.Second
+
+// watchConfigRequests applies the requests config_callback scripts write when
+// the user saves the config wizard of an app
+func (m *Monitor) watchConfigRequests(ctx context.Context) {
+	ticker := time.NewTicker(configRequestInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, req := range m.generator.TakeConfigRequests() {
+				m.applyConfigRequest(ctx, req)
+			}
+		}
+	}
+}
+
+// applyConfigRequest recreates the container of an app with the environment
+// values of a config request and upgrades the app to the new container
+func (m *Monitor) applyConfigRequest(ctx context.Context, req *fpkgen.ConfigRequest) {
+	m.mu.RLock()
+	state, exists := m.containers[req.ContainerID]
+	m.mu.RUnlock()
+	if !exists || state.AppName != req.AppName {
+		slog.Warn("Ignoring config request for unknown container", "app", req.AppName, "container", req.ContainerID)
+		return
+	}
+
+	info, err := m.cli.ContainerInspect(ctx, req.ContainerID)
+	if err != nil {
+		slog.Error("Failed to inspect container", "container", state.ContainerName, "error", err)
+		return
+	}
+
+	// Only variables exposed by watchcow.config.env may be changed
+	env, changed := updateEnv(info.Config.Env, req.Env, fpkgen.ConfigEnvKeys(info.Config.Labels))
+	if len(changed) == 0 {
+		slog.Info("Config unchanged, not recreating container", "app", req.AppName)
+		return
+	}
+	if err := m.checkImage(ctx, &info); err != nil {
+		slog.Error("Not recreating container", "container", state.ContainerName, "error", err)
+		return
+	}
+	slog.Info("Recreating container with new config", "app", req.AppName, "container", state.ContainerName,
+		"env", fpkgen.RedactEnv(info.Config.Labels, changed))
+
+	// The old container is replaced: its stop and destroy events must not stop or uninstall the app
+	m.mu.Lock()
+	if state.upgradeDir != "" {
+		os.RemoveAll(state.upgradeDir)
+	}
+	delete(m.containers, req.ContainerID)
+	m.mu.Unlock()
+	m.generator.MarkUninstalled(req.ContainerID)
+
+	newID, err := m.recreateContainer(ctx, &info, env)
+	if err != nil {
+		// The old container was restarted, its start event tracks it again
+		slog.Error("Failed to recreate container", "container", state.ContainerName, "error", err)
+		return
+	}
+
+	// Scripts and wizards of the package refer to the container ID and values
+	config, appDir, err := m.generator.GenerateFromContainer(ctx, newID)
+	if err != nil {
+		slog.Error("Failed to generate fnOS app", "container", state.ContainerName, "error", err)
+		return
+	}
+	if err := m.queueOperation("install", config.AppName, appDir); err != nil {
+		slog.Warn("Failed to upgrade fnOS app", "app", config.AppName, "error", err)
+		return
+	}
+	m.generator.MarkInstalled(newID, config)
+	slog.Info("Recreated container with new config", "app", config.AppName, "container", state.ContainerName)
+}
+
+// updateEnv returns env with the values of the allowed keys replaced or added,
+// and the changed entries (KEY=VALUE) sorted by key
+func updateEnv(env []string, values map[string]string, allowed []string) ([]string, []string) {
+	updated := slices.Clone(env)
+	var changed []string
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		if !slices.Contains(allowed, key) {
+			slog.Warn("Ignoring config value not exposed by watchcow.config.env", "name", key)
+			continue
+		}
+		entry := key + "=" + values[key]
+		i := slices.IndexFunc(updated, func(e string) bool { return strings.HasPrefix(e, key+"=") })
+		switch {
+		case i < 0:
+			updated = append(updated, entry)
+		case updated[i] != entry:
+			updated[i] = entry
+		default:
+			continue
+		}
+		changed = append(changed, entry)
+	}
+	return updated, changed
+}
+
+// checkImage verifies that the image name of a container still refers to the image it
+// runs. The recreated container keeps the name (used for app metadata): a config change
+// must not silently update the app to an image pulled since the container was created.
+func (m *Monitor) checkImage(ctx context.Context, info *container.InspectResponse) error {
+	image, err := m.cli.ImageInspect(ctx, info.Config.Image)
+	if err != nil {
+		return fmt.Errorf("failed to inspect image %s: %w", info.Config.Image, err)
+	}
+	if image.ID != info.Image {
+		return fmt.Errorf("image %s was updated since the container was created, recreate the container with the new image first", info.Config.Image)
+	}
+	return nil
+}
+
+// recreateContainer replaces a container by one with the same image, configuration,
+// mounts, networks and labels but the given environment. The old container is kept
+// (renamed) until the new one runs, and restarted if the new container fails.
+// Returns the short ID of the new container.
+func (m *Monitor) recreateContainer(ctx context.Context, info *container.InspectResponse, env []string) (string, error) {
+	name := strings.TrimPrefix(info.Name, "/")
+	shortID := info.ID[:12]
+
+	config := *info.Config
+	config.Env = env
+	if config.Hostname == shortID {
+		// Default hostname, the new container gets its own
+		config.Hostname = ""
+	}
+	hostConfig := *info.HostConfig
+	hostConfig.Mounts = append(slices.Clone(hostConfig.Mounts), anonymousVolumes(info)...)
+
+	// Docker attaches a new container to one network, the others are connected before it starts
+	primary, endpoints := containerEndpoints(info)
+	var networking *network.NetworkingConfig
+	if endpoint, ok := endpoints[primary]; ok {
+		networking = &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{primary: endpoint}}
+	}
+
+	if err := m.cli.ContainerStop(ctx, info.ID, container.StopOptions{}); err != nil {
+		return "", fmt.Errorf("failed to stop container: %w", err)
+	}
+	oldName := name + "-watchcow-" + shortID
+	if err := m.cli.ContainerRename(ctx, info.ID, oldName); err != nil {
+		m.restoreContainer(ctx, info.ID, "")
+		return "", fmt.Errorf("failed to rename container: %w", err)
+	}
+
+	created, err := m.cli.ContainerCreate(ctx, &config, &hostConfig, networking, nil, name)
+	if err != nil {
+		m.restoreContainer(ctx, info.ID, name)
+		return "", fmt.Errorf("failed to create container: %w", err)
+	}
+	for networkName, endpoint := range endpoints {
+		if networkName == primary {
+			continue
+		}
+		if err = m.cli.NetworkConnect(ctx, networkName, created.ID, endpoint); err != nil {
+			err = fmt.Errorf("failed to connect network %s: %w", networkName, err)
+			break
+		}
+	}
+	if err == nil {
+		err = m.cli.ContainerStart(ctx, created.ID, container.StartOptions{})
+	}
+	if err != nil {
+		if rmErr := m.cli.ContainerRemove(ctx, created.ID, container.RemoveOptions{Force: true}); rmErr != nil {
+			slog.Warn("Failed to remove new container", "container", name, "error", rmErr)
+		}
+		m.restoreContainer(ctx, info.ID, name)
+		return "", err
+	}
+
+	if err := m.cli.ContainerRemove(ctx, info.ID, container.RemoveOptions{}); err != nil {
+		slog.Warn("Failed to remove replaced container", "container", oldName, "error", err)
+	}
+	return created.ID[:12], nil
+}
+
+// restoreContainer renames a container back (unless name is empty) and restarts it
+func (m *Monitor) restoreContainer(ctx context.Context, containerID, name string) {
+	if name != "" {
+		if err := m.cli.ContainerRename(ctx, containerID, name); err != nil {
+			slog.Warn("Failed to rename container back", "container", name, "error", err)
+		}
+	}
+	if err := m.cli.ContainerStart(ctx, containerID, container.StartOptions{}); err != nil {
+		slog.Warn("Failed to restart container", "container", containerID, "error", err)
+	}
+}
+
+// anonymousVolumes returns mounts of the volumes of a container that are not
+// configured in its host config (e.g. image VOLUMEs), so that a recreated
+// container keeps their data instead of getting new empty volumes
+func anonymousVolumes(info *container.InspectResponse) []mount.Mount {
+	configured := make(map[string]bool)
+	for _, bind := range info.HostConfig.Binds {
+		if parts := strings.Split(bind, ":"); len(parts) >= 2 {
+			configured[parts[1]] = true
+		}
+	}
+	for _, m := range info.HostConfig.Mounts {
+		configured[m.Target] = true
+	}
+
+	var mounts []mount.Mount
+	for _, mp := range info.Mounts {
+		if mp.Type != mount.TypeVolume || mp.Name == "" || configured[mp.Destination] {
+			continue
+		}
+		mounts = append(mounts, mount.Mount{
+			Type:     mount.TypeVolume,
+			Source:   mp.Name,
+			Target:   mp.Destination,
+			ReadOnly: !mp.RW,
+		})
+	}
+	return mounts
+}
+
+// containerEndpoints returns the network of the container's network mode and the
+// endpoint settings of all its networks, without operational data
+func containerEndpoints(info *container.InspectResponse) (string, map[string]*network.EndpointSettings) {
+	primary := string(info.HostConfig.NetworkMode)
+	if primary == "default" {
+		primary = "bridge"
+	}
+	endpoints := make(map[string]*network.EndpointSettings)
+	if info.NetworkSettings == nil || info.HostConfig.NetworkMode.IsHost() ||
+		info.HostConfig.NetworkMode.IsNone() || info.HostConfig.NetworkMode.IsContainer() {
+		return primary, endpoints
+	}
+
+	shortID := info.ID[:12]
+	for networkName, endpoint := range info.NetworkSettings.Networks {
+		if endpoint == nil {
+			continue
+		}
+		endpoints[networkName] = &network.EndpointSettings{
+			IPAMConfig: endpoint.IPAMConfig,
+			Links:      endpoint.Links,
+			Aliases:    slices.DeleteFunc(slices.Clone(endpoint.Aliases), func(a string) bool { return a == shortID }),
+			DriverOpts: endpoint.DriverOpts,
+			GwPriority: endpoint.GwPriority,
+		}
+	}
+	return primary, endpoints
+}
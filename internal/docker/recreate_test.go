@@ -0,0 +1,225 @@
+package docker
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+)
+
+const testContainerID = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+// newTestInspect creates inspect data of a container with the given network mode
+func newTestInspect(mode container.NetworkMode) *container.InspectResponse {
+	return &container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			ID:         testContainerID,
+			HostConfig: &container.HostConfig{NetworkMode: mode},
+		},
+		Config:          &container.Config{},
+		NetworkSettings: &container.NetworkSettings{},
+	}
+}
+
+// TestUpdateEnv tests applying config wizard values to the environment of a container
+func TestUpdateEnv(t *testing.T) {
+	env := []string{"TZ=UTC", "TZONE=keep", "PUID=1000", "DB_PASSWORD=old"}
+	allowed := []string{"TZ", "DB_PASSWORD", "LOG_LEVEL"}
+
+	tests := []struct {
+		name        string
+		values      map[string]string
+		wantEnv     []string
+		wantChanged []string
+	}{
+		{
+			name:        "replace",
+			values:      map[string]string{"TZ": "Asia/Shanghai"},
+			wantEnv:     []string{"TZ=Asia/Shanghai", "TZONE=keep", "PUID=1000", "DB_PASSWORD=old"},
+			wantChanged: []string{"TZ=Asia/Shanghai"},
+		},
+		{
+			name:        "add",
+			values:      map[string]string{"LOG_LEVEL": "debug"},
+			wantEnv:     []string{"TZ=UTC", "TZONE=keep", "PUID=1000", "DB_PASSWORD=old", "LOG_LEVEL=debug"},
+			wantChanged: []string{"LOG_LEVEL=debug"},
+		},
+		{
+			name:    "unchanged",
+			values:  map[string]string{"TZ": "UTC"},
+			wantEnv: env,
+		},
+		{
+			name:    "not allowed",
+			values:  map[string]string{"PUID": "0", "TZONE": "x", "LD_PRELOAD": "/tmp/evil.so"},
+			wantEnv: env,
+		},
+		{
+			name:        "mixed, sorted by key",
+			values:      map[string]string{"TZ": "Europe/Berlin", "DB_PASSWORD": "new", "PUID": "0"},
+			wantEnv:     []string{"TZ=Europe/Berlin", "TZONE=keep", "PUID=1000", "DB_PASSWORD=new"},
+			wantChanged: []string{"DB_PASSWORD=new", "TZ=Europe/Berlin"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotEnv, gotChanged := updateEnv(env, tt.values, allowed)
+			if !reflect.DeepEqual(gotEnv, tt.wantEnv) {
+				t.Errorf("env = %v, want %v", gotEnv, tt.wantEnv)
+			}
+			if !reflect.DeepEqual(gotChanged, tt.wantChanged) {
+				t.Errorf("changed = %v, want %v", gotChanged, tt.wantChanged)
+			}
+		})
+	}
+
+	if env[0] != "TZ=UTC" {
+		t.Errorf("updateEnv() modified its input: %v", env)
+	}
+}
+
+// TestAnonymousVolumes tests finding the volumes a recreated container must mount explicitly
+func TestAnonymousVolumes(t *testing.T) {
+	tests := []struct {
+		name   string
+		binds  []string
+		mounts []mount.Mount
+		points []container.MountPoint
+		want   []mount.Mount
+	}{
+		{
+			name:   "bind mount",
+			binds:  []string{"/srv/app:/data:ro"},
+			points: []container.MountPoint{{Type: mount.TypeBind, Source: "/srv/app", Destination: "/data"}},
+		},
+		{
+			name:   "named volume in binds",
+			binds:  []string{"app_config:/config"},
+			points: []container.MountPoint{{Type: mount.TypeVolume, Name: "app_config", Destination: "/config", RW: true}},
+		},
+		{
+			name:   "volume in mounts",
+			mounts: []mount.Mount{{Type: mount.TypeVolume, Source: "app_data", Target: "/data"}},
+			points: []container.MountPoint{{Type: mount.TypeVolume, Name: "app_data", Destination: "/data", RW: true}},
+		},
+		{
+			name: "image volumes",
+			points: []container.MountPoint{
+				{Type: mount.TypeVolume, Name: "3f2a", Destination: "/cache", RW: true},
+				{Type: mount.TypeVolume, Name: "9b1c", Destination: "/var/lib/app", RW: false},
+			},
+			want: []mount.Mount{
+				{Type: mount.TypeVolume, Source: "3f2a", Target: "/cache"},
+				{Type: mount.TypeVolume, Source: "9b1c", Target: "/var/lib/app", ReadOnly: true},
+			},
+		},
+		{
+			name:  "mixed",
+			binds: []string{"/srv/app:/data"},
+			points: []container.MountPoint{
+				{Type: mount.TypeBind, Source: "/srv/app", Destination: "/data", RW: true},
+				{Type: mount.TypeVolume, Name: "3f2a", Destination: "/cache", RW: true},
+				{Type: mount.TypeTmpfs, Destination: "/tmp", RW: true},
+			},
+			want: []mount.Mount{{Type: mount.TypeVolume, Source: "3f2a", Target: "/cache"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := newTestInspect("default")
+			info.HostConfig.Binds = tt.binds
+			info.HostConfig.Mounts = tt.mounts
+			info.Mounts = tt.points
+			if got := anonymousVolumes(info); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("anonymousVolumes() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestContainerEndpoints tests the networks a recreated container is attached to
+func TestContainerEndpoints(t *testing.T) {
+	shortID := testContainerID[:12]
+	ipam := &network.EndpointIPAMConfig{IPv4Address: "172.20.0.10"}
+
+	tests := []struct {
+		name        string
+		mode        container.NetworkMode
+		networks    map[string]*network.EndpointSettings
+		wantPrimary string
+		want        map[string]*network.EndpointSettings
+	}{
+		{
+			name: "default bridge",
+			mode: "default",
+			networks: map[string]*network.EndpointSettings{
+				"bridge": {NetworkID: "n1", IPAddress: "172.17.0.2", MacAddress: "02:42:ac:11:00:02"},
+			},
+			wantPrimary: "bridge",
+			want:        map[string]*network.EndpointSettings{"bridge": {}},
+		},
+		{
+			name: "primary and secondary networks",
+			mode: "app_default",
+			networks: map[string]*network.EndpointSettings{
+				"app_default": {
+					NetworkID:  "n2",
+					IPAddress:  "172.20.0.10",
+					IPAMConfig: ipam,
+					Aliases:    []string{"web", shortID},
+					DriverOpts: map[string]string{"com.example.opt": "1"},
+				},
+				"proxy": {
+					NetworkID:  "n3",
+					Aliases:    []string{shortID, "app"},
+					Links:      []string{"db:db"},
+					GwPriority: 10,
+				},
+				"stale": nil,
+			},
+			wantPrimary: "app_default",
+			want: map[string]*network.EndpointSettings{
+				"app_default": {IPAMConfig: ipam, Aliases: []string{"web"}, DriverOpts: map[string]string{"com.example.opt": "1"}},
+				"proxy":       {Aliases: []string{"app"}, Links: []string{"db:db"}, GwPriority: 10},
+			},
+		},
+		{
+			name:        "host",
+			mode:        "host",
+			networks:    map[string]*network.EndpointSettings{"host": {NetworkID: "n4"}},
+			wantPrimary: "host",
+			want:        map[string]*network.EndpointSettings{},
+		},
+		{
+			name:        "none",
+			mode:        "none",
+			networks:    map[string]*network.EndpointSettings{"none": {NetworkID: "n5"}},
+			wantPrimary: "none",
+			want:        map[string]*network.EndpointSettings{},
+		},
+		{
+			name:        "container",
+			mode:        "container:vpn",
+			wantPrimary: "container:vpn",
+			want:        map[string]*network.EndpointSettings{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := newTestInspect(tt.mode)
+			info.NetworkSettings.Networks = tt.networks
+			primary, endpoints := containerEndpoints(info)
+			if primary != tt.wantPrimary {
+				t.Errorf("primary = %q, want %q", primary, tt.wantPrimary)
+			}
+			if !reflect.DeepEqual(endpoints, tt.want) {
+				t.Errorf("endpoints = %+v, want %+v", endpoints, tt.want)
+			}
+		})
+	}
+}
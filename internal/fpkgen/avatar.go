@@ -0,0 +1,295 @@
+package fpkgen
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	avatarSize       = 256 // Size of generated icons (the largest icon size)
+	avatarRadius     = 48  // Corner radius of the background
+	avatarLetterSize = 150 // Font size of a single initial
+	avatarPairSize   = 112 // Font size of two initials
+	avatarEmojiSize  = 160 // Font size of emoji
+	maxAvatarLetters = 2
+)
+
+// avatarPalette holds the background colors of generated icons (white text stays readable)
+var avatarPalette = []color.RGBA{
+	{0xE5, 0x39, 0x35, 0xFF}, // Red
+	{0xD8, 0x1B, 0x60, 0xFF}, // Pink
+	{0x8E, 0x24, 0xAA, 0xFF}, // Purple
+	{0x5E, 0x35, 0xB1, 0xFF}, // Deep purple
+	{0x39, 0x49, 0xAB, 0xFF}, // Indigo
+	{0x1E, 0x88, 0xE5, 0xFF}, // Blue
+	{0x03, 0x9B, 0xE5, 0xFF}, // Light blue
+	{0x00, 0xAC, 0xC1, 0xFF}, // Cyan
+	{0x00, 0x89, 0x7B, 0xFF}, // Teal
+	{0x43, 0xA0, 0x47, 0xFF}, // Green
+	{0x7C, 0xB3, 0x42, 0xFF}, // Light green
+	{0xF4, 0x51, 0x1E, 0xFF}, // Deep orange
+	{0xFB, 0x8C, 0x00, 0xFF}, // Orange
+	{0x6D, 0x4C, 0x41, 0xFF}, // Brown
+	{0x54, 0x6E, 0x7A, 0xFF}, // Blue grey
+	{0x75, 0x75, 0x75, 0xFF}, // Grey
+}
+
+// embeddedFont is the built-in font of generated icons (Go Bold, Latin/Greek/Cyrillic)
+var embeddedFont = sync.OnceValues(func() (*sfnt.Font, error) {
+	return opentype.Parse(gobold.TTF)
+})
+
+// emojiTTF is the built-in emoji font: silhouettes of Noto Color Emoji (see fonts/generate)
+//
+//go:embed fonts/emoji.ttf
+var emojiTTF []byte
+
+// embeddedEmojiFont is the built-in font of single code point emoji
+var embeddedEmojiFont = sync.OnceValues(func() (*sfnt.Font, error) {
+	return opentype.Parse(emojiTTF)
+})
+
+// iconFonts renders generated icons with the embedded fonts, falling back to
+// extra fonts (e.g. CJK fonts) for glyphs they do not have.
+// A nil *iconFonts uses the embedded font only.
+type iconFonts struct {
+	extra []*sfnt.Font
+}
+
+// loadIconFonts loads extra TTF/OTF fonts or font collections (TTC).
+// Fonts that fail to load are skipped and reported in the returned error.
+func loadIconFonts(paths []string) (*iconFonts, error) {
+	fonts := &iconFonts{}
+	var errs []error
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		collection, err := opentype.ParseCollection(data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to parse font %s: %w", path, err))
+			continue
+		}
+		for i := 0; i < collection.NumFonts(); i++ {
+			f, err := collection.Font(i)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to parse font %s: %w", path, err))
+				continue
+			}
+			fonts.extra = append(fonts.extra, f)
+		}
+	}
+	return fonts, errors.Join(errs...)
+}
+
+// fontFor returns the first font with a glyph for r (nil if none)
+func (f *iconFonts) fontFor(r rune) *sfnt.Font {
+	fonts := make([]*sfnt.Font, 0, 2)
+	for _, load := range []func() (*sfnt.Font, error){embeddedFont, embeddedEmojiFont} {
+		if embedded, err := load(); err == nil {
+			fonts = append(fonts, embedded)
+		}
+	}
+	if f != nil {
+		fonts = append(fonts, f.extra...)
+	}
+
+	var buf sfnt.Buffer
+	for _, sf := range fonts {
+		if idx, err := sf.GlyphIndex(&buf, r); err == nil && idx != 0 {
+			return sf
+		}
+	}
+	return nil
+}
+
+// letterAvatar generates an icon with the initials of name on a background color derived from key
+func (f *iconFonts) letterAvatar(name, key string) (image.Image, error) {
+	initials := f.avatarInitials(name)
+	if len(initials) == 0 {
+		return nil, fmt.Errorf("no renderable letters in %q", name)
+	}
+
+	size := float64(avatarLetterSize)
+	if len(initials) > 1 {
+		size = avatarPairSize
+	}
+	return f.renderIcon(initials, size, avatarColor(key))
+}
+
+// avatarInitials picks up to two initials of the words of name that a font can render,
+// e.g. "Home Assistant" -> "HA", "jellyfin" -> "J", "飞牛相册" -> "飞"
+func (f *iconFonts) avatarInitials(name string) []rune {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var initials []rune
+	for _, word := range words {
+		for _, r := range word {
+			if f.fontFor(r) == nil {
+				continue
+			}
+			// Ideographs fill the icon on their own
+			if isWideRune(r) {
+				if len(initials) == 0 {
+					return []rune{r}
+				}
+				return initials
+			}
+			initials = append(initials, unicode.ToUpper(r))
+			break
+		}
+		if len(initials) == maxAvatarLetters {
+			break
+		}
+	}
+	return initials
+}
+
+// isWideRune reports whether r is a CJK character
+func isWideRune(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hangul, unicode.Hiragana, unicode.Katakana)
+}
+
+// emojiIcon generates an icon for an emoji: source (e.g. emoji:📚) on a background color derived from key.
+// Fonts render emoji in monochrome; ZWJ sequences, skin tones and flags use their first emoji.
+func (f *iconFonts) emojiIcon(source, key string) (image.Image, error) {
+	text := strings.TrimSpace(strings.TrimPrefix(source, "emoji:"))
+
+	var emoji rune
+	for _, r := range text {
+		if !isEmojiModifier(r) {
+			emoji = r
+			break
+		}
+	}
+	if emoji == 0 {
+		return nil, fmt.Errorf("empty emoji icon: %s", source)
+	}
+	if f.fontFor(emoji) == nil {
+		return nil, fmt.Errorf("no font has a glyph for %q (add a font with -icon-fonts)", string(emoji))
+	}
+
+	return f.renderIcon([]rune{emoji}, avatarEmojiSize, avatarColor(key))
+}
+
+// isEmojiModifier reports whether r only modifies the preceding emoji
+func isEmojiModifier(r rune) bool {
+	return r == 0xFE0E || r == 0xFE0F || // Variation selectors
+		r == 0x200D || // Zero width joiner
+		(r >= 0x1F3FB && r <= 0x1F3FF) || // Skin tones
+		unicode.IsSpace(r)
+}
+
+// avatarColor picks a deterministic background color for key (e.g. the appname)
+func avatarColor(key string) color.RGBA {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return avatarPalette[h.Sum32()%uint32(len(avatarPalette))]
+}
+
+// renderIcon draws text in white, centered on a rounded square of color bg
+func (f *iconFonts) renderIcon(text []rune, size float64, bg color.RGBA) (image.Image, error) {
+	dst := roundedSquare(avatarSize, avatarRadius, bg)
+	if err := f.drawText(dst, text, size, avatarSize); err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
+
+// textGlyph is a glyph laid out on a line of text
+type textGlyph struct {
+	face font.Face
+	r    rune
+	x    fixed.Int26_6 // Pen position
+}
+
+// layoutText lays out text on one line and measures its ink bounds (baseline at y = 0)
+func (f *iconFonts) layoutText(text []rune, size float64) ([]textGlyph, fixed.Rectangle26_6, error) {
+	var glyphs []textGlyph
+	var ink fixed.Rectangle26_6
+	var pen fixed.Int26_6
+	for _, r := range text {
+		sf := f.fontFor(r)
+		if sf == nil {
+			return nil, ink, fmt.Errorf("no font has a glyph for %q", string(r))
+		}
+		face, err := opentype.NewFace(sf, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingNone})
+		if err != nil {
+			return nil, ink, fmt.Errorf("failed to create font face: %w", err)
+		}
+
+		bounds, advance, ok := face.GlyphBounds(r)
+		if !ok {
+			return nil, ink, fmt.Errorf("no font has a glyph for %q", string(r))
+		}
+		bounds = bounds.Add(fixed.Point26_6{X: pen})
+		if len(glyphs) == 0 {
+			ink = bounds
+		} else {
+			ink = ink.Union(bounds)
+		}
+		glyphs = append(glyphs, textGlyph{face: face, r: r, x: pen})
+		pen += advance
+	}
+	return glyphs, ink, nil
+}
+
+// drawText draws text in white centered on dst, shrinking the font size to fit maxWidth
+func (f *iconFonts) drawText(dst *image.RGBA, text []rune, size float64, maxWidth int) error {
+	glyphs, ink, err := f.layoutText(text, size)
+	if err != nil {
+		return err
+	}
+	if width := (ink.Max.X - ink.Min.X).Ceil(); width > maxWidth {
+		glyphs, ink, err = f.layoutText(text, size*float64(maxWidth)/float64(width))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Center the ink bounds
+	b := dst.Bounds()
+	origin := fixed.Point26_6{
+		X: fixed.I(b.Min.X+b.Max.X)/2 - (ink.Min.X+ink.Max.X)/2,
+		Y: fixed.I(b.Min.Y+b.Max.Y)/2 - (ink.Min.Y+ink.Max.Y)/2,
+	}
+	for _, g := range glyphs {
+		dot := origin.Add(fixed.Point26_6{X: g.x})
+		dr, mask, maskp, _, ok := g.face.Glyph(dot, g.r)
+		if !ok {
+			continue
+		}
+		draw.DrawMask(dst, dr, image.White, image.Point{}, mask, maskp, draw.Over)
+	}
+	return nil
+}
+
+// roundedSquare creates a size x size image filled with c, with anti-aliased rounded corners
+func roundedSquare(size, radius int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.DrawMask(img, img.Bounds(), image.NewUniform(c), image.Point{}, roundedMask(size, float64(radius)), image.Point{}, draw.Src)
+	return img
+}
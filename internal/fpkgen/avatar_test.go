@@ -0,0 +1,234 @@
+package fpkgen
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/quick"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// inkBounds returns the bounds of the white (text) pixels of an icon
+func inkBounds(img image.Image) image.Rectangle {
+	var ink image.Rectangle
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			if r > 0xF000 && g > 0xF000 && bl > 0xF000 {
+				ink = ink.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return ink
+}
+
+// TestAvatarInitials tests picking initials from display names
+func TestAvatarInitials(t *testing.T) {
+	var fonts *iconFonts
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Home Assistant", "HA"},
+		{"jellyfin", "J"},
+		{"my-cool_app server", "MC"},
+		{"  qBittorrent  ", "Q"},
+		{"123 go", "1G"},
+		{"飞牛 Photos", "P"}, // No CJK glyphs in the embedded font
+		{"飞牛相册", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := string(fonts.avatarInitials(tt.name)); got != tt.want {
+			t.Errorf("avatarInitials(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestAvatarColor_Deterministic tests that colors are stable and from the palette
+func TestAvatarColor_Deterministic(t *testing.T) {
+	inPalette := func(c color.RGBA) bool {
+		for _, p := range avatarPalette {
+			if p == c {
+				return true
+			}
+		}
+		return false
+	}
+
+	f := func(key string) bool {
+		c := avatarColor(key)
+		return c == avatarColor(key) && inPalette(c)
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+
+	// Different apps get different colors
+	colors := make(map[color.RGBA]bool)
+	for _, key := range []string{"watchcow.jellyfin", "watchcow.gitea", "watchcow.memos", "watchcow.nginx"} {
+		colors[avatarColor(key)] = true
+	}
+	if len(colors) < 2 {
+		t.Error("expected different colors for different apps")
+	}
+}
+
+// TestLetterAvatar tests rendering of letter avatars
+func TestLetterAvatar(t *testing.T) {
+	var fonts *iconFonts
+
+	for _, name := range []string{"Jellyfin", "Home Assistant", "W"} {
+		t.Run(name, func(t *testing.T) {
+			img, err := fonts.letterAvatar(name, "watchcow.test")
+			if err != nil {
+				t.Fatalf("letterAvatar() error = %v", err)
+			}
+			if img.Bounds() != image.Rect(0, 0, avatarSize, avatarSize) {
+				t.Fatalf("unexpected bounds %v", img.Bounds())
+			}
+
+			// Transparent rounded corners, colored background
+			if _, _, _, a := img.At(0, 0).RGBA(); a != 0 {
+				t.Error("expected transparent corner")
+			}
+			bg := avatarColor("watchcow.test")
+			if got := color.RGBAModel.Convert(img.At(avatarSize/2, 4)).(color.RGBA); got != bg {
+				t.Errorf("background = %v, want %v", got, bg)
+			}
+
+			// Text is centered
+			ink := inkBounds(img)
+			if ink.Empty() {
+				t.Fatal("no text rendered")
+			}
+			cx, cy := (ink.Min.X+ink.Max.X)/2, (ink.Min.Y+ink.Max.Y)/2
+			if abs(cx-avatarSize/2) > 3 || abs(cy-avatarSize/2) > 3 {
+				t.Errorf("text center = (%d, %d), ink %v", cx, cy, ink)
+			}
+		})
+	}
+
+	if _, err := fonts.letterAvatar("飞牛相册", "watchcow.test"); err == nil {
+		t.Error("expected error without a font for the name")
+	}
+}
+
+// TestEmojiIcon tests the emoji: icon scheme
+func TestEmojiIcon(t *testing.T) {
+	var fonts *iconFonts
+
+	// ☺ is in the embedded font, the variation selector is ignored
+	img, err := fonts.emojiIcon("emoji:☺️", "watchcow.test")
+	if err != nil {
+		t.Fatalf("emojiIcon() error = %v", err)
+	}
+	if inkBounds(img).Empty() {
+		t.Error("no emoji rendered")
+	}
+
+	// 📚 is in the embedded emoji font, skin tones and ZWJ sequences use the first emoji
+	for _, source := range []string{"emoji:📚", "emoji:👍🏽", "emoji:🧑‍💻"} {
+		img, err := fonts.emojiIcon(source, "watchcow.test")
+		if err != nil {
+			t.Fatalf("emojiIcon(%q) error = %v", source, err)
+		}
+		ink := inkBounds(img)
+		if ink.Dx() < avatarSize/3 || ink.Dy() < avatarSize/3 {
+			t.Errorf("emojiIcon(%q) ink = %v, want a glyph", source, ink)
+		}
+	}
+
+	for _, source := range []string{"emoji:", "emoji: ️", "emoji:\uE000"} {
+		if _, err := fonts.emojiIcon(source, "watchcow.test"); err == nil {
+			t.Errorf("emojiIcon(%q) expected error", source)
+		}
+	}
+
+	// Loaded through the icon loader
+	loader := &iconLoader{appName: "watchcow.test"}
+	if _, err := loader.load("emoji:♪"); err != nil {
+		t.Errorf("load(emoji:♪) error = %v", err)
+	}
+}
+
+// TestLoadIconFonts tests loading extra fonts
+func TestLoadIconFonts(t *testing.T) {
+	dir := t.TempDir()
+	fontPath := filepath.Join(dir, "Go-Regular.ttf")
+	os.WriteFile(fontPath, goregular.TTF, 0644)
+	badPath := filepath.Join(dir, "bad.ttf")
+	os.WriteFile(badPath, []byte("not a font"), 0644)
+
+	fonts, err := loadIconFonts([]string{fontPath, "", " "})
+	if err != nil {
+		t.Fatalf("loadIconFonts() error = %v", err)
+	}
+	if len(fonts.extra) != 1 {
+		t.Errorf("expected 1 extra font, got %d", len(fonts.extra))
+	}
+
+	fonts, err = loadIconFonts([]string{badPath, fontPath, filepath.Join(dir, "missing.ttf")})
+	if err == nil {
+		t.Error("expected error for invalid fonts")
+	}
+	if len(fonts.extra) != 1 {
+		t.Errorf("valid fonts should still load, got %d", len(fonts.extra))
+	}
+}
+
+// TestIconLoader_EntryIcon tests generated icons for entries without a usable icon
+func TestIconLoader_EntryIcon(t *testing.T) {
+	loader := &iconLoader{}
+	config := &AppConfig{AppName: "watchcow.myapp", DisplayName: "My App"}
+
+	for _, source := range []string{"", fallbackIconURL, "file:///nonexistent/icon.png"} {
+		img := loadEntryIcon(loader, config, Entry{Icon: source})
+		if img == nil {
+			t.Fatalf("entryIcon(%q) = nil, want letter avatar", source)
+		}
+		if got := color.RGBAModel.Convert(img.At(avatarSize/2, 4)).(color.RGBA); got != avatarColor("watchcow.myapp") {
+			t.Errorf("entryIcon(%q) background = %v", source, got)
+		}
+	}
+
+	// Named entries get their own color key
+	img := loadEntryIcon(loader, config, Entry{Name: "admin"})
+	if got := color.RGBAModel.Convert(img.At(avatarSize/2, 4)).(color.RGBA); got != avatarColor("watchcow.myapp.admin") {
+		t.Errorf("named entry background = %v", got)
+	}
+
+	// Configured icons are used as is
+	absPath, _ := filepath.Abs(filepath.Join("testdata", "test.png"))
+	img = loadEntryIcon(loader, config, Entry{Icon: "file://" + absPath})
+	if img == nil || img.Bounds().Dx() == avatarSize {
+		t.Error("expected the configured icon")
+	}
+
+	// Nothing to render falls back to the default icon
+	if img := loadEntryIcon(loader, &AppConfig{DisplayName: "飞牛"}, Entry{}); img != nil {
+		t.Error("expected nil without renderable letters")
+	}
+}
+
+// loadEntryIcon loads the icon of a single entry, waiting for it to load
+func loadEntryIcon(loader *iconLoader, config *AppConfig, entry Entry) image.Image {
+	config.Entries = []Entry{entry}
+	icons := loader.loadIcons(config)
+	icons.wait(0)
+	img, _ := icons.entryIcon(entry)
+	return img
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,68 @@
+package fpkgen
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+const (
+	badgeScale    = 0.45 // Badge diameter relative to the icon size
+	badgeSize     = 128  // Size text badges are rendered at before scaling
+	badgeRing     = 8    // Width of the white ring separating text badges from the icon
+	badgeFontSize = 84   // Font size of a single character (longer text is shrunk to fit)
+	maxBadgeRunes = 3
+)
+
+// isIconSource reports whether a badge label is an icon source rather than text
+func isIconSource(badge string) bool {
+	return strings.Contains(badge, "://") || strings.HasPrefix(badge, "emoji:") || strings.HasPrefix(badge, "data:")
+}
+
+// badgeImage renders a text badge: short text drawn in white on a circle of
+// color bg (nil = color derived from key). Badges that are icon sources (URLs,
+// file://, container://, data:, emoji:) load with the entry icons, see iconSet.badge.
+func (l *iconLoader) badgeImage(badge string, bg color.Color, key string) (image.Image, error) {
+	badge = strings.TrimSpace(badge)
+	if badge == "" {
+		return nil, fmt.Errorf("empty badge")
+	}
+	if n := utf8.RuneCountInString(badge); n > maxBadgeRunes {
+		return nil, fmt.Errorf("badge text %q too long (max %d characters)", badge, maxBadgeRunes)
+	}
+	if bg == nil {
+		bg = avatarColor(key)
+	}
+
+	// White ring around a colored circle
+	dst := roundedSquare(badgeSize, badgeSize/2, color.RGBA{0xFF, 0xFF, 0xFF, 0xFF})
+	inner := image.Rect(badgeRing, badgeRing, badgeSize-badgeRing, badgeSize-badgeRing)
+	innerMask := roundedMask(inner.Dx(), float64(inner.Dx())/2)
+	draw.DrawMask(dst, inner, image.NewUniform(bg), image.Point{}, innerMask, image.Point{}, draw.Over)
+
+	// Keep the text inside the circle
+	text := dst.SubImage(inner).(*image.RGBA)
+	if err := l.fonts.drawText(text, []rune(badge), badgeFontSize, inner.Dx()*7/10); err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
+
+// applyBadge composites a badge over the bottom-right corner of an icon
+func applyBadge(icon, badge image.Image) image.Image {
+	b := icon.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), icon, b.Min, draw.Src)
+
+	d := int(math.Round(float64(b.Dx()) * badgeScale))
+	corner := image.Rect(b.Dx()-d, b.Dy()-d, b.Dx(), b.Dy())
+	badge = iconForSize(badge, d)
+	xdraw.CatmullRom.Scale(dst, corner, badge, badge.Bounds(), xdraw.Over, nil)
+	return dst
+}
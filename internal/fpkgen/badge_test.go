@@ -0,0 +1,203 @@
+package fpkgen
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestBadgeImage_Text tests text badges: white ring, colored circle and white text
+func TestBadgeImage_Text(t *testing.T) {
+	loader := &iconLoader{}
+	blue := color.NRGBA{0x1E, 0x88, 0xE5, 0xFF}
+
+	for _, text := range []string{"A", "99", "ADM", " ♪ "} {
+		t.Run(text, func(t *testing.T) {
+			img, err := loader.badgeImage(text, blue, "watchcow.app.admin")
+			if err != nil {
+				t.Fatalf("badgeImage(%q) error = %v", text, err)
+			}
+			if img.Bounds() != image.Rect(0, 0, badgeSize, badgeSize) {
+				t.Fatalf("unexpected bounds %v", img.Bounds())
+			}
+			if alphaAt(img, 0, 0) != 0 {
+				t.Error("expected transparent corner")
+			}
+			if got := color.RGBAModel.Convert(img.At(badgeSize/2, 2)); got != (color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}) {
+				t.Errorf("ring pixel = %v, want white", got)
+			}
+			if got := color.RGBAModel.Convert(img.At(badgeSize/2, badgeRing+4)); got != color.RGBAModel.Convert(blue) {
+				t.Errorf("circle pixel = %v, want %v", got, blue)
+			}
+
+			// Text stays inside the circle
+			ink := inkBounds(img.(*image.RGBA).SubImage(image.Rect(badgeRing+4, badgeRing+4, badgeSize-badgeRing-4, badgeSize-badgeRing-4)))
+			if ink.Empty() {
+				t.Error("no text rendered")
+			}
+		})
+	}
+
+	// Without a color the background is derived from the key
+	img, err := loader.badgeImage("A", nil, "watchcow.app.admin")
+	if err != nil {
+		t.Fatalf("badgeImage() error = %v", err)
+	}
+	if got := color.RGBAModel.Convert(img.At(badgeSize/2, badgeRing+4)); got != avatarColor("watchcow.app.admin") {
+		t.Errorf("circle pixel = %v, want derived color", got)
+	}
+}
+
+// TestBadgeImage_Errors tests invalid badges
+func TestBadgeImage_Errors(t *testing.T) {
+	loader := &iconLoader{}
+	for _, badge := range []string{"", "   ", "ADMIN", "飞"} {
+		if _, err := loader.badgeImage(badge, nil, "key"); err == nil {
+			t.Errorf("badgeImage(%q) expected error", badge)
+		}
+	}
+}
+
+// loadBadge loads the badge of an entry like package generation does
+func loadBadge(loader *iconLoader, badge string) image.Image {
+	entry := Entry{Badge: badge}
+	icons := loader.loadIcons(&AppConfig{AppName: "watchcow.app", Entries: []Entry{entry}})
+	icons.wait(0)
+	img, _ := icons.badge(entry)
+	return img
+}
+
+// TestIconSet_BadgeIconSource tests badges loaded from icon sources
+func TestIconSet_BadgeIconSource(t *testing.T) {
+	loader := &iconLoader{appName: "watchcow.app"}
+	absPath, _ := filepath.Abs(filepath.Join("testdata", "test.png"))
+
+	for _, source := range []string{"file://" + absPath, "emoji:♪", "emoji:📚"} {
+		img := loadBadge(loader, source)
+		if img == nil {
+			t.Fatalf("badge %q not loaded", source)
+		}
+		if img.Bounds().Dx() != img.Bounds().Dy() {
+			t.Errorf("badge %q not square: %v", source, img.Bounds())
+		}
+	}
+	for _, source := range []string{"file:///nonexistent.png", "emoji:\uE000"} {
+		if img := loadBadge(loader, source); img != nil {
+			t.Errorf("badge %q should fail to load", source)
+		}
+	}
+}
+
+// TestApplyBadge tests compositing a badge in the bottom-right corner
+func TestApplyBadge(t *testing.T) {
+	for _, size := range []int{64, 256} {
+		icon := solidImage(size, size, image.Rect(0, 0, size, size))
+		badge := roundedSquare(badgeSize, badgeSize/2, color.RGBA{0, 0, 0xFF, 0xFF})
+
+		got := applyBadge(icon, badge)
+		d := int(float64(size) * badgeScale)
+
+		red := color.RGBA{0xFF, 0, 0, 0xFF}
+		if c := color.RGBAModel.Convert(got.At(2, 2)); c != red {
+			t.Errorf("%dpx top-left = %v, want icon", size, c)
+		}
+		if c := color.RGBAModel.Convert(got.At(size-d/2, size-d/2)); c != (color.RGBA{0, 0, 0xFF, 0xFF}) {
+			t.Errorf("%dpx badge center = %v, want badge", size, c)
+		}
+		// Outside the round badge the icon shows through
+		if c := color.RGBAModel.Convert(got.At(size-1, size-1)); c != red {
+			t.Errorf("%dpx bottom-right corner = %v, want icon", size, c)
+		}
+		// The original icon is not modified
+		if c := color.RGBAModel.Convert(icon.At(size-d/2, size-d/2)); c != red {
+			t.Error("applyBadge() modified the icon")
+		}
+	}
+}
+
+// TestParseEntry_Badge tests badge labels on named entries
+func TestParseEntry_Badge(t *testing.T) {
+	labels := map[string]string{
+		"watchcow.badge":             "X",
+		"watchcow.badge_color":       "#ff0000",
+		"watchcow.admin.badge":       "A",
+		"watchcow.admin.badge_color": "#00ff00",
+		"watchcow.logs.badge":        "emoji:♪",
+		"watchcow.logs.badge_color":  "green",
+	}
+
+	if e := parseEntry(labels, "", "App", ""); e.Badge != "" || e.BadgeColor != nil {
+		t.Errorf("default entry badge = %q, %v, want none", e.Badge, e.BadgeColor)
+	}
+	if e := parseEntry(labels, "admin", "App", ""); e.Badge != "A" || e.BadgeColor != (color.NRGBA{0, 0xFF, 0, 0xFF}) {
+		t.Errorf("admin badge = %q, %v", e.Badge, e.BadgeColor)
+	}
+	if e := parseEntry(labels, "logs", "App", ""); e.Badge != "emoji:♪" || e.BadgeColor != nil {
+		t.Errorf("logs badge = %q, %v (invalid color should be ignored)", e.Badge, e.BadgeColor)
+	}
+}
+
+// TestHandleIcons_Badge tests that badged entries get distinct icon files
+func TestHandleIcons_Badge(t *testing.T) {
+	appDir := t.TempDir()
+	os.MkdirAll(filepath.Join(appDir, "app", "ui", "images"), 0755)
+	absPath, _ := filepath.Abs(filepath.Join("testdata", "test.png"))
+
+	config := &AppConfig{
+		AppName:     "watchcow.app",
+		DisplayName: "App",
+		Entries: []Entry{
+			{Name: "", Icon: "file://" + absPath},
+			{Name: "admin", Icon: "file://" + absPath, Badge: "A"},
+			{Name: "plain", Icon: "file://" + absPath},
+		},
+	}
+	if err := (&Generator{}).handleIcons(appDir, config); err != nil {
+		t.Fatalf("handleIcons() error = %v", err)
+	}
+
+	load := func(name string) image.Image {
+		t.Helper()
+		f, err := os.Open(filepath.Join(appDir, "app", "ui", "images", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		img, err := png.Decode(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return img
+	}
+
+	for _, size := range []string{"64", "256"} {
+		base := load("icon_" + size + ".png")
+		plain := load("icon_plain_" + size + ".png")
+		admin := load("icon_admin_" + size + ".png")
+
+		if !imagesEqual(base, plain) {
+			t.Errorf("%s: entry without badge should match the app icon", size)
+		}
+		if imagesEqual(base, admin) {
+			t.Errorf("%s: badged entry should differ from the app icon", size)
+		}
+	}
+}
+
+// imagesEqual compares the pixels of two images
+func imagesEqual(a, b image.Image) bool {
+	if a.Bounds() != b.Bounds() {
+		return false
+	}
+	for y := a.Bounds().Min.Y; y < a.Bounds().Max.Y; y++ {
+		for x := a.Bounds().Min.X; x < a.Bounds().Max.X; x++ {
+			if a.At(x, y) != b.At(x, y) {
+				return false
+			}
+		}
+	}
+	return true
+}
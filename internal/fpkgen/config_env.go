@@ -0,0 +1,187 @@
+package fpkgen
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// configEnvTypes lists the value types of config wizard fields
+var configEnvTypes = []string{"text", "password", "number", "bool", "select"}
+
+// envKeyPattern matches environment variable names usable as shell variables
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ConfigEnv is an environment variable exposed in the config wizard
+type ConfigEnv struct {
+	Key         string   // Environment variable name
+	Type        string   // text, password, number, bool or select
+	Label       string   // Field label (defaults to Key)
+	Description string   // Help text shown above the field
+	Options     []string // Choices of select fields
+	Value       string   // Current value (empty for secrets)
+	Secret      bool     // Value is a secret: not shown, kept unless a new value is entered
+}
+
+// Field returns the wizard field name, exported to config_callback as environment variable
+func (e ConfigEnv) Field() string {
+	return "wizard_env_" + e.Key
+}
+
+// ConfigEnvKeys returns the environment variables a container exposes in the
+// config wizard (watchcow.config.env=KEY1,KEY2), invalid names are skipped
+func ConfigEnvKeys(labels map[string]string) []string {
+	var keys []string
+	for _, key := range splitList(labels["watchcow.config.env"]) {
+		if slices.Contains(keys, key) {
+			continue
+		}
+		if !envKeyPattern.MatchString(key) {
+			slog.Warn("Ignoring invalid environment variable name", "label", "watchcow.config.env", "name", key)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// parseConfigEnv reads the config wizard fields of an app:
+//
+//	watchcow.config.env                 -> KEY1,KEY2 (opt-in, in wizard order)
+//	watchcow.config.env.<KEY>.type      -> text (default), password, number, bool or select
+//	watchcow.config.env.<KEY>.label     -> field label (default KEY)
+//	watchcow.config.env.<KEY>.desc      -> help text
+//	watchcow.config.env.<KEY>.options   -> comma-separated choices of select fields
+//
+// Current values are taken from env (KEY=VALUE), except for secrets (see secretEnv)
+// which are entered as passwords and left unchanged when empty.
+func parseConfigEnv(labels map[string]string, env []string) []ConfigEnv {
+	var fields []ConfigEnv
+	secrets := newSecretEnv(labels)
+	for _, key := range ConfigEnvKeys(labels) {
+		prefix := "watchcow.config.env." + key + "."
+		field := ConfigEnv{
+			Key:         key,
+			Type:        getLabel(labels, prefix+"type", "text"),
+			Label:       getLabel(labels, prefix+"label", key),
+			Description: labels[prefix+"desc"],
+		}
+		for _, e := range env {
+			if value, ok := strings.CutPrefix(e, key+"="); ok {
+				field.Value = value
+			}
+		}
+
+		if !slices.Contains(configEnvTypes, field.Type) {
+			slog.Warn("Unknown config field type, using text", "label", prefix+"type", "type", field.Type)
+			field.Type = "text"
+		}
+		switch field.Type {
+		case "bool":
+			enabled, _ := strconv.ParseBool(field.Value)
+			field.Value = strconv.FormatBool(enabled)
+		case "select":
+			field.Options = splitList(labels[prefix+"options"])
+			if len(field.Options) == 0 {
+				slog.Warn("Select config field without options, using text", "label", prefix+"options")
+				field.Type = "text"
+			}
+		}
+		if secrets.hidden(key) {
+			field.Secret = true
+			field.Value = ""
+			if field.Type == "text" {
+				field.Type = "password"
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// ConfigRequest asks WatchCow to recreate the container of an app with new
+// environment values, written by the config_callback script
+type ConfigRequest struct {
+	AppName     string
+	ContainerID string
+	Env         map[string]string // KEY -> new value
+}
+
+// configRequestFile returns the file the config callback of an app writes its
+// request to. Returns empty string without a state directory.
+func configRequestFile(stateDir, appName string) string {
+	return stateFile(stateDir, "config", appName)
+}
+
+// parseConfigRequest parses a request file: WATCHCOW_CONTAINER_ID=<id> followed by KEY=VALUE lines
+func parseConfigRequest(appName string, data []byte) (*ConfigRequest, error) {
+	req := &ConfigRequest{AppName: appName, Env: make(map[string]string)}
+	for i, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		// Values may be secrets, errors only mention the line number
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || !envKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("invalid line %d", i+1)
+		}
+		if key == "WATCHCOW_CONTAINER_ID" {
+			req.ContainerID = value
+		} else {
+			req.Env[key] = value
+		}
+	}
+	if req.ContainerID == "" {
+		return nil, fmt.Errorf("missing WATCHCOW_CONTAINER_ID")
+	}
+	return req, nil
+}
+
+// TakeConfigRequests returns and removes the pending config wizard requests
+func (g *Generator) TakeConfigRequests() []*ConfigRequest {
+	if g.stateDir == "" {
+		return nil
+	}
+	dir := filepath.Join(g.stateDir, "config")
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		slog.Warn("Failed to read config requests", "dir", dir, "error", err)
+		return nil
+	}
+
+	var requests []*ConfigRequest
+	for _, file := range files {
+		// Requests are written to <name>.tmp and renamed when complete
+		if !file.Type().IsRegular() || strings.HasSuffix(file.Name(), ".tmp") {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		data, err := os.ReadFile(path)
+		if err == nil {
+			err = os.Remove(path)
+		}
+		if err != nil {
+			slog.Warn("Failed to take config request", "file", path, "error", err)
+			continue
+		}
+
+		appName, err := url.PathUnescape(file.Name())
+		if err != nil {
+			slog.Warn("Ignoring config request", "file", path, "error", err)
+			continue
+		}
+		req, err := parseConfigRequest(appName, data)
+		if err != nil {
+			slog.Warn("Ignoring invalid config request", "file", path, "error", err)
+			continue
+		}
+		requests = append(requests, req)
+	}
+	return requests
+}
@@ -0,0 +1,165 @@
+package fpkgen
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParseConfigEnv tests config wizard fields, their defaults and invalid labels
+func TestParseConfigEnv(t *testing.T) {
+	labels := map[string]string{
+		"watchcow.config.env":                   "TZ, DEBUG,LOG_LEVEL,bad-name,PORT,TZ,MODE",
+		"watchcow.config.env.TZ.label":          "Time zone",
+		"watchcow.config.env.TZ.desc":           "e.g. Asia/Shanghai",
+		"watchcow.config.env.DEBUG.type":        "bool",
+		"watchcow.config.env.LOG_LEVEL.type":    "select",
+		"watchcow.config.env.LOG_LEVEL.options": "info, debug,,warn",
+		"watchcow.config.env.PORT.type":         "integer",
+		"watchcow.config.env.MODE.type":         "select",
+	}
+	env := []string{"TZ=Asia/Shanghai", "DEBUG=1", "LOG_LEVEL=debug", "PATH=/bin", "MODE=a=b"}
+
+	want := []ConfigEnv{
+		{Key: "TZ", Type: "text", Label: "Time zone", Description: "e.g. Asia/Shanghai", Value: "Asia/Shanghai"},
+		{Key: "DEBUG", Type: "bool", Label: "DEBUG", Value: "true"},
+		{Key: "LOG_LEVEL", Type: "select", Label: "LOG_LEVEL", Options: []string{"info", "debug", "warn"}, Value: "debug"},
+		{Key: "PORT", Type: "text", Label: "PORT"},
+		{Key: "MODE", Type: "text", Label: "MODE", Value: "a=b"},
+	}
+	if got := parseConfigEnv(labels, env); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseConfigEnv() = %+v, want %+v", got, want)
+	}
+	if got := parseConfigEnv(map[string]string{"watchcow.enable": "true"}, env); got != nil {
+		t.Errorf("parseConfigEnv() without watchcow.config.env = %+v", got)
+	}
+}
+
+// TestTakeConfigRequests tests reading, removing and validating config requests
+func TestTakeConfigRequests(t *testing.T) {
+	g := &Generator{stateDir: t.TempDir()}
+	if err := createStateDir(g.stateDir); err != nil {
+		t.Fatal(err)
+	}
+	write := func(appName, content string) {
+		os.WriteFile(configRequestFile(g.stateDir, appName), []byte(content), 0644)
+	}
+	write("watchcow.app/x", "WATCHCOW_CONTAINER_ID=abc123\nTZ=Europe/Berlin\nEMPTY=\nURL=http://x/?a=b\n")
+	write("watchcow.partial.tmp", "WATCHCOW_CONTAINER_ID=abc123\n")
+	write("watchcow.noid", "TZ=UTC\n")
+	write("watchcow.bad", "WATCHCOW_CONTAINER_ID=abc123\n$(reboot)=1\n")
+
+	requests := g.TakeConfigRequests()
+	want := []*ConfigRequest{{
+		AppName:     "watchcow.app/x",
+		ContainerID: "abc123",
+		Env:         map[string]string{"TZ": "Europe/Berlin", "EMPTY": "", "URL": "http://x/?a=b"},
+	}}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("TakeConfigRequests() = %+v, want %+v", requests, want)
+	}
+
+	// Taken and invalid requests are removed, incomplete ones are kept
+	files, _ := os.ReadDir(filepath.Join(g.stateDir, "config"))
+	if len(files) != 1 || files[0].Name() != "watchcow.partial.tmp" {
+		t.Errorf("config requests left: %v", files)
+	}
+	if requests := (&Generator{}).TakeConfigRequests(); requests != nil {
+		t.Errorf("TakeConfigRequests() without state dir = %v", requests)
+	}
+}
+
+// TestGenerateFromConfig_ConfigWizard tests the config wizard and the request written by config_callback
+func TestGenerateFromConfig_ConfigWizard(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+	engine, err := NewTemplateEngine()
+	if err != nil {
+		t.Fatalf("NewTemplateEngine() error = %v", err)
+	}
+	g := &Generator{templateEngine: engine, stateDir: t.TempDir()}
+	if err := createStateDir(g.stateDir); err != nil {
+		t.Fatal(err)
+	}
+
+	appDir := filepath.Join(t.TempDir(), "app")
+	config := &AppConfig{
+		AppName:       "watchcow.app",
+		DisplayName:   "App",
+		ContainerID:   "abc123",
+		ContainerName: "app",
+		ConfigEnv: []ConfigEnv{
+			{Key: "TZ", Type: "text", Label: "Time zone", Description: `"quoted"`, Value: "UTC"},
+			{Key: "WORKERS", Type: "number", Label: "WORKERS", Value: "4"},
+			{Key: "DEBUG", Type: "bool", Label: "DEBUG", Value: "false"},
+			{Key: "LEVEL", Type: "select", Label: "LEVEL", Options: []string{"info", "debug"}, Value: "info"},
+		},
+	}
+	if err := g.GenerateFromConfig(config, appDir); err != nil {
+		t.Fatalf("GenerateFromConfig() error = %v", err)
+	}
+
+	var wizard []struct {
+		Items []struct {
+			Type      string
+			Field     string
+			InitValue string
+			Options   []struct{ Value string }
+			Rules     []struct{ Pattern string }
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(appDir, "wizard", "config"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &wizard); err != nil {
+		t.Fatalf("wizard/config is not valid JSON: %v\n%s", err, data)
+	}
+	var items []string
+	for _, item := range wizard[0].Items {
+		items = append(items, item.Type+":"+item.Field+"="+item.InitValue)
+	}
+	want := "tips:=,tips:=,text:wizard_env_TZ=UTC,text:wizard_env_WORKERS=4,switch:wizard_env_DEBUG=false,radio:wizard_env_LEVEL=info"
+	if got := strings.Join(items, ","); got != want {
+		t.Errorf("wizard items = %s, want %s", got, want)
+	}
+	if rules := wizard[0].Items[3].Rules; len(rules) != 1 || rules[0].Pattern == "" {
+		t.Errorf("number field rules = %+v", rules)
+	}
+	if options := wizard[0].Items[5].Options; len(options) != 2 {
+		t.Errorf("select field options = %+v", options)
+	}
+
+	cmd := exec.Command(bash, filepath.Join(appDir, "cmd", "config_callback"))
+	cmd.Env = append(os.Environ(), "wizard_env_TZ=Europe/Berlin", "wizard_env_DEBUG=true", "wizard_env_LEVEL=two\nlines")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("running cmd/config_callback: %v\n%s", err, out)
+	}
+	requests := g.TakeConfigRequests()
+	wantReq := []*ConfigRequest{{
+		AppName:     "watchcow.app",
+		ContainerID: "abc123",
+		Env:         map[string]string{"TZ": "Europe/Berlin", "DEBUG": "true", "LEVEL": "two lines"},
+	}}
+	if !reflect.DeepEqual(requests, wantReq) {
+		t.Errorf("config request = %+v, want %+v", requests, wantReq)
+	}
+
+	// Without fields there is no config wizard and config_callback stays empty
+	config.ConfigEnv = nil
+	if err := g.GenerateFromConfig(config, appDir); err != nil {
+		t.Fatalf("GenerateFromConfig() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(appDir, "wizard", "config")); !os.IsNotExist(err) {
+		t.Error("wizard/config generated without config fields")
+	}
+	if script, _ := os.ReadFile(filepath.Join(appDir, "cmd", "config_callback")); strings.Contains(string(script), "REQUEST") {
+		t.Errorf("config_callback without config fields = %s", script)
+	}
+}
@@ -0,0 +1,100 @@
+package fpkgen
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	dockercontainer "github.com/docker/docker/api/types/container"
+)
+
+// containerIconTimeout limits copying an icon out of a container
+const containerIconTimeout = 30 * time.Second
+
+// maxSymlinkHops limits how many symlinks are followed inside a container
+const maxSymlinkHops = 5
+
+// containerFileCopier copies files out of containers (implemented by the Docker client)
+type containerFileCopier interface {
+	CopyFromContainer(ctx context.Context, containerID, srcPath string) (io.ReadCloser, dockercontainer.PathStat, error)
+}
+
+// parseContainerPath extracts the absolute in-container path of a container:// icon source
+// e.g. container:///app/public/logo.png -> /app/public/logo.png
+func parseContainerPath(source string) (string, error) {
+	p := strings.TrimPrefix(source, "container://")
+	if !strings.HasPrefix(p, "/") {
+		return "", fmt.Errorf("container:// path must be absolute (container:///path/to/icon.png): %s", source)
+	}
+	return path.Clean(p), nil
+}
+
+// readContainerFile copies a regular file out of a container via the archive API.
+// Symlinks are followed (up to maxSymlinkHops) and files larger than limit are rejected.
+func readContainerFile(ctx context.Context, copier containerFileCopier, containerID, filePath string, limit int64) ([]byte, error) {
+	for hops := 0; ; hops++ {
+		rc, stat, err := copier.CopyFromContainer(ctx, containerID, filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy %s from container: %w", filePath, err)
+		}
+
+		if stat.Mode&os.ModeSymlink != 0 {
+			rc.Close()
+			if hops >= maxSymlinkHops || stat.LinkTarget == "" {
+				return nil, fmt.Errorf("too many symlinks: %s", filePath)
+			}
+			target := stat.LinkTarget
+			if !path.IsAbs(target) {
+				target = path.Join(path.Dir(filePath), target)
+			}
+			filePath = target
+			continue
+		}
+
+		data, err := readSingleTarFile(rc, filePath, stat, limit)
+		rc.Close()
+		return data, err
+	}
+}
+
+// readSingleTarFile reads the single regular file from a CopyFromContainer tar stream
+func readSingleTarFile(r io.Reader, filePath string, stat dockercontainer.PathStat, limit int64) ([]byte, error) {
+	if stat.Mode.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", filePath)
+	}
+	if stat.Size > limit {
+		return nil, fmt.Errorf("file too large: %s (%d bytes, max %d)", filePath, stat.Size, limit)
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("file not found in container archive: %s", filePath)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid container archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if hdr.Size > limit {
+			return nil, fmt.Errorf("file too large: %s (%d bytes, max %d)", filePath, hdr.Size, limit)
+		}
+
+		data, err := io.ReadAll(io.LimitReader(tr, limit+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from container archive: %w", filePath, err)
+		}
+		if int64(len(data)) > limit {
+			return nil, fmt.Errorf("file too large: %s (max %d bytes)", filePath, limit)
+		}
+		return data, nil
+	}
+}
@@ -0,0 +1,154 @@
+package fpkgen
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	dockercontainer "github.com/docker/docker/api/types/container"
+)
+
+// fakeContainerFile is a file served by fakeCopier
+type fakeContainerFile struct {
+	stat dockercontainer.PathStat
+	data []byte // Regular file content (ignored for symlinks and directories)
+}
+
+// fakeCopier serves files like the Docker archive API
+type fakeCopier struct {
+	containerID string
+	files       map[string]fakeContainerFile
+}
+
+func (c *fakeCopier) CopyFromContainer(_ context.Context, containerID, srcPath string) (io.ReadCloser, dockercontainer.PathStat, error) {
+	if containerID != c.containerID {
+		return nil, dockercontainer.PathStat{}, fmt.Errorf("no such container: %s", containerID)
+	}
+	f, ok := c.files[srcPath]
+	if !ok {
+		return nil, dockercontainer.PathStat{}, fmt.Errorf("no such file: %s", srcPath)
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	switch {
+	case f.stat.Mode&os.ModeSymlink != 0:
+		tw.WriteHeader(&tar.Header{Name: f.stat.Name, Typeflag: tar.TypeSymlink, Linkname: f.stat.LinkTarget})
+	case f.stat.Mode.IsDir():
+		tw.WriteHeader(&tar.Header{Name: f.stat.Name + "/", Typeflag: tar.TypeDir, Mode: 0755})
+	default:
+		tw.WriteHeader(&tar.Header{Name: f.stat.Name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(f.data))})
+		tw.Write(f.data)
+	}
+	tw.Close()
+
+	return io.NopCloser(&buf), f.stat, nil
+}
+
+// newFakeCopier creates a copier for container "abc123" serving a PNG logo and some links
+func newFakeCopier(t *testing.T) *fakeCopier {
+	logo := pngBytes(t, 48)
+	return &fakeCopier{
+		containerID: "abc123",
+		files: map[string]fakeContainerFile{
+			"/app/public/logo.png": {stat: dockercontainer.PathStat{Name: "logo.png", Size: int64(len(logo))}, data: logo},
+			"/app/logo.png":        {stat: dockercontainer.PathStat{Name: "logo.png", Mode: os.ModeSymlink, LinkTarget: "/app/public/logo.png"}},
+			"/app/rel.png":         {stat: dockercontainer.PathStat{Name: "rel.png", Mode: os.ModeSymlink, LinkTarget: "public/logo.png"}},
+			"/app/loop.png":        {stat: dockercontainer.PathStat{Name: "loop.png", Mode: os.ModeSymlink, LinkTarget: "/app/loop.png"}},
+			"/app/public":          {stat: dockercontainer.PathStat{Name: "public", Mode: os.ModeDir | 0755}},
+			"/app/huge.png":        {stat: dockercontainer.PathStat{Name: "huge.png", Size: maxIconFileSize + 1}},
+			"/app/readme.txt":      {stat: dockercontainer.PathStat{Name: "readme.txt", Size: 5}, data: []byte("hello")},
+		},
+	}
+}
+
+// TestIconLoader_ContainerIcon tests loading icons from the container filesystem
+func TestIconLoader_ContainerIcon(t *testing.T) {
+	copier := newFakeCopier(t)
+	loader := &iconLoader{containerID: "abc123", copier: copier}
+
+	for _, source := range []string{
+		"container:///app/public/logo.png",
+		"container:///app/public/../public/./logo.png",
+		"container:///app/logo.png", // absolute symlink
+		"container:///app/rel.png",  // relative symlink
+	} {
+		t.Run(source, func(t *testing.T) {
+			img, err := loader.load(source)
+			if err != nil {
+				t.Fatalf("load(%q) error = %v", source, err)
+			}
+			if img.Bounds().Dx() != 48 {
+				t.Errorf("expected 48x48 icon, got %v", img.Bounds())
+			}
+		})
+	}
+}
+
+// TestIconLoader_ContainerIconErrors tests error handling for container:// icons
+func TestIconLoader_ContainerIconErrors(t *testing.T) {
+	loader := &iconLoader{containerID: "abc123", copier: newFakeCopier(t)}
+
+	tests := []string{
+		"container://app/logo.png",     // relative path
+		"container:///app/missing.png", // not found
+		"container:///app/public",      // directory
+		"container:///app/huge.png",    // too large
+		"container:///app/loop.png",    // symlink loop
+		"container:///app/readme.txt",  // not an image
+	}
+	for _, source := range tests {
+		t.Run(source, func(t *testing.T) {
+			if _, err := loader.load(source); err == nil {
+				t.Errorf("load(%q) expected error", source)
+			}
+		})
+	}
+
+	// Without a container (e.g. generating from a config) the scheme is unavailable
+	if _, err := loadIconFromSource("container:///app/public/logo.png", ""); err == nil {
+		t.Error("expected error for container:// without container")
+	}
+}
+
+// TestReadSingleTarFile_SizeLimit tests that the tar entry size is enforced even if the stat is wrong
+func TestReadSingleTarFile_SizeLimit(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "big", Typeflag: tar.TypeReg, Size: 100})
+	tw.Write(make([]byte, 100))
+	tw.Close()
+
+	_, err := readSingleTarFile(&buf, "/big", dockercontainer.PathStat{Size: 1}, 10)
+	if err == nil {
+		t.Error("expected error for tar entry larger than limit")
+	}
+}
+
+// TestParseContainerPath tests parsing of container:// sources
+func TestParseContainerPath(t *testing.T) {
+	tests := []struct {
+		source  string
+		want    string
+		wantErr bool
+	}{
+		{"container:///app/logo.png", "/app/logo.png", false},
+		{"container:///app/../etc/icon.png", "/etc/icon.png", false},
+		{"container://logo.png", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseContainerPath(tt.source)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseContainerPath(%q) error = %v, wantErr %v", tt.source, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseContainerPath(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+}
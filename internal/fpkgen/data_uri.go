@@ -0,0 +1,66 @@
+package fpkgen
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// decodeDataURI decodes an RFC 2397 data URI icon source:
+//
+//	data:image/png;base64,iVBORw0KGgo...
+//	data:image/svg+xml;utf8,<svg xmlns=...>...</svg>
+//
+// The media type must be an image type; the actual format is detected from the
+// decoded content like for any other source. Whitespace inside base64 data is
+// ignored so long labels can be wrapped.
+func decodeDataURI(uri string) ([]byte, error) {
+	rest, ok := strings.CutPrefix(uri, "data:")
+	if !ok {
+		return nil, fmt.Errorf("not a data URI")
+	}
+	header, payload, ok := strings.Cut(rest, ",")
+	if !ok {
+		return nil, fmt.Errorf("invalid data URI: missing ','")
+	}
+
+	params := strings.Split(header, ";")
+	mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+	if !strings.HasPrefix(mediaType, "image/") {
+		return nil, fmt.Errorf("unsupported data URI media type %q (expected image/...)", params[0])
+	}
+	isBase64 := strings.EqualFold(strings.TrimSpace(params[len(params)-1]), "base64")
+
+	// Reject oversized payloads before decoding (percent-encoding at most triples the size)
+	if len(payload) > 3*maxIconFileSize {
+		return nil, fmt.Errorf("data URI too large (max %d bytes)", maxIconFileSize)
+	}
+
+	var data []byte
+	if isBase64 {
+		encoded := strings.TrimRight(strings.Join(strings.Fields(payload), ""), "=")
+		var err error
+		data, err = base64.RawStdEncoding.DecodeString(encoded)
+		if err != nil {
+			// Also accept the URL-safe alphabet
+			if data, err = base64.RawURLEncoding.DecodeString(encoded); err != nil {
+				return nil, fmt.Errorf("invalid base64 in data URI: %w", err)
+			}
+		}
+	} else {
+		decoded, err := url.PathUnescape(payload)
+		if err != nil {
+			return nil, fmt.Errorf("invalid data URI: %w", err)
+		}
+		data = []byte(decoded)
+	}
+
+	if len(data) > maxIconFileSize {
+		return nil, fmt.Errorf("data URI too large (max %d bytes)", maxIconFileSize)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty data URI")
+	}
+	return data, nil
+}
@@ -0,0 +1,104 @@
+package fpkgen
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+// TestLoadIconFromSource_DataURI tests icons embedded in labels
+func TestLoadIconFromSource_DataURI(t *testing.T) {
+	pngData, err := os.ReadFile(filepath.Join("testdata", "test.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	svgData, err := os.ReadFile(filepath.Join("testdata", "test.svg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(pngData)
+
+	sources := map[string]string{
+		"base64":            "data:image/png;base64," + encoded,
+		"wrapped base64":    "data:image/png;base64," + encoded[:20] + "\n  " + encoded[20:],
+		"unpadded url-safe": "data:image/png;base64," + base64.RawURLEncoding.EncodeToString(pngData),
+		"mismatched type":   "data:image/x-icon;base64," + encoded,
+		"parameters":        "data:IMAGE/PNG;name=icon.png;base64," + encoded,
+		"percent-encoded":   "data:image/svg+xml;utf8," + url.PathEscape(string(svgData)),
+	}
+
+	for name, source := range sources {
+		t.Run(name, func(t *testing.T) {
+			img, err := loadIconFromSource(source, "")
+			if err != nil {
+				t.Fatalf("loadIconFromSource() error = %v", err)
+			}
+			if img.Bounds().Empty() {
+				t.Error("empty image")
+			}
+		})
+	}
+}
+
+// TestDecodeDataURI_Errors tests rejected data URIs
+func TestDecodeDataURI_Errors(t *testing.T) {
+	tests := []struct {
+		uri     string
+		wantErr string
+	}{
+		{"data:image/png;base64", "missing ','"},
+		{"data:text/html,<script>", "media type"},
+		{"data:,hello", "media type"},
+		{"data:image/png;base64,!!!", "invalid base64"},
+		{"data:image/png;base64,", "empty"},
+		{"data:image/svg+xml,%zz", "invalid data URI"},
+		{"data:image/png;base64," + strings.Repeat("A", 4*maxIconFileSize), "too large"},
+	}
+
+	for _, tt := range tests {
+		if _, err := decodeDataURI(tt.uri); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("decodeDataURI(%.40q) error = %v, want %q", tt.uri, err, tt.wantErr)
+		}
+	}
+
+	// Valid data URIs with unsupported content fail in format detection
+	if _, err := loadIconFromSource("data:image/png;base64,"+base64.StdEncoding.EncodeToString([]byte("not an image")), ""); err == nil ||
+		!strings.Contains(err.Error(), "unsupported image format") {
+		t.Errorf("loadIconFromSource() error = %v, want unsupported format", err)
+	}
+}
+
+// Property test: base64 data URIs round-trip arbitrary content
+func TestProperty_DataURIRoundTrip(t *testing.T) {
+	f := func(data []byte) bool {
+		if len(data) == 0 {
+			return true
+		}
+		got, err := decodeDataURI("data:image/png;base64," + base64.StdEncoding.EncodeToString(data))
+		return err == nil && bytes.Equal(got, data)
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 100}); err != nil {
+		t.Errorf("Property test failed: %v", err)
+	}
+}
+
+// TestIconSet_BadgeDataURI tests data URIs as badge icon sources
+func TestIconSet_BadgeDataURI(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, solidImage(16, 16, image.Rect(0, 0, 16, 16)))
+
+	badge := "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+	if !isIconSource(badge) {
+		t.Fatal("data URI should be an icon source")
+	}
+	if loadBadge(&iconLoader{}, badge) == nil {
+		t.Error("data URI badge not loaded")
+	}
+}
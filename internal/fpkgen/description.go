@@ -0,0 +1,284 @@
+package fpkgen
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	xhtml "golang.org/x/net/html"
+)
+
+const (
+	// maxDescriptionLength caps the visible text (in runes) of a rendered description
+	maxDescriptionLength = 1024
+	// maxDescriptionFileSize caps the size of a watchcow.desc_file source
+	maxDescriptionFileSize = 64 * 1024
+)
+
+// descriptionAllowedTags lists the HTML tags fnOS App Center renders in manifest.desc
+var descriptionAllowedTags = map[string]bool{
+	"b": true, "strong": true, "i": true, "em": true, "u": true, "s": true,
+	"code": true, "br": true, "a": true,
+}
+
+// descriptionDroppedTags lists tags whose content is removed together with the tag
+var descriptionDroppedTags = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "embed": true,
+	"noscript": true, "template": true, "textarea": true, "title": true,
+	"svg": true, "math": true, "head": true,
+}
+
+// Inline Markdown patterns
+var (
+	mdCodeSpan  = regexp.MustCompile("`([^`]+)`")
+	mdImage     = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLink      = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s\x00]+)\)`)
+	mdBold      = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	mdItalic    = regexp.MustCompile(`\*([^*\s][^*]*)\*`)
+	mdHeading   = regexp.MustCompile(`^#{1,6}\s+(.*?)\s*#*$`)
+	mdListItem  = regexp.MustCompile(`^[-*+]\s+(.*)$`)
+	mdCodeFence = regexp.MustCompile("^(```|~~~)")
+	mdHTMLTag   = regexp.MustCompile(`<[A-Za-z/!][^>]*>`)
+	mdTagMarker = regexp.MustCompile("\x00([0-9]+)\x00")
+)
+
+// renderDescription converts a Markdown (or inline HTML) description into the
+// sanitized single-line HTML subset rendered by fnOS App Center
+func renderDescription(src string) string {
+	return sanitizeDescriptionHTML(markdownToHTML(src), maxDescriptionLength)
+}
+
+// loadDescriptionFile reads a watchcow.desc_file source
+// Only file:// sources are supported, resolved and confined to roots like file:// icons
+func loadDescriptionFile(source string, basePath string, roots *fileRoots) (string, error) {
+	if !strings.HasPrefix(source, "file://") {
+		return "", fmt.Errorf("unsupported description source: %s", source)
+	}
+
+	data, err := readFileSource(source, basePath, roots, maxDescriptionFileSize)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// markdownToHTML converts the Markdown subset used for app descriptions to HTML.
+// Line breaks are kept as <br>, blank lines separate paragraphs, headings become
+// bold lines and list items become bullet lines. Raw HTML is passed through and
+// left to the sanitizer.
+func markdownToHTML(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+
+	var paragraphs []string
+	var lines []string
+	inFence := false
+
+	flush := func() {
+		if len(lines) > 0 {
+			paragraphs = append(paragraphs, strings.Join(lines, "<br>"))
+			lines = nil
+		}
+	}
+
+	for _, line := range strings.Split(src, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if mdCodeFence.MatchString(trimmed) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			lines = append(lines, "<code>"+html.EscapeString(line)+"</code>")
+			continue
+		}
+
+		if trimmed == "" {
+			flush()
+			continue
+		}
+
+		if m := mdHeading.FindStringSubmatch(trimmed); m != nil {
+			lines = append(lines, "<b>"+markdownInline(m[1])+"</b>")
+		} else if m := mdListItem.FindStringSubmatch(trimmed); m != nil {
+			lines = append(lines, "• "+markdownInline(m[1]))
+		} else {
+			lines = append(lines, markdownInline(trimmed))
+		}
+	}
+	flush()
+
+	return strings.Join(paragraphs, "<br><br>")
+}
+
+// markdownInline converts inline Markdown markup (code, links, bold, italic)
+// Code spans are converted first and their content is protected from other rules
+func markdownInline(s string) string {
+	var result strings.Builder
+	last := 0
+	for _, loc := range mdCodeSpan.FindAllStringSubmatchIndex(s, -1) {
+		result.WriteString(markdownEmphasis(s[last:loc[0]]))
+		result.WriteString("<code>" + html.EscapeString(s[loc[2]:loc[3]]) + "</code>")
+		last = loc[1]
+	}
+	result.WriteString(markdownEmphasis(s[last:]))
+	return result.String()
+}
+
+// markdownEmphasis converts images, links, bold and italic markup
+// HTML tags (raw or generated links) are replaced by markers first, so emphasis
+// is only applied to text and attributes such as href="/a__b__c" stay intact
+func markdownEmphasis(s string) string {
+	var tags []string
+	protect := func(tag string) string {
+		tags = append(tags, tag)
+		return "\x00" + strconv.Itoa(len(tags)-1) + "\x00"
+	}
+
+	s = strings.ReplaceAll(s, "\x00", "")
+	s = mdHTMLTag.ReplaceAllStringFunc(s, protect)
+	s = mdImage.ReplaceAllString(s, "$1")
+	s = mdLink.ReplaceAllStringFunc(s, func(m string) string {
+		parts := mdLink.FindStringSubmatch(m)
+		return protect(`<a href="`+html.EscapeString(parts[2])+`">`) + parts[1] + protect("</a>")
+	})
+	s = mdBold.ReplaceAllString(s, "<b>$1$2</b>")
+	s = mdItalic.ReplaceAllString(s, "<i>$1</i>")
+
+	return mdTagMarker.ReplaceAllStringFunc(s, func(m string) string {
+		i, _ := strconv.Atoi(m[1 : len(m)-1])
+		return tags[i]
+	})
+}
+
+// sanitizeDescriptionHTML keeps only the allowlisted tags, strips all attributes
+// except safe link targets, removes script-like elements with their content and
+// truncates the visible text to maxLength runes. The result never contains newlines.
+func sanitizeDescriptionHTML(src string, maxLength int) string {
+	type openTag struct {
+		name    string
+		emitted bool
+	}
+
+	var out strings.Builder
+	var stack []openTag
+	skipDepth := 0
+	length := 0
+	truncated := false
+
+	z := xhtml.NewTokenizer(strings.NewReader(src))
+
+loop:
+	for {
+		tt := z.Next()
+		switch tt {
+		case xhtml.ErrorToken:
+			break loop
+
+		case xhtml.TextToken:
+			if skipDepth > 0 {
+				continue
+			}
+			text := strings.NewReplacer("\r", " ", "\n", " ", "\t", " ").Replace(string(z.Text()))
+			n := utf8.RuneCountInString(text)
+			if length+n > maxLength {
+				text = string([]rune(text)[:maxLength-length]) + "…"
+				out.WriteString(html.EscapeString(text))
+				truncated = true
+				break loop
+			}
+			length += n
+			out.WriteString(html.EscapeString(text))
+
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			tok := z.Token()
+			name := tok.Data
+			if descriptionDroppedTags[name] {
+				if tt == xhtml.StartTagToken {
+					skipDepth++
+				}
+				continue
+			}
+			if skipDepth > 0 || !descriptionAllowedTags[name] {
+				continue
+			}
+			if name == "br" {
+				out.WriteString("<br>")
+				continue
+			}
+
+			emitted := true
+			if name == "a" {
+				href := descriptionLinkTarget(tok.Attr)
+				if href == "" {
+					emitted = false
+				} else {
+					out.WriteString(`<a href="` + html.EscapeString(href) + `">`)
+				}
+			} else {
+				out.WriteString("<" + name + ">")
+			}
+			if tt == xhtml.StartTagToken {
+				stack = append(stack, openTag{name: name, emitted: emitted})
+			} else if emitted {
+				out.WriteString("</" + name + ">")
+			}
+
+		case xhtml.EndTagToken:
+			tok := z.Token()
+			name := tok.Data
+			if descriptionDroppedTags[name] {
+				if skipDepth > 0 {
+					skipDepth--
+				}
+				continue
+			}
+			if skipDepth > 0 {
+				continue
+			}
+			// Close up to the matching open tag, ignoring stray end tags
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i].name != name {
+					continue
+				}
+				for j := len(stack) - 1; j >= i; j-- {
+					if stack[j].emitted {
+						out.WriteString("</" + stack[j].name + ">")
+					}
+				}
+				stack = stack[:i]
+				break
+			}
+		}
+	}
+
+	// Close any tags left open by the source or by truncation
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i].emitted {
+			out.WriteString("</" + stack[i].name + ">")
+		}
+	}
+
+	result := out.String()
+	if !truncated {
+		result = strings.TrimSpace(result)
+	}
+	return result
+}
+
+// descriptionLinkTarget returns the href of a link if it uses a safe scheme
+func descriptionLinkTarget(attrs []xhtml.Attribute) string {
+	for _, attr := range attrs {
+		if attr.Key != "href" {
+			continue
+		}
+		href := strings.TrimSpace(attr.Val)
+		lower := strings.ToLower(href)
+		if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "mailto:") {
+			return href
+		}
+	}
+	return ""
+}
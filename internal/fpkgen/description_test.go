@@ -0,0 +1,160 @@
+package fpkgen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/quick"
+	"unicode/utf8"
+)
+
+// TestRenderDescription_Markdown tests conversion of the supported Markdown subset
+func TestRenderDescription_Markdown(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "Docker container: nginx:latest", "Docker container: nginx:latest"},
+		{"bold", "**Fast** notes", "<b>Fast</b> notes"},
+		{"italic", "a *quick* note", "a <i>quick</i> note"},
+		{"code", "set `watchcow.enable`", "set <code>watchcow.enable</code>"},
+		{"code protects markup", "`**not bold**`", "<code>**not bold**</code>"},
+		{"link", "[Docs](https://example.com/docs)", `<a href="https://example.com/docs">Docs</a>`},
+		{"heading", "# Memos\nNotes", "<b>Memos</b><br>Notes"},
+		{"list", "- one\n- two", "• one<br>• two"},
+		{"paragraphs", "first\n\nsecond", "first<br><br>second"},
+		{"crlf", "first\r\nsecond", "first<br>second"},
+		{"image dropped", "![logo](https://example.com/logo.png) App", "logo App"},
+		{"escapes text", "Tom & Jerry < 3", "Tom &amp; Jerry &lt; 3"},
+		{"raw html", "<b>🐄 WatchCow</b><br>Docker", "<b>🐄 WatchCow</b><br>Docker"},
+		{"raw link href", `<a href="https://x/a__b__c">__docs__</a>`, `<a href="https://x/a__b__c"><b>docs</b></a>`},
+		{"link href", "[*Docs*](https://x/a__b__c)", `<a href="https://x/a__b__c"><i>Docs</i></a>`},
+		{"bold around html", "**<u>Fast</u> notes**", "<b><u>Fast</u> notes</b>"},
+		{"fence", "```\n<b>x</b>\n```", "<code>&lt;b&gt;x&lt;/b&gt;</code>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderDescription(tt.in); got != tt.want {
+				t.Errorf("renderDescription(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSanitizeDescriptionHTML tests the allowlist sanitizer
+func TestSanitizeDescriptionHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"script removed", "a<script>alert(1)</script>b", "ab"},
+		{"style removed", "<style>b{color:red}</style>text", "text"},
+		{"attributes stripped", `<b onclick="x()" class="y">bold</b>`, "<b>bold</b>"},
+		{"unknown tag unwrapped", "<div><span>text</span></div>", "text"},
+		{"javascript link dropped", `<a href="javascript:alert(1)">x</a>`, "x"},
+		{"safe link kept", `<a href="https://example.com" target="_blank">x</a>`, `<a href="https://example.com">x</a>`},
+		{"unclosed tag closed", "<b>bold", "<b>bold</b>"},
+		{"stray end tag ignored", "text</b>", "text"},
+		{"self closing br", "a<br/>b", "a<br>b"},
+		{"img removed", `<img src=x onerror="alert(1)">ok`, "ok"},
+		{"newlines flattened", "<b>a\nb</b>", "<b>a b</b>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeDescriptionHTML(tt.in, maxDescriptionLength); got != tt.want {
+				t.Errorf("sanitizeDescriptionHTML(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSanitizeDescriptionHTML_Truncate tests length limiting with open tags
+func TestSanitizeDescriptionHTML_Truncate(t *testing.T) {
+	got := sanitizeDescriptionHTML("<b>abcdef</b>ghi", 4)
+	want := "<b>abcd…</b>"
+	if got != want {
+		t.Errorf("sanitizeDescriptionHTML() = %q, want %q", got, want)
+	}
+}
+
+// Property test: rendered descriptions are single-line and never contain script tags
+func TestProperty_RenderDescriptionSafe(t *testing.T) {
+	f := func(s string) bool {
+		got := renderDescription(s + "<script>x</script>\n" + s)
+		if strings.ContainsAny(got, "\n\r") {
+			return false
+		}
+		if strings.Contains(strings.ToLower(got), "<script") {
+			return false
+		}
+		return utf8.ValidString(got)
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 100}); err != nil {
+		t.Errorf("Property test failed: %v", err)
+	}
+}
+
+// TestLoadDescriptionFile tests reading a description from a relative file:// source
+func TestLoadDescriptionFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# App\nHello"), 0644); err != nil {
+		t.Fatalf("Failed to write temp file: %v", err)
+	}
+
+	got, err := loadDescriptionFile("file://README.md", dir, nil)
+	if err != nil {
+		t.Fatalf("loadDescriptionFile() error = %v", err)
+	}
+	if got != "# App\nHello" {
+		t.Errorf("loadDescriptionFile() = %q", got)
+	}
+}
+
+// TestLoadDescriptionFile_Errors tests rejected description sources
+func TestLoadDescriptionFile_Errors(t *testing.T) {
+	dir := t.TempDir()
+	large := filepath.Join(dir, "large.md")
+	if err := os.WriteFile(large, make([]byte, maxDescriptionFileSize+1), 0644); err != nil {
+		t.Fatalf("Failed to write temp file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		source  string
+		wantErr string
+	}{
+		{"unsupported scheme", "https://example.com/README.md", "unsupported description source"},
+		{"missing file", "file://" + filepath.Join(dir, "missing.md"), "file not found"},
+		{"too large", "file://" + large, "too large"},
+		{"relative without base", "file://README.md", "relative path requires base path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			basePath := ""
+			_, err := loadDescriptionFile(tt.source, basePath, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("loadDescriptionFile(%q) error = %v, want error containing %q", tt.source, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestTemplateData_DescriptionRendered tests that the manifest description is rendered HTML
+func TestTemplateData_DescriptionRendered(t *testing.T) {
+	config := &AppConfig{
+		AppName:     "watchcow.test",
+		Description: "**Notes**\n\n- fast\n- <script>alert(1)</script>simple",
+	}
+
+	data := NewTemplateData(config)
+	want := "<b>Notes</b><br><br>• fast<br>• simple"
+	if data.Description != want {
+		t.Errorf("Description = %q, want %q", data.Description, want)
+	}
+}
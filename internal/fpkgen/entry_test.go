@@ -514,6 +514,20 @@ func TestHasDefaultEntry(t *testing.T) {
 			},
 			expected: true,
 		},
+		{
+			name: "has localized title",
+			labels: map[string]string{
+				"watchcow.title.en": "My App",
+			},
+			expected: true,
+		},
+		{
+			name: "only named localized title",
+			labels: map[string]string{
+				"watchcow.admin.title.en": "Admin",
+			},
+			expected: false,
+		},
 		{
 			name: "has ui_type",
 			labels: map[string]string{
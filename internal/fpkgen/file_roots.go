@@ -0,0 +1,138 @@
+package fpkgen
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// errOutsideRoots is returned for file:// paths outside the allowed directories
+var errOutsideRoots = errors.New("path is outside the allowed icon directories")
+
+// DefaultIconRoots returns the directories file:// icons may be read from: the fnOS
+// storage volumes (where compose projects live) and the WatchCow icons directory
+// ($TRIM_PKGVAR/icons when running as fnOS app).
+func DefaultIconRoots() []string {
+	roots := []string{"/vol*"}
+	if pkgVar := os.Getenv("TRIM_PKGVAR"); pkgVar != "" {
+		roots = append(roots, filepath.Join(pkgVar, "icons"))
+	}
+	return roots
+}
+
+// fileRoots confines file:// paths to allowed directories.
+// WatchCow runs as root, so labels must not be able to read (or probe) arbitrary files.
+type fileRoots struct {
+	patterns []string // Absolute root directories; glob patterns like /vol* match whole path components
+}
+
+// newFileRoots creates the allowed directories from configured roots.
+// Relative and empty roots are ignored.
+func newFileRoots(roots []string) *fileRoots {
+	r := &fileRoots{}
+	for _, root := range roots {
+		root = strings.TrimSpace(root)
+		if root == "" || !filepath.IsAbs(root) {
+			continue
+		}
+		r.patterns = append(r.patterns, filepath.Clean(root))
+	}
+	return r
+}
+
+// resolve resolves a file:// URL like resolveFilePath and checks that the file lies
+// inside one of the roots, following symlinks. basePath only anchors relative paths:
+// it comes from the com.docker.compose.project.working_dir label, which whoever sets
+// the file:// label also controls, so it does not widen the roots.
+// Returns the real path of the file. A nil fileRoots allows any path.
+func (r *fileRoots) resolve(fileURL string, basePath string) (string, error) {
+	path, err := resolveFilePath(fileURL, basePath)
+	if err != nil {
+		return "", err
+	}
+	if r == nil {
+		return path, nil
+	}
+
+	patterns := r.patterns
+
+	// Reject lexically before touching the filesystem, so errors do not reveal
+	// whether files outside the roots exist
+	path = filepath.Clean(path)
+	if !withinRoots(path, patterns) {
+		return "", fmt.Errorf("%w: %s", errOutsideRoots, path)
+	}
+
+	// Symlinks inside a root must not point outside of all roots
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("file not found: %s", path)
+	}
+	if !withinRoots(real, realRoots(patterns)) {
+		return "", fmt.Errorf("%w: %s links to %s", errOutsideRoots, path, real)
+	}
+	return real, nil
+}
+
+// withinRoots reports whether a clean absolute path is inside one of the root patterns
+func withinRoots(path string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if pattern == "/" {
+			return true
+		}
+
+		// Match the pattern against the leading components of the path
+		depth := strings.Count(pattern, "/")
+		parts := strings.SplitAfterN(path, "/", depth+2)
+		if len(parts) <= depth {
+			continue
+		}
+		prefix := strings.TrimSuffix(strings.Join(parts[:depth+1], ""), "/")
+		if ok, _ := filepath.Match(pattern, prefix); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// realRoots resolves symlinks in literal root directories.
+// Glob patterns and missing directories are kept as is.
+func realRoots(patterns []string) []string {
+	resolved := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if !strings.ContainsAny(pattern, `*?[\`) {
+			if real, err := filepath.EvalSymlinks(pattern); err == nil {
+				pattern = real
+			}
+		}
+		resolved = append(resolved, pattern)
+	}
+	return resolved
+}
+
+// readFileSource reads a file:// source of at most limit bytes,
+// resolved and confined to roots like file:// icons
+func readFileSource(source string, basePath string, roots *fileRoots, limit int64) ([]byte, error) {
+	path, err := roots.resolve(source, basePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve file path: %w", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("file not found: %s", path)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("file too large: %s exceeds %d bytes", path, limit)
+	}
+	return data, nil
+}
@@ -0,0 +1,7 @@
+module watchcow/internal/fpkgen/fonts/generate
+
+go 1.25.0
+
+require github.com/go-text/typesetting v0.3.5
+
+require golang.org/x/image v0.23.0
@@ -0,0 +1,817 @@
+// Command generate builds the embedded emoji font of generated icons (fonts/emoji.ttf)
+// from the COLRv1 version of Noto Color Emoji (SIL Open Font License 1.1).
+//
+// Icons draw glyphs in white on a colored background, so each emoji is reduced
+// to its silhouette: the union of the outlines of all its color layers.
+//
+// Run with: go run . path/to/NotoColorEmoji-Regular.ttf ../emoji.ttf
+// (NotoColorEmoji-Regular.ttf is in github.com/go-text/typesetting-utils/opentype/color)
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"math"
+	"math/bits"
+	"os"
+	"slices"
+	"unicode/utf16"
+
+	"github.com/go-text/typesetting/font"
+	ot "github.com/go-text/typesetting/font/opentype"
+	"github.com/go-text/typesetting/font/opentype/tables"
+	"golang.org/x/image/vector"
+)
+
+// emojiRanges are the code point ranges of single code point emoji
+var emojiRanges = [][2]rune{
+	{0x2000, 0x2BFF},
+	{0x3000, 0x33FF},
+	{0x1F000, 0x1FAFF},
+}
+
+// unitsPerEm of the generated font: outlines are scaled down from the source
+// font, icons render emoji at 160px
+const unitsPerEm = 512
+
+const (
+	familyName = "WatchCow Emoji"
+	psName     = "WatchCowEmoji-Regular"
+	copyright  = "Copyright 2013 Google LLC. Silhouettes of Noto Color Emoji."
+	license    = "This Font Software is licensed under the SIL Open Font License, Version 1.1."
+	licenseURL = "https://openfontlicense.org"
+)
+
+func main() {
+	if len(os.Args) != 3 {
+		fmt.Fprintln(os.Stderr, "usage: go run . NotoColorEmoji-Regular.ttf emoji.ttf")
+		os.Exit(2)
+	}
+	if err := run(os.Args[1], os.Args[2]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	face, err := font.ParseTTF(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if face.COLR == nil {
+		return fmt.Errorf("%s has no COLR table", src)
+	}
+
+	scale := float64(unitsPerEm) / float64(face.Upem())
+	out := &fontBuilder{upem: unitsPerEm, glyphs: []glyph{{}}} // Glyph 0 is .notdef
+	if extents, ok := face.FontHExtents(); ok {
+		out.ascent = int16(math.Round(float64(extents.Ascender) * scale))
+		out.descent = int16(math.Round(float64(extents.Descender) * scale))
+	}
+	glyphIndex := make(map[font.GID]uint16)
+	for _, r := range emojiRanges {
+		for ch := r[0]; ch <= r[1]; ch++ {
+			// Skin tones only modify the preceding emoji
+			if ch >= 0x1F3FB && ch <= 0x1F3FF {
+				continue
+			}
+			gid, ok := face.NominalGlyph(ch)
+			if !ok {
+				continue
+			}
+			if idx, ok := glyphIndex[gid]; ok {
+				out.cmap = append(out.cmap, cmapEntry{ch, idx})
+				continue
+			}
+			paint, ok := face.COLR.Search(tables.GlyphID(gid))
+			if !ok {
+				continue
+			}
+			s := newSilhouette(face)
+			s.paint(paint, affine{xx: scale, yy: scale}, 0)
+			contours := s.contours()
+			if len(contours) == 0 {
+				continue
+			}
+			idx := uint16(len(out.glyphs))
+			glyphIndex[gid] = idx
+			out.glyphs = append(out.glyphs, glyph{contours: contours, advance: uint16(math.Round(float64(face.HorizontalAdvance(gid)) * scale))})
+			out.cmap = append(out.cmap, cmapEntry{ch, idx})
+		}
+	}
+
+	ttf := out.build()
+	if err := os.WriteFile(dst, ttf, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("%s: %d glyphs, %d code points, %d bytes\n", dst, len(out.glyphs), len(out.cmap), len(ttf))
+	return nil
+}
+
+// affine is a 2x3 transformation matrix: x' = xx*x + xy*y + dx, y' = yx*x + yy*y + dy
+type affine struct{ xx, yx, xy, yy, dx, dy float64 }
+
+// then returns the transformation applying t first, then a
+func (a affine) then(t affine) affine {
+	return affine{
+		xx: a.xx*t.xx + a.xy*t.yx,
+		yx: a.yx*t.xx + a.yy*t.yx,
+		xy: a.xx*t.xy + a.xy*t.yy,
+		yy: a.yx*t.xy + a.yy*t.yy,
+		dx: a.xx*t.dx + a.xy*t.dy + a.dx,
+		dy: a.yx*t.dx + a.yy*t.dy + a.dy,
+	}
+}
+
+func (a affine) apply(x, y float64) (float64, float64) {
+	return a.xx*x + a.xy*y + a.dx, a.yx*x + a.yy*y + a.dy
+}
+
+func translate(dx, dy float64) affine { return affine{xx: 1, yy: 1, dx: dx, dy: dy} }
+
+// aroundCenter returns t applied around (cx, cy) instead of the origin
+func aroundCenter(t affine, cx, cy int16) affine {
+	return translate(float64(cx), float64(cy)).then(t).then(translate(-float64(cx), -float64(cy)))
+}
+
+func f214(c tables.Coord) float64 { return float64(c) / (1 << 14) }
+
+func rotate(angle tables.Coord) affine {
+	sin, cos := math.Sincos(f214(angle) * math.Pi)
+	return affine{xx: cos, yx: sin, xy: -sin, yy: cos}
+}
+
+func skew(x, y tables.Coord) affine {
+	return affine{xx: 1, yx: math.Tan(f214(y) * math.Pi), xy: -math.Tan(f214(x) * math.Pi), yy: 1}
+}
+
+const (
+	// canvasSize is the size of the silhouette raster (1 pixel per unit),
+	// it covers [-canvasOrigin, canvasSize-canvasOrigin) on both axes
+	canvasSize   = 2 * unitsPerEm
+	canvasOrigin = unitsPerEm / 2
+
+	// tolerance is the maximum distance of simplified outlines to the traced ones
+	tolerance = 1.0
+	// minArea is the area of the smallest contour kept
+	minArea = 8
+	// darkLuminance is the relative luminance below which fills are cut out
+	darkLuminance = 0.2
+)
+
+// point is an on-curve point of a TrueType outline
+type point struct{ x, y int16 }
+
+// silhouette collects the union of the glyphs a color glyph paints
+type silhouette struct {
+	face *font.Face
+	mask *image.Alpha // Raster of the painted glyphs, y down
+}
+
+func newSilhouette(face *font.Face) *silhouette {
+	return &silhouette{face: face, mask: image.NewAlpha(image.Rect(0, 0, canvasSize, canvasSize))}
+}
+
+// paint walks a paint graph, adding the outlines of PaintGlyph nodes transformed by t.
+// Fills are ignored: the silhouette is drawn in one color.
+func (s *silhouette) paint(p tables.PaintTable, t affine, depth int) {
+	if depth > 64 {
+		return
+	}
+	depth++
+	switch p := p.(type) {
+	case tables.PaintColrLayers:
+		layers, err := s.face.COLR.LayerList.Resolve(p)
+		if err != nil {
+			return
+		}
+		for _, layer := range layers {
+			s.paint(layer, t, depth)
+		}
+	case tables.PaintColrGlyph:
+		if base, ok := s.face.COLR.Search(tables.GlyphID(p.GlyphID)); ok {
+			s.paint(base, t, depth)
+		}
+	case tables.PaintGlyph:
+		s.addGlyph(font.GID(p.GlyphID), t, s.dark(p.Paint))
+	case tables.PaintComposite:
+		s.paint(p.BackdropPaint, t, depth)
+		s.paint(p.SourcePaint, t, depth)
+	case tables.PaintTransform:
+		m := p.Transform
+		s.paint(p.Paint, t.then(affine{float64(m.Xx), float64(m.Yx), float64(m.Xy), float64(m.Yy), float64(m.Dx), float64(m.Dy)}), depth)
+	case tables.PaintVarTransform:
+		m := p.Transform
+		s.paint(p.Paint, t.then(affine{float64(m.Xx), float64(m.Yx), float64(m.Xy), float64(m.Yy), float64(m.Dx), float64(m.Dy)}), depth)
+	case tables.PaintTranslate:
+		s.paint(p.Paint, t.then(translate(float64(p.Dx), float64(p.Dy))), depth)
+	case tables.PaintVarTranslate:
+		s.paint(p.Paint, t.then(translate(float64(p.Dx), float64(p.Dy))), depth)
+	case tables.PaintScale:
+		s.paint(p.Paint, t.then(affine{xx: f214(p.ScaleX), yy: f214(p.ScaleY)}), depth)
+	case tables.PaintVarScale:
+		s.paint(p.Paint, t.then(affine{xx: f214(p.ScaleX), yy: f214(p.ScaleY)}), depth)
+	case tables.PaintScaleAroundCenter:
+		s.paint(p.Paint, t.then(aroundCenter(affine{xx: f214(p.ScaleX), yy: f214(p.ScaleY)}, p.CenterX, p.CenterY)), depth)
+	case tables.PaintVarScaleAroundCenter:
+		s.paint(p.Paint, t.then(aroundCenter(affine{xx: f214(p.ScaleX), yy: f214(p.ScaleY)}, p.CenterX, p.CenterY)), depth)
+	case tables.PaintScaleUniform:
+		s.paint(p.Paint, t.then(affine{xx: f214(p.Scale), yy: f214(p.Scale)}), depth)
+	case tables.PaintVarScaleUniform:
+		s.paint(p.Paint, t.then(affine{xx: f214(p.Scale), yy: f214(p.Scale)}), depth)
+	case tables.PaintScaleUniformAroundCenter:
+		s.paint(p.Paint, t.then(aroundCenter(affine{xx: f214(p.Scale), yy: f214(p.Scale)}, p.CenterX, p.CenterY)), depth)
+	case tables.PaintVarScaleUniformAroundCenter:
+		s.paint(p.Paint, t.then(aroundCenter(affine{xx: f214(p.Scale), yy: f214(p.Scale)}, p.CenterX, p.CenterY)), depth)
+	case tables.PaintRotate:
+		s.paint(p.Paint, t.then(rotate(p.Angle)), depth)
+	case tables.PaintVarRotate:
+		s.paint(p.Paint, t.then(rotate(p.Angle)), depth)
+	case tables.PaintRotateAroundCenter:
+		s.paint(p.Paint, t.then(aroundCenter(rotate(p.Angle), p.CenterX, p.CenterY)), depth)
+	case tables.PaintVarRotateAroundCenter:
+		s.paint(p.Paint, t.then(aroundCenter(rotate(p.Angle), p.CenterX, p.CenterY)), depth)
+	case tables.PaintSkew:
+		s.paint(p.Paint, t.then(skew(p.XSkewAngle, p.YSkewAngle)), depth)
+	case tables.PaintVarSkew:
+		s.paint(p.Paint, t.then(skew(p.XSkewAngle, p.YSkewAngle)), depth)
+	case tables.PaintSkewAroundCenter:
+		s.paint(p.Paint, t.then(aroundCenter(skew(p.XSkewAngle, p.YSkewAngle), p.CenterX, p.CenterY)), depth)
+	case tables.PaintVarSkewAroundCenter:
+		s.paint(p.Paint, t.then(aroundCenter(skew(p.XSkewAngle, p.YSkewAngle), p.CenterX, p.CenterY)), depth)
+	}
+}
+
+// dark reports whether a fill is dark: dark details (e.g. eyes) are cut out of the
+// silhouette so that they stay visible
+func (s *silhouette) dark(p tables.PaintTable) bool {
+	var stops []tables.ColorStop
+	switch p := p.(type) {
+	case tables.PaintSolid:
+		stops = []tables.ColorStop{{PaletteIndex: p.PaletteIndex, Alpha: p.Alpha}}
+	case tables.PaintLinearGradient:
+		stops = p.ColorLine.ColorStops
+	case tables.PaintRadialGradient:
+		stops = p.ColorLine.ColorStops
+	case tables.PaintSweepGradient:
+		stops = p.ColorLine.ColorStops
+	}
+	if len(stops) == 0 || len(s.face.CPAL) == 0 {
+		return false
+	}
+	var luminance float64
+	for _, stop := range stops {
+		if int(stop.PaletteIndex) >= len(s.face.CPAL[0]) || f214(stop.Alpha) < 1 {
+			return false
+		}
+		c := s.face.CPAL[0][stop.PaletteIndex]
+		luminance += (0.2126*float64(c.Red) + 0.7152*float64(c.Green) + 0.0722*float64(c.Blue)) / 255
+	}
+	return luminance/float64(len(stops)) < darkLuminance
+}
+
+// addGlyph adds the outline of a glyph transformed by t to the raster, or cuts it out
+func (s *silhouette) addGlyph(gid font.GID, t affine, cut bool) {
+	outline, ok := s.face.GlyphDataOutline(gid)
+	if !ok || len(outline.Segments) == 0 {
+		return
+	}
+	segments := slices.Clone(outline.Segments)
+	var minX, minY, maxX, maxY float32 = math.MaxFloat32, math.MaxFloat32, -math.MaxFloat32, -math.MaxFloat32
+	for i := range segments {
+		args := segments[i].ArgsSlice()
+		for j := range args {
+			x, y := t.apply(float64(args[j].X), float64(args[j].Y))
+			args[j] = font.SegmentPoint{X: float32(x + canvasOrigin), Y: float32(canvasSize - canvasOrigin - y)}
+			minX, minY = min(minX, args[j].X), min(minY, args[j].Y)
+			maxX, maxY = max(maxX, args[j].X), max(maxY, args[j].Y)
+		}
+	}
+	bounds := image.Rect(int(math.Floor(float64(minX))), int(math.Floor(float64(minY))),
+		int(math.Ceil(float64(maxX)))+1, int(math.Ceil(float64(maxY)))+1).Intersect(s.mask.Bounds())
+	if bounds.Empty() {
+		return
+	}
+
+	r := vector.NewRasterizer(bounds.Dx(), bounds.Dy())
+	dx, dy := float32(bounds.Min.X), float32(bounds.Min.Y)
+	for i, seg := range segments {
+		a := seg.Args
+		switch seg.Op {
+		case ot.SegmentOpMoveTo:
+			if i > 0 {
+				r.ClosePath()
+			}
+			r.MoveTo(a[0].X-dx, a[0].Y-dy)
+		case ot.SegmentOpLineTo:
+			r.LineTo(a[0].X-dx, a[0].Y-dy)
+		case ot.SegmentOpQuadTo:
+			r.QuadTo(a[0].X-dx, a[0].Y-dy, a[1].X-dx, a[1].Y-dy)
+		case ot.SegmentOpCubeTo:
+			r.CubeTo(a[0].X-dx, a[0].Y-dy, a[1].X-dx, a[1].Y-dy, a[2].X-dx, a[2].Y-dy)
+		}
+	}
+	r.ClosePath()
+	coverage := image.NewAlpha(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	r.Draw(coverage, coverage.Bounds(), image.Opaque, image.Point{})
+
+	// Layers are united whatever their winding direction
+	for y := range bounds.Dy() {
+		for x := range bounds.Dx() {
+			c := uint32(coverage.Pix[coverage.PixOffset(x, y)])
+			i := s.mask.PixOffset(bounds.Min.X+x, bounds.Min.Y+y)
+			if cut {
+				s.mask.Pix[i] = uint8(uint32(s.mask.Pix[i]) * (255 - c) / 255)
+			} else {
+				s.mask.Pix[i] = uint8(c + uint32(s.mask.Pix[i])*(255-c)/255)
+			}
+		}
+	}
+}
+
+// filled reports whether the cell at (x, y) in font units is inside the silhouette
+func (s *silhouette) filled(x, y int) bool {
+	return s.mask.AlphaAt(x+canvasOrigin, canvasSize-canvasOrigin-1-y).A >= 0x80
+}
+
+// vec is a vertex of a traced outline in font units
+type vec struct{ x, y float64 }
+
+// Directions of traced edges, clockwise
+const (
+	east = iota
+	south
+	west
+	north
+)
+
+var steps = [4][2]int{{1, 0}, {0, -1}, {-1, 0}, {0, 1}}
+
+// contours traces the silhouette: clockwise outer contours and counter-clockwise holes,
+// simplified to straight lines
+func (s *silhouette) contours() [][]point {
+	const n = canvasSize + 1
+	index := func(x, y int) int { return (x+canvasOrigin)*n + y + canvasOrigin }
+	// Boundary edges of the filled cells by start vertex, with the cells on their right
+	edges := make([]uint8, n*n)
+	lo, hi := -canvasOrigin, canvasSize-canvasOrigin
+	for x := lo; x < hi; x++ {
+		for y := lo; y < hi; y++ {
+			if !s.filled(x, y) {
+				continue
+			}
+			if y+1 == hi || !s.filled(x, y+1) {
+				edges[index(x, y+1)] |= 1 << east
+			}
+			if x+1 == hi || !s.filled(x+1, y) {
+				edges[index(x+1, y+1)] |= 1 << south
+			}
+			if y == lo || !s.filled(x, y-1) {
+				edges[index(x+1, y)] |= 1 << west
+			}
+			if x == lo || !s.filled(x-1, y) {
+				edges[index(x, y)] |= 1 << north
+			}
+		}
+	}
+
+	var contours [][]point
+	for x := lo; x <= hi; x++ {
+		for y := lo; y <= hi; y++ {
+			if edges[index(x, y)] == 0 {
+				continue
+			}
+			// Follow the edges, preferring right turns, until back at the start
+			var loop []vec
+			cx, cy, dir := x, y, -1
+			for {
+				out := edges[index(cx, cy)]
+				next := -1
+				for _, d := range []int{(dir + 1) % 4, dir, (dir + 3) % 4} {
+					if dir < 0 {
+						d = bits.TrailingZeros8(out)
+					}
+					if out&(1<<d) != 0 {
+						next = d
+						break
+					}
+				}
+				if next < 0 {
+					break
+				}
+				edges[index(cx, cy)] &^= 1 << next
+				if next != dir {
+					loop = append(loop, vec{float64(cx), float64(cy)})
+				}
+				cx, cy, dir = cx+steps[next][0], cy+steps[next][1], next
+				if cx == x && cy == y {
+					break
+				}
+			}
+			if contour := simplify(loop); contour != nil {
+				contours = append(contours, contour)
+			}
+		}
+	}
+	return contours
+}
+
+// simplify simplifies a closed polygon (Douglas-Peucker), dropping tiny ones
+func simplify(loop []vec) []point {
+	if len(loop) < 3 {
+		return nil
+	}
+	far := 0
+	for i, v := range loop {
+		if dist2(v, loop[0]) > dist2(loop[far], loop[0]) {
+			far = i
+		}
+	}
+	keep := []int{0}
+	keep = append(keep, douglasPeucker(loop, 0, far)...)
+	keep = append(keep, far)
+	closed := append(slices.Clone(loop), loop[0])
+	keep = append(keep, douglasPeucker(closed, far, len(loop))...)
+
+	var area float64
+	var contour []point
+	for i, k := range keep {
+		a, b := loop[k], loop[keep[(i+1)%len(keep)]]
+		area += a.x*b.y - b.x*a.y
+		contour = append(contour, point{int16(a.x), int16(a.y)})
+	}
+	if len(contour) < 3 || math.Abs(area)/2 < minArea {
+		return nil
+	}
+	return contour
+}
+
+// douglasPeucker returns the indices of the vertices strictly between from and to to keep
+func douglasPeucker(pts []vec, from, to int) []int {
+	if to-from < 2 {
+		return nil
+	}
+	a, b := pts[from], pts[to]
+	worst, worstDist := -1, tolerance
+	for i := from + 1; i < to; i++ {
+		if d := segmentDist(pts[i], a, b); d > worstDist {
+			worst, worstDist = i, d
+		}
+	}
+	if worst < 0 {
+		return nil
+	}
+	keep := douglasPeucker(pts, from, worst)
+	keep = append(keep, worst)
+	return append(keep, douglasPeucker(pts, worst, to)...)
+}
+
+func dist2(a, b vec) float64 { return (a.x-b.x)*(a.x-b.x) + (a.y-b.y)*(a.y-b.y) }
+
+// segmentDist returns the distance of p to the segment ab
+func segmentDist(p, a, b vec) float64 {
+	l2 := dist2(a, b)
+	if l2 == 0 {
+		return math.Sqrt(dist2(p, a))
+	}
+	t := max(0, min(1, ((p.x-a.x)*(b.x-a.x)+(p.y-a.y)*(b.y-a.y))/l2))
+	return math.Sqrt(dist2(p, vec{a.x + t*(b.x-a.x), a.y + t*(b.y-a.y)}))
+}
+
+// glyph is a glyph of the generated font
+type glyph struct {
+	contours [][]point
+	advance  uint16
+}
+
+type cmapEntry struct {
+	r     rune
+	glyph uint16
+}
+
+// fontBuilder writes a TrueType font with glyf outlines
+type fontBuilder struct {
+	upem            uint16
+	ascent, descent int16
+	glyphs          []glyph
+	cmap            []cmapEntry
+}
+
+type bbox struct{ xMin, yMin, xMax, yMax int16 }
+
+func (g glyph) bounds() bbox {
+	b := bbox{math.MaxInt16, math.MaxInt16, math.MinInt16, math.MinInt16}
+	for _, c := range g.contours {
+		for _, p := range c {
+			b.xMin, b.yMin = min(b.xMin, p.x), min(b.yMin, p.y)
+			b.xMax, b.yMax = max(b.xMax, p.x), max(b.yMax, p.y)
+		}
+	}
+	return b
+}
+
+// encode returns the glyf table entry of a simple glyph
+func (g glyph) encode() []byte {
+	if len(g.contours) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	b := g.bounds()
+	w := func(v any) { binary.Write(&buf, binary.BigEndian, v) }
+	w(int16(len(g.contours)))
+	w(b)
+	end := -1
+	for _, c := range g.contours {
+		end += len(c)
+		w(uint16(end))
+	}
+	w(uint16(0)) // No instructions
+
+	var flags []byte
+	var xs, ys bytes.Buffer
+	var prev point
+	for _, c := range g.contours {
+		for _, p := range c {
+			flag := byte(0x01) // On curve
+			flag |= encodeDelta(int(p.x)-int(prev.x), 0x02, 0x10, &xs)
+			flag |= encodeDelta(int(p.y)-int(prev.y), 0x04, 0x20, &ys)
+			flags = append(flags, flag)
+			prev = p
+		}
+	}
+	// Runs of equal flags use the repeat flag
+	for i := 0; i < len(flags); {
+		n := 1
+		for i+n < len(flags) && flags[i+n] == flags[i] && n < 256 {
+			n++
+		}
+		if n > 1 {
+			buf.WriteByte(flags[i] | 0x08)
+			buf.WriteByte(byte(n - 1))
+		} else {
+			buf.WriteByte(flags[i])
+		}
+		i += n
+	}
+	buf.Write(xs.Bytes())
+	buf.Write(ys.Bytes())
+	for buf.Len()%4 != 0 {
+		buf.WriteByte(0)
+	}
+	return buf.Bytes()
+}
+
+// encodeDelta writes a coordinate delta and returns its flags
+func encodeDelta(d int, short, same byte, buf *bytes.Buffer) byte {
+	switch {
+	case d == 0:
+		return same
+	case d > -256 && d < 256:
+		if d > 0 {
+			buf.WriteByte(byte(d))
+			return short | same
+		}
+		buf.WriteByte(byte(-d))
+		return short
+	default:
+		binary.Write(buf, binary.BigEndian, int16(d))
+		return 0
+	}
+}
+
+func (f *fontBuilder) build() []byte {
+	var glyf, loca bytes.Buffer
+	var maxPoints, maxContours int
+	total := bbox{math.MaxInt16, math.MaxInt16, math.MinInt16, math.MinInt16}
+	var maxAdvance uint16
+	var minLSB, minRSB, maxExtent int16 = math.MaxInt16, math.MaxInt16, math.MinInt16
+	for _, g := range f.glyphs {
+		binary.Write(&loca, binary.BigEndian, uint32(glyf.Len()))
+		glyf.Write(g.encode())
+		maxAdvance = max(maxAdvance, g.advance)
+		if len(g.contours) == 0 {
+			continue
+		}
+		points := 0
+		for _, c := range g.contours {
+			points += len(c)
+		}
+		maxPoints, maxContours = max(maxPoints, points), max(maxContours, len(g.contours))
+		b := g.bounds()
+		total = bbox{min(total.xMin, b.xMin), min(total.yMin, b.yMin), max(total.xMax, b.xMax), max(total.yMax, b.yMax)}
+		minLSB, minRSB = min(minLSB, b.xMin), min(minRSB, int16(g.advance)-b.xMax)
+		maxExtent = max(maxExtent, b.xMax)
+	}
+	binary.Write(&loca, binary.BigEndian, uint32(glyf.Len()))
+	numGlyphs := uint16(len(f.glyphs))
+
+	var hmtx bytes.Buffer
+	for _, g := range f.glyphs {
+		lsb := int16(0)
+		if len(g.contours) > 0 {
+			lsb = g.bounds().xMin
+		}
+		binary.Write(&hmtx, binary.BigEndian, struct {
+			Advance uint16
+			LSB     int16
+		}{g.advance, lsb})
+	}
+
+	tablesByTag := map[string][]byte{
+		"cmap": f.cmapTable(),
+		"glyf": glyf.Bytes(),
+		"head": be(struct {
+			Major, Minor       uint16
+			Revision           uint32
+			ChecksumAdjustment uint32
+			Magic              uint32
+			Flags, UnitsPerEm  uint16
+			Created, Modified  int64
+			Bounds             bbox
+			MacStyle, LowestPP uint16
+			DirectionHint      int16
+			IndexToLocFormat   int16
+			GlyphDataFormat    int16
+		}{1, 0, 0x00010000, 0, 0x5F0F3CF5, 0x0003, f.upem, 0, 0, total, 0, 8, 2, 1, 0}),
+		"hhea": be(struct {
+			Major, Minor                  uint16
+			Ascent, Descent, LineGap      int16
+			MaxAdvance                    uint16
+			MinLSB, MinRSB, MaxExtent     int16
+			CaretRise, CaretRun, CaretOff int16
+			Reserved                      [4]int16
+			MetricFormat                  int16
+			NumHMetrics                   uint16
+		}{1, 0, f.ascent, f.descent, 0, maxAdvance, minLSB, minRSB, maxExtent, 1, 0, 0, [4]int16{}, 0, numGlyphs}),
+		"hmtx": hmtx.Bytes(),
+		"loca": loca.Bytes(),
+		"maxp": be(struct {
+			Version                            uint32
+			NumGlyphs, MaxPoints, MaxContours  uint16
+			MaxCompositePoints, MaxCompContour uint16
+			MaxZones, MaxTwilight, MaxStorage  uint16
+			MaxFDefs, MaxIDefs, MaxStack       uint16
+			MaxInstructions, MaxCompElements   uint16
+			MaxCompDepth                       uint16
+		}{0x00010000, numGlyphs, uint16(maxPoints), uint16(maxContours), 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0}),
+		"name": nameTable(map[uint16]string{
+			0: copyright, 1: familyName, 2: "Regular", 3: psName, 4: familyName,
+			5: "Version 1.000", 6: psName, 13: license, 14: licenseURL,
+		}),
+		"OS/2": f.os2Table(total, maxAdvance),
+		"post": be(struct {
+			Version                      uint32
+			ItalicAngle                  int32
+			UnderlinePos, UnderlineThick int16
+			IsFixedPitch                 uint32
+			MemType                      [4]uint32
+		}{0x00030000, 0, 0, 0, 0, [4]uint32{}}),
+	}
+	return assemble(tablesByTag)
+}
+
+// cmapTable maps code points with a format 12 subtable (Windows, Unicode full repertoire)
+func (f *fontBuilder) cmapTable() []byte {
+	slices.SortFunc(f.cmap, func(a, b cmapEntry) int { return int(a.r - b.r) })
+	type group struct{ start, end, glyph uint32 }
+	var groups []group
+	for _, e := range f.cmap {
+		if n := len(groups); n > 0 {
+			last := &groups[n-1]
+			if uint32(e.r) == last.end+1 && uint32(e.glyph) == last.glyph+last.end-last.start+1 {
+				last.end++
+				continue
+			}
+		}
+		groups = append(groups, group{uint32(e.r), uint32(e.r), uint32(e.glyph)})
+	}
+	var buf bytes.Buffer
+	w := func(v any) { binary.Write(&buf, binary.BigEndian, v) }
+	w([]uint16{0, 1})  // Version, one subtable
+	w([]uint16{3, 10}) // Windows, Unicode full repertoire
+	w(uint32(12))      // Offset
+	w([]uint16{12, 0}) // Format 12
+	w(uint32(16 + 12*len(groups)))
+	w(uint32(0)) // Language
+	w(uint32(len(groups)))
+	w(groups)
+	return buf.Bytes()
+}
+
+func (f *fontBuilder) os2Table(b bbox, avgWidth uint16) []byte {
+	var first, last uint16 = 0xFFFF, 0xFFFF
+	if len(f.cmap) > 0 {
+		first = uint16(min(f.cmap[0].r, 0xFFFF))
+	}
+	return be(struct {
+		Version                 uint16
+		AvgCharWidth            int16
+		WeightClass, WidthClass uint16
+		Type                    uint16
+		SubX, SubY, SubXOff     int16
+		SubYOff, SupX, SupY     int16
+		SupXOff, SupYOff        int16
+		StrikeSize, StrikePos   int16
+		FamilyClass             int16
+		Panose                  [10]byte
+		UnicodeRange            [4]uint32
+		VendID                  [4]byte
+		Selection, First, Last  uint16
+		TypoAscender, TypoDesc  int16
+		TypoLineGap             int16
+		WinAscent, WinDescent   uint16
+		CodePageRange           [2]uint32
+		XHeight, CapHeight      int16
+		DefaultChar, BreakChar  uint16
+		MaxContext              uint16
+	}{
+		4, int16(avgWidth), 400, 5, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		[10]byte{}, [4]uint32{0, 1 << (57 - 32), 0, 0}, [4]byte{'N', 'O', 'N', 'E'},
+		0x0040, first, last,
+		f.ascent, f.descent, 0, uint16(max(b.yMax, 0)), uint16(max(-b.yMin, 0)),
+		[2]uint32{1, 0}, 0, 0, 0, 0x20, 1,
+	})
+}
+
+// nameTable writes Windows (UTF-16BE, en-US) name records
+func nameTable(names map[uint16]string) []byte {
+	ids := slices.Sorted(func(yield func(uint16) bool) {
+		for id := range names {
+			if !yield(id) {
+				return
+			}
+		}
+	})
+	var records, storage bytes.Buffer
+	for _, id := range ids {
+		var s bytes.Buffer
+		binary.Write(&s, binary.BigEndian, utf16.Encode([]rune(names[id])))
+		binary.Write(&records, binary.BigEndian, []uint16{3, 1, 0x409, id, uint16(s.Len()), uint16(storage.Len())})
+		storage.Write(s.Bytes())
+	}
+	header := be([]uint16{0, uint16(len(ids)), uint16(6 + records.Len())})
+	return slices.Concat(header, records.Bytes(), storage.Bytes())
+}
+
+func be(v any) []byte {
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.BigEndian, v); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+func checksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
+
+// assemble writes the table directory and the 4-byte aligned tables
+func assemble(tablesByTag map[string][]byte) []byte {
+	tags := slices.Sorted(func(yield func(string) bool) {
+		for tag := range tablesByTag {
+			if !yield(tag) {
+				return
+			}
+		}
+	})
+	n := len(tags)
+	entrySelector := 0
+	for 1<<(entrySelector+1) <= n {
+		entrySelector++
+	}
+	searchRange := 16 << entrySelector
+
+	var dir, body bytes.Buffer
+	binary.Write(&dir, binary.BigEndian, []uint32{0x00010000})
+	binary.Write(&dir, binary.BigEndian, []uint16{uint16(n), uint16(searchRange), uint16(entrySelector), uint16(16*n - searchRange)})
+	offset := 12 + 16*n
+	headOffset := 0
+	for _, tag := range tags {
+		data := tablesByTag[tag]
+		if tag == "head" {
+			headOffset = offset + body.Len()
+		}
+		dir.WriteString(tag)
+		binary.Write(&dir, binary.BigEndian, []uint32{checksum(data), uint32(offset + body.Len()), uint32(len(data))})
+		body.Write(data)
+		for body.Len()%4 != 0 {
+			body.WriteByte(0)
+		}
+	}
+	ttf := slices.Concat(dir.Bytes(), body.Bytes())
+	binary.BigEndian.PutUint32(ttf[headOffset+8:], 0xB1B0AFBA-checksum(ttf))
+	return ttf
+}
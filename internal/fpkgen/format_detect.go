@@ -12,11 +12,15 @@ const (
 	FormatWebP
 	FormatBMP
 	FormatICO
+	FormatSVG
+	FormatGIF
+	FormatTIFF
+	FormatICNS
 )
 
 // String returns the string representation of the image format
 func (f ImageFormat) String() string {
-	names := []string{"Unknown", "PNG", "JPEG", "WebP", "BMP", "ICO"}
+	names := []string{"Unknown", "PNG", "JPEG", "WebP", "BMP", "ICO", "SVG", "GIF", "TIFF", "ICNS"}
 	if int(f) < len(names) {
 		return names[f]
 	}
@@ -25,12 +29,17 @@ func (f ImageFormat) String() string {
 
 // Magic bytes for format detection
 var (
-	magicPNG  = []byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A} // PNG signature
-	magicJPEG = []byte{0xFF, 0xD8, 0xFF}                                // JPEG SOI marker
-	magicBMP  = []byte{0x42, 0x4D}                                      // "BM"
-	magicICO  = []byte{0x00, 0x00, 0x01, 0x00}                          // ICO header
-	magicRIFF = []byte{0x52, 0x49, 0x46, 0x46}                          // "RIFF" for WebP
-	magicWEBP = []byte{0x57, 0x45, 0x42, 0x50}                          // "WEBP" at offset 8
+	magicPNG    = []byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A} // PNG signature
+	magicJPEG   = []byte{0xFF, 0xD8, 0xFF}                               // JPEG SOI marker
+	magicBMP    = []byte{0x42, 0x4D}                                     // "BM"
+	magicICO    = []byte{0x00, 0x00, 0x01, 0x00}                         // ICO header
+	magicRIFF   = []byte{0x52, 0x49, 0x46, 0x46}                         // "RIFF" for WebP
+	magicWEBP   = []byte{0x57, 0x45, 0x42, 0x50}                         // "WEBP" at offset 8
+	magicGIF87  = []byte("GIF87a")                                       // GIF 87a signature
+	magicGIF89  = []byte("GIF89a")                                       // GIF 89a signature
+	magicTIFFLE = []byte{0x49, 0x49, 0x2A, 0x00}                         // "II*\0" little-endian TIFF
+	magicTIFFBE = []byte{0x4D, 0x4D, 0x00, 0x2A}                         // "MM\0*" big-endian TIFF
+	magicICNS   = []byte("icns")                                         // Apple icon image
 )
 
 // detectFormat detects the image format by examining magic bytes in the data.
@@ -57,6 +66,21 @@ func detectFormat(data []byte) ImageFormat {
 		return FormatWebP
 	}
 
+	// Check GIF (GIF87a or GIF89a)
+	if len(data) >= 6 && (bytes.HasPrefix(data, magicGIF87) || bytes.HasPrefix(data, magicGIF89)) {
+		return FormatGIF
+	}
+
+	// Check TIFF (II*\0 or MM\0*)
+	if len(data) >= 4 && (bytes.HasPrefix(data, magicTIFFLE) || bytes.HasPrefix(data, magicTIFFBE)) {
+		return FormatTIFF
+	}
+
+	// Check ICNS ("icns" followed by the big-endian file length)
+	if len(data) >= 8 && bytes.HasPrefix(data, magicICNS) {
+		return FormatICNS
+	}
+
 	// Check ICO (starts with 00 00 01 00)
 	if len(data) >= 4 && bytes.HasPrefix(data, magicICO) {
 		return FormatICO
@@ -67,5 +91,10 @@ func detectFormat(data []byte) ImageFormat {
 		return FormatBMP
 	}
 
+	// Check SVG (text format, root element must be <svg>)
+	if isSVG(data) {
+		return FormatSVG
+	}
+
 	return FormatUnknown
 }
@@ -61,6 +61,32 @@ func TestDetectFormat_ICO(t *testing.T) {
 	}
 }
 
+// TestDetectFormat_GIF tests GIF format detection
+func TestDetectFormat_GIF(t *testing.T) {
+	for _, data := range [][]byte{[]byte("GIF87a\x01\x00"), []byte("GIF89a\x01\x00")} {
+		if got := detectFormat(data); got != FormatGIF {
+			t.Errorf("detectFormat(%q) = %v, want %v", data, got, FormatGIF)
+		}
+	}
+}
+
+// TestDetectFormat_TIFF tests TIFF format detection (both byte orders)
+func TestDetectFormat_TIFF(t *testing.T) {
+	for _, data := range [][]byte{{0x49, 0x49, 0x2A, 0x00, 0x08}, {0x4D, 0x4D, 0x00, 0x2A, 0x00}} {
+		if got := detectFormat(data); got != FormatTIFF {
+			t.Errorf("detectFormat(%v) = %v, want %v", data, got, FormatTIFF)
+		}
+	}
+}
+
+// TestDetectFormat_ICNS tests ICNS format detection
+func TestDetectFormat_ICNS(t *testing.T) {
+	icnsData := []byte{0x69, 0x63, 0x6E, 0x73, 0x00, 0x00, 0x00, 0x08}
+	if got := detectFormat(icnsData); got != FormatICNS {
+		t.Errorf("detectFormat(ICNS data) = %v, want %v", got, FormatICNS)
+	}
+}
+
 // TestDetectFormat_Unknown tests unknown format detection
 func TestDetectFormat_Unknown(t *testing.T) {
 	// Random data that doesn't match any format
@@ -159,6 +185,50 @@ func TestProperty_WebPDetection(t *testing.T) {
 	}
 }
 
+// Property test: GIF format detection is consistent
+// For any byte slice with GIF magic bytes prefix, detectFormat returns FormatGIF
+func TestProperty_GIFDetection(t *testing.T) {
+	f := func(suffix []byte) bool {
+		data := append([]byte("GIF89a"), suffix...)
+		return detectFormat(data) == FormatGIF
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 100}); err != nil {
+		t.Errorf("Property test failed: %v", err)
+	}
+}
+
+// Property test: TIFF format detection is consistent
+// For any byte slice with TIFF magic bytes prefix, detectFormat returns FormatTIFF
+func TestProperty_TIFFDetection(t *testing.T) {
+	f := func(bigEndian bool, suffix []byte) bool {
+		tiffMagic := []byte{0x49, 0x49, 0x2A, 0x00}
+		if bigEndian {
+			tiffMagic = []byte{0x4D, 0x4D, 0x00, 0x2A}
+		}
+		data := append(tiffMagic, suffix...)
+		return detectFormat(data) == FormatTIFF
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 100}); err != nil {
+		t.Errorf("Property test failed: %v", err)
+	}
+}
+
+// Property test: ICNS format detection is consistent
+// For any byte slice with ICNS magic bytes and length, detectFormat returns FormatICNS
+func TestProperty_ICNSDetection(t *testing.T) {
+	f := func(length uint32, suffix []byte) bool {
+		icnsHeader := []byte{
+			0x69, 0x63, 0x6E, 0x73, // "icns"
+			byte(length >> 24), byte(length >> 16), byte(length >> 8), byte(length), // file length
+		}
+		data := append(icnsHeader, suffix...)
+		return detectFormat(data) == FormatICNS
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 100}); err != nil {
+		t.Errorf("Property test failed: %v", err)
+	}
+}
+
 // Property test: Format detection is deterministic
 // For any byte slice, calling detectFormat twice returns the same result
 func TestProperty_Deterministic(t *testing.T) {
@@ -184,6 +254,10 @@ func TestImageFormat_String(t *testing.T) {
 		{FormatWebP, "WebP"},
 		{FormatBMP, "BMP"},
 		{FormatICO, "ICO"},
+		{FormatSVG, "SVG"},
+		{FormatGIF, "GIF"},
+		{FormatTIFF, "TIFF"},
+		{FormatICNS, "ICNS"},
 		{ImageFormat(100), "Unknown"}, // Out of range
 	}
 
@@ -3,41 +3,119 @@ package fpkgen
 import (
 	"context"
 	"fmt"
+	"image/color"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	dockercontainer "github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
 )
 
+// Options configures a Generator
+type Options struct {
+	PresetFile    string           // Optional YAML file extending/overriding the built-in image presets
+	DiscoverIcons bool             // Discover icons from the running web app when no icon is configured
+	IconCacheDir  string           // Directory of the persistent icon cache (empty disables caching)
+	IconCache     IconCacheOptions // Icon cache max-age, size cap and offline mode
+	IconMirrors   []string         // Ordered base URLs serving dashboard-icons (empty uses the canonical CDN)
+	IconFonts     []string         // Extra TTF/OTF/TTC fonts for generated icons (e.g. CJK fonts)
+	IconRoots     []string         // Directories file:// icons, description files and hooks may be read from
+	IconWait      time.Duration    // How long to wait for icons before installing with placeholders (0 waits for all icons)
+	IconRefresh   time.Duration    // How often to re-check the icons of installed apps (0 disables)
+	TemplateDir   string           // Directory of templates overriding the embedded ones, see LoadTemplateEngine
+	StateDir      string           // Directory of files shared with the scripts of generated apps (empty disables uninstall markers)
+
+	// OnIconsReady receives a regenerated package once icons that were still loading
+	// when the package was generated have arrived, or icons refreshed later changed.
+	// The callee owns appDir. An error reports that the app was not upgraded, so
+	// that the next icon refresh retries. Without it, package generation always
+	// waits for all icons.
+	OnIconsReady func(config *AppConfig, appDir string) error
+}
+
 // Generator handles fnOS application package generation from Docker containers
 type Generator struct {
-	dockerClient   *client.Client        // Docker API client
-	templateEngine *TemplateEngine       // Template engine for rendering
-	installed      map[string]*AppConfig // map[containerID]AppConfig - installed apps
-	mu             sync.RWMutex          // Protects installed map
+	dockerClient   *client.Client                               // Docker API client
+	templateEngine *TemplateEngine                              // Template engine for rendering
+	presets        *PresetCatalog                               // Image presets for zero-config apps
+	discoverIcons  bool                                         // Discover icons from the running web app
+	iconCache      *IconCache                                   // Persistent icon download cache (nil if disabled)
+	iconMirrors    *iconMirrors                                 // Mirrors of dashboard-icons URLs (nil downloads URLs as is)
+	iconFonts      *iconFonts                                   // Fonts of generated letter avatar and emoji icons
+	fileRoots      *fileRoots                                   // Allowed directories of file:// sources (nil allows any path)
+	iconWait       time.Duration                                // How long to wait for icons before installing with placeholders
+	onIconsReady   func(config *AppConfig, appDir string) error // Receives packages with icons that arrived late (nil waits for all icons)
+	iconRefresh    time.Duration                                // How often to re-check the icons of installed apps
+	stateDir       string                                       // Directory of files shared with app scripts (empty if unavailable)
+	appsDir        string                                       // Directory App Center installs apps to
+	iconHashes     map[string]string                            // map[containerID]hash - icon files of the last generated package
+	installed      map[string]*AppConfig                        // map[containerID]AppConfig - installed apps
+	mu             sync.RWMutex                                 // Protects installed and iconHashes maps
 }
 
 // NewGenerator creates a new application generator
-func NewGenerator() (*Generator, error) {
+func NewGenerator(opts Options) (*Generator, error) {
 	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Docker client: %w", err)
 	}
 
 	// Initialize template engine
-	tmplEngine, err := NewTemplateEngine()
+	tmplEngine, err := LoadTemplateEngine(opts.TemplateDir)
 	if err != nil {
 		cli.Close()
 		return nil, fmt.Errorf("failed to create template engine: %w", err)
 	}
 
+	// Load image presets
+	presets, err := LoadPresetCatalog(opts.PresetFile)
+	if err != nil {
+		cli.Close()
+		return nil, fmt.Errorf("failed to load presets: %w", err)
+	}
+
+	// Open icon cache (optional, icons are downloaded directly without it)
+	var iconCache *IconCache
+	if opts.IconCacheDir != "" {
+		iconCache, err = NewIconCache(opts.IconCacheDir, opts.IconCache)
+		if err != nil {
+			slog.Warn("Icon cache disabled", "dir", opts.IconCacheDir, "error", err)
+		}
+	}
+
+	// Load extra fonts for generated icons (the embedded font is always available)
+	iconFonts, err := loadIconFonts(opts.IconFonts)
+	if err != nil {
+		slog.Warn("Failed to load icon fonts", "error", err)
+	}
+
+	// Create the state directory shared with app scripts (optional)
+	stateDir := opts.StateDir
+	if stateDir != "" {
+		if err := createStateDir(stateDir); err != nil {
+			slog.Warn("State directory unavailable, uninstall markers and config wizards disabled", "dir", stateDir, "error", err)
+			stateDir = ""
+		}
+	}
+
 	return &Generator{
 		dockerClient:   cli,
 		templateEngine: tmplEngine,
+		presets:        presets,
+		discoverIcons:  opts.DiscoverIcons && !opts.IconCache.Offline, // Discovery downloads icons
+		iconCache:      iconCache,
+		iconMirrors:    newIconMirrors(opts.IconMirrors),
+		iconFonts:      iconFonts,
+		fileRoots:      newFileRoots(opts.IconRoots),
+		iconWait:       opts.IconWait,
+		onIconsReady:   opts.OnIconsReady,
+		iconRefresh:    opts.IconRefresh,
+		stateDir:       stateDir,
+		appsDir:        fnosAppsDir,
 		installed:      make(map[string]*AppConfig),
 	}, nil
 }
@@ -45,42 +123,147 @@ func NewGenerator() (*Generator, error) {
 // GenerateFromContainer creates fnOS app structure from a running container
 // Returns the config, temp directory path (caller should clean up after install)
 func (g *Generator) GenerateFromContainer(ctx context.Context, containerID string) (*AppConfig, string, error) {
-	// 1. Inspect container for full details
+	// 1. Inspect the container and extract its configuration
+	config, err := g.inspectConfig(ctx, containerID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 2. Start loading icons of all entries concurrently
+	icons := g.newIconLoader(config).loadIcons(config)
+
+	// 3. Generate all files using templates in a temp directory
+	slog.Info("Generating fnOS app package", "appName", config.AppName, "container", config.ContainerName)
+
+	appDir, err := g.newPackageDir(config)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 4. Save icons, using placeholders for icons still loading after iconWait
+	wait := g.iconWait
+	if g.onIconsReady == nil {
+		wait = 0 // Placeholders could never be replaced
+	}
+	icons.wait(wait)
+
+	pending, err := writeIcons(appDir, config, icons)
+	if err == nil {
+		err = g.trackIcons(config.ContainerID, appDir)
+	}
+	if err != nil {
+		os.RemoveAll(appDir)
+		return nil, "", fmt.Errorf("failed to handle icons: %w", err)
+	}
+	if pending {
+		slog.Info("Icons still loading, using placeholder icons", "appName", config.AppName)
+		go g.finishIcons(config, icons)
+	}
+
+	slog.Info("Successfully generated fnOS app package", "appDir", appDir)
+
+	return config, appDir, nil
+}
+
+// inspectConfig extracts the configuration of a container, including its image
+// labels and discovered icons
+func (g *Generator) inspectConfig(ctx context.Context, containerID string) (*AppConfig, error) {
 	container, err := g.dockerClient.ContainerInspect(ctx, containerID)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to inspect container: %w", err)
+		return nil, fmt.Errorf("failed to inspect container: %w", err)
 	}
 
-	// 2. Extract configuration from container
-	config := g.extractConfig(&container)
+	// Inspect image for OCI metadata labels (optional)
+	var imageLabels map[string]string
+	imageInfo, err := g.dockerClient.ImageInspect(ctx, container.Image)
+	if err != nil {
+		slog.Debug("Failed to inspect image", "image", container.Config.Image, "error", err)
+	} else if imageInfo.Config != nil {
+		imageLabels = imageInfo.Config.Labels
+	}
+
+	config := g.extractConfig(&container, imageLabels)
+
+	// Published ports are reachable on the host, where WatchCow runs
+	if g.discoverIcons {
+		discoverEntryIcons(ctx, config, "127.0.0.1")
+	}
+	return config, nil
+}
+
+// TrackInstalled marks a container whose app is already installed (e.g. by an
+// earlier run) as installed without regenerating its package, so that its icons
+// are refreshed like the icons of apps installed by this run
+func (g *Generator) TrackInstalled(ctx context.Context, containerID string) (*AppConfig, error) {
+	config, err := g.inspectConfig(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+	g.trackInstalled(containerID, config)
+	return config, nil
+}
+
+// trackInstalled marks an already installed app as installed, recording the
+// icons of the installed package as the icons to compare refreshed icons with
+func (g *Generator) trackInstalled(containerID string, config *AppConfig) {
+	g.MarkInstalled(containerID, config)
+
+	hash, err := hashInstalledIcons(filepath.Join(g.appsDir, config.AppName))
+	if err != nil {
+		slog.Warn("Failed to read installed icons, the next icon refresh reinstalls them", "appName", config.AppName, "error", err)
+		return
+	}
+	g.setIconHash(containerID, hash)
+}
 
-	// 3. Create temp directory for app package
+// newPackageDir creates a temp directory with all files of an app package except icons
+func (g *Generator) newPackageDir(config *AppConfig) (string, error) {
 	appDir, err := os.MkdirTemp("", "watchcow-"+config.AppName+"-")
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to create temp directory: %w", err)
+		return "", fmt.Errorf("failed to create temp directory: %w", err)
 	}
 
 	if err := g.createDirectoryStructure(appDir); err != nil {
 		os.RemoveAll(appDir)
-		return nil, "", fmt.Errorf("failed to create directory structure: %w", err)
+		return "", fmt.Errorf("failed to create directory structure: %w", err)
 	}
 
-	// 4. Generate all files using templates
-	slog.Info("Generating fnOS app package", "appName", config.AppName, "container", config.ContainerName)
-
-	data := NewTemplateData(config)
-
-	if err := g.generateFromTemplates(appDir, data); err != nil {
-		return nil, "", err
+	if err := g.generateFromTemplates(appDir, config); err != nil {
+		os.RemoveAll(appDir)
+		return "", err
 	}
+	return appDir, nil
+}
 
-	if err := g.handleIcons(appDir, config); err != nil {
-		return nil, "", fmt.Errorf("failed to handle icons: %w", err)
+// finishIcons waits for icons that were replaced by placeholders and hands a
+// regenerated package with the real icons to OnIconsReady
+func (g *Generator) finishIcons(config *AppConfig, icons *iconSet) {
+	icons.wait(0)
+	if !icons.upgraded() {
+		slog.Debug("No placeholder icons could be replaced", "appName", config.AppName)
+		return
 	}
 
-	slog.Info("Successfully generated fnOS app package", "appDir", appDir)
+	appDir, err := g.newPackageDir(config)
+	if err != nil {
+		slog.Warn("Failed to regenerate app package with icons", "appName", config.AppName, "error", err)
+		return
+	}
+	var hash string
+	if _, err = writeIcons(appDir, config, icons); err == nil {
+		hash, err = hashIcons(appDir)
+	}
+	if err != nil {
+		os.RemoveAll(appDir)
+		slog.Warn("Failed to regenerate app package with icons", "appName", config.AppName, "error", err)
+		return
+	}
 
-	return config, appDir, nil
+	slog.Info("Icons ready, regenerated fnOS app package", "appName", config.AppName, "appDir", appDir)
+	// Until the upgrade succeeds, the placeholders stay recorded and icon refreshes retry
+	if err := g.onIconsReady(config, appDir); err == nil {
+		g.setIconHash(config.ContainerID, hash)
+	}
 }
 
 // GenerateFromConfig creates fnOS app structure from an AppConfig directly
@@ -98,9 +281,7 @@ func (g *Generator) GenerateFromConfig(config *AppConfig, appDir string) error {
 	// Generate all files using templates
 	slog.Info("Generating fnOS app package from config", "appName", config.AppName)
 
-	data := NewTemplateData(config)
-
-	if err := g.generateFromTemplates(appDir, data); err != nil {
+	if err := g.generateFromTemplates(appDir, config); err != nil {
 		return err
 	}
 
@@ -112,8 +293,20 @@ func (g *Generator) GenerateFromConfig(config *AppConfig, appDir string) error {
 	return nil
 }
 
-// generateFromTemplates generates all files using template engine
-func (g *Generator) generateFromTemplates(appDir string, data *TemplateData) error {
+// generateFromTemplates generates all files using the template set of the app
+func (g *Generator) generateFromTemplates(appDir string, config *AppConfig) error {
+	data := NewTemplateData(config)
+	data.UninstallMarker = uninstallMarker(g.stateDir, config.AppName)
+	data.ConfigRequest = configRequestFile(g.stateDir, config.AppName)
+	if len(data.ConfigEnv) > 0 && data.ConfigRequest == "" {
+		slog.Warn("Config wizard requires a state directory, skipping", "appName", config.AppName)
+		data.ConfigEnv = nil
+	}
+	engine, ok := g.templateEngine.Set(config.TemplateSet)
+	if !ok {
+		slog.Warn("Unknown template set, using the default templates", "appName", config.AppName, "templateSet", config.TemplateSet)
+	}
+
 	// Define template -> file mappings
 	mappings := []struct {
 		template string
@@ -124,12 +317,13 @@ func (g *Generator) generateFromTemplates(appDir string, data *TemplateData) err
 		{"cmd_main.tmpl", "cmd/main", 0755},
 		{"config_privilege.json.tmpl", "config/privilege", 0644},
 		{"config_resource.json.tmpl", "config/resource", 0644},
+		{"wizard_uninstall.json.tmpl", "wizard/uninstall", 0644},
 		{"LICENSE.tmpl", "LICENSE", 0644},
 	}
 
 	for _, m := range mappings {
 		filePath := filepath.Join(appDir, m.path)
-		if err := g.templateEngine.RenderToFile(m.template, filePath, data, m.perm); err != nil {
+		if err := engine.RenderToFile(m.template, filePath, data, m.perm); err != nil {
 			return fmt.Errorf("failed to generate %s: %w", m.path, err)
 		}
 	}
@@ -144,17 +338,15 @@ func (g *Generator) generateFromTemplates(appDir string, data *TemplateData) err
 		return fmt.Errorf("failed to write UI config: %w", err)
 	}
 
-	// Generate empty cmd scripts
-	cmdScripts := []string{"install_init", "install_callback", "uninstall_init", "uninstall_callback",
-		"upgrade_init", "upgrade_callback", "config_init", "config_callback"}
-	for _, script := range cmdScripts {
-		filePath := filepath.Join(appDir, "cmd", script)
-		if err := g.templateEngine.RenderToFile("cmd_empty.tmpl", filePath, data, 0755); err != nil {
-			return fmt.Errorf("failed to generate cmd/%s: %w", script, err)
+	// Generate config wizard (only for apps exposing environment variables)
+	if len(data.ConfigEnv) > 0 {
+		if err := engine.RenderToFile("wizard_config.json.tmpl", filepath.Join(appDir, "wizard", "config"), data, 0644); err != nil {
+			return fmt.Errorf("failed to generate wizard/config: %w", err)
 		}
 	}
 
-	return nil
+	// Generate lifecycle scripts (empty unless a hook is configured, see writeHooks)
+	return writeHooks(engine, appDir, data, config.Hooks)
 }
 
 // extractConfig extracts AppConfig from container inspection result
@@ -162,14 +354,30 @@ func (g *Generator) generateFromTemplates(appDir string, data *TemplateData) err
 //
 //	watchcow.appname      -> manifest.appname
 //	watchcow.display_name -> manifest.display_name
-//	watchcow.desc         -> manifest.desc
+//	watchcow.desc         -> manifest.desc (Markdown or HTML)
+//	watchcow.desc_file    -> manifest.desc, read from a file:// source
 //	watchcow.version      -> manifest.version
 //	watchcow.maintainer   -> manifest.maintainer
+//	watchcow.homepage     -> manifest.maintainer_url
 //	watchcow.service_port -> manifest.service_port
 //	watchcow.protocol     -> UI config (http/https)
 //	watchcow.path         -> UI config (url path)
 //	watchcow.icon         -> app icon URL
-func (g *Generator) extractConfig(container *dockercontainer.InspectResponse) *AppConfig {
+//	watchcow.icon_*       -> icon styling, see parseIconStyle
+//	watchcow.icon_<size>  -> hand-tuned icon per size, see parseSizedIcons
+//	watchcow.template_set -> template set, see LoadTemplateEngine
+//	watchcow.hook.<name>  -> lifecycle script, see parseHooks
+//	watchcow.config.env   -> config wizard fields, see parseConfigEnv
+//	watchcow.secret_env   -> secret environment variables, see secretEnv
+//
+// display_name and entry titles also accept locale-suffixed variants
+// (e.g. watchcow.display_name.en) that become localized entry titles in the UI config.
+// fnOS has no localized app names or descriptions, see warnUnlocalizedLabels.
+//
+// Missing metadata is resolved from the OCI image labels (imageLabels), the
+// image preset and the image tag, see resolveMetadata. Presets also supply the
+// icon, protocol, port, path, UI type and extra entries of well-known images.
+func (g *Generator) extractConfig(container *dockercontainer.InspectResponse, imageLabels map[string]string) *AppConfig {
 	name := strings.TrimPrefix(container.Name, "/")
 	labels := container.Config.Labels
 
@@ -177,26 +385,54 @@ func (g *Generator) extractConfig(container *dockercontainer.InspectResponse) *A
 	sanitizedName := sanitizeAppName(name)
 	appName := getLabel(labels, "watchcow.appname", fmt.Sprintf("watchcow.%s", sanitizedName))
 
-	defaultIcon := getLabel(labels, "watchcow.icon", guessIcon(container.Config.Image))
-	displayName := getLabel(labels, "watchcow.display_name", prettifyName(name))
+	imageRef := parseImageRef(container.Config.Image)
+	preset := g.presets.Lookup(imageRef)
+	if preset == nil {
+		preset = &Preset{}
+	}
+
+	defaultIcon := getLabel(labels, "watchcow.icon", g.presets.guessIcon(imageRef))
+	meta := resolveMetadata(labels, imageLabels, name, imageRef, preset)
+	displayName := meta.DisplayName
+	description := meta.Description
+
+	// A description file takes precedence over the inline label
+	if descFile := getLabel(labels, "watchcow.desc_file", ""); descFile != "" {
+		content, err := loadDescriptionFile(descFile, getBasePath(labels), g.fileRoots)
+		if err != nil {
+			slog.Warn("Failed to load description file", "container", name, "source", descFile, "error", err)
+		} else {
+			description = content
+		}
+	}
+
+	// Localized display names (watchcow.display_name.en, ...) for entry titles
+	displayNameI18n := getLocalizedLabels(labels, "watchcow.display_name")
+	warnUnlocalizedLabels(labels, name)
 
 	config := &AppConfig{
-		AppName:       appName,
-		Version:       getLabel(labels, "watchcow.version", "1.0.0"),
-		DisplayName:   displayName,
-		Description:   getLabel(labels, "watchcow.desc", fmt.Sprintf("Docker container: %s", container.Config.Image)),
-		Maintainer:    getLabel(labels, "watchcow.maintainer", "WatchCow"),
-		ContainerID:   container.ID[:12],
-		ContainerName: name,
-		Image:         container.Config.Image,
-		Protocol:      getLabel(labels, "watchcow.protocol", "http"),
-		Port:          getLabel(labels, "watchcow.service_port", ""),
-		Path:          getLabel(labels, "watchcow.path", "/"),
-		UIType:        getLabel(labels, "watchcow.ui_type", "url"),
-		AllUsers:      getLabel(labels, "watchcow.all_users", "true") == "true",
-		Icon:          defaultIcon,
-		Environment:   filterEnvironment(container.Config.Env),
-		Labels:        labels,
+		AppName:         appName,
+		Version:         meta.Version,
+		DisplayName:     displayName,
+		Description:     description,
+		Maintainer:      meta.Maintainer,
+		Homepage:        meta.Homepage,
+		DisplayNameI18n: displayNameI18n,
+		ContainerID:     container.ID[:12],
+		ContainerName:   name,
+		Image:           container.Config.Image,
+		ImageRef:        imageRef,
+		Protocol:        getLabel(labels, "watchcow.protocol", firstNonEmpty(preset.Protocol, "http")),
+		Port:            getLabel(labels, "watchcow.service_port", hostPortFor(container, preset.Port)),
+		Path:            getLabel(labels, "watchcow.path", firstNonEmpty(preset.Path, "/")),
+		UIType:          getLabel(labels, "watchcow.ui_type", firstNonEmpty(preset.UIType, "url")),
+		AllUsers:        getLabel(labels, "watchcow.all_users", "true") == "true",
+		Icon:            defaultIcon,
+		TemplateSet:     getLabel(labels, "watchcow.template_set", ""),
+		Hooks:           parseHooks(labels, imageLabels, getBasePath(labels), g.fileRoots),
+		Environment:     newSecretEnv(labels).redact(filterEnvironment(container.Config.Env)),
+		ConfigEnv:       parseConfigEnv(labels, container.Config.Env),
+		Labels:          labels,
 	}
 
 	// Extract port if not specified in label
@@ -207,11 +443,21 @@ func (g *Generator) extractConfig(container *dockercontainer.InspectResponse) *A
 	// Parse multi-entry configuration
 	config.Entries = parseEntries(labels, displayName, defaultIcon, config.Port)
 
+	// The default entry falls back to preset protocol/path/ui_type like the legacy fields
+	for i := range config.Entries {
+		if config.Entries[i].Name == "" {
+			config.Entries[i].Protocol = config.Protocol
+			config.Entries[i].Path = config.Path
+			config.Entries[i].UIType = config.UIType
+		}
+	}
+
 	// If no entries configured, create a default entry for backward compatibility
 	if len(config.Entries) == 0 {
 		config.Entries = []Entry{{
 			Name:      "",
 			Title:     displayName,
+			TitleI18n: displayNameI18n,
 			Protocol:  config.Protocol,
 			Port:      config.Port,
 			Path:      config.Path,
@@ -222,6 +468,14 @@ func (g *Generator) extractConfig(container *dockercontainer.InspectResponse) *A
 			NoDisplay: getLabel(labels, "watchcow.no_display", "false") == "true",
 			Control:   nil,
 		}}
+
+		// Add extra entries supplied by the image preset
+		config.Entries = append(config.Entries, presetEntries(preset, container, displayName, defaultIcon, config.Port)...)
+	}
+	localizeEntryTitles(config.Entries, labels, displayNameI18n)
+	for i := range config.Entries {
+		config.Entries[i].IconStyle = parseIconStyle(labels, config.Entries[i].Name)
+		config.Entries[i].SizedIcons = parseSizedIcons(labels, config.Entries[i], defaultIcon)
 	}
 
 	// Extract volumes
@@ -231,6 +485,7 @@ func (g *Generator) extractConfig(container *dockercontainer.InspectResponse) *A
 			Destination: mount.Destination,
 			ReadOnly:    !mount.RW,
 			Type:        string(mount.Type),
+			Name:        mount.Name,
 		})
 	}
 
@@ -251,6 +506,7 @@ func (g *Generator) createDirectoryStructure(appDir string) error {
 		filepath.Join(appDir, "app", "ui", "images"),
 		filepath.Join(appDir, "cmd"),
 		filepath.Join(appDir, "config"),
+		filepath.Join(appDir, "wizard"),
 	}
 
 	for _, dir := range dirs {
@@ -289,6 +545,7 @@ func (g *Generator) MarkUninstalled(containerID string) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 	delete(g.installed, containerID)
+	delete(g.iconHashes, containerID)
 }
 
 // GetAllInstalled returns all installed apps
@@ -333,7 +590,7 @@ func getLabel(labels map[string]string, key, fallback string) string {
 	return fallback
 }
 
-// filterEnvironment removes sensitive/unwanted environment variables
+// filterEnvironment removes unwanted environment variables, secrets are redacted by secretEnv
 func filterEnvironment(env []string) []string {
 	var filtered []string
 	blacklist := []string{"PATH=", "HOME=", "USER=", "HOSTNAME=", "PWD=", "SHLVL="}
@@ -371,32 +628,6 @@ func extractFirstPort(container *dockercontainer.InspectResponse) string {
 	return ""
 }
 
-// guessIcon tries to guess an appropriate icon URL based on image name
-func guessIcon(image string) string {
-	parts := strings.Split(image, "/")
-	imageName := parts[len(parts)-1]
-	imageName = strings.Split(imageName, ":")[0]
-
-	iconMap := map[string]string{
-		"jellyfin": "jellyfin", "portainer": "portainer", "nginx": "nginx",
-		"postgres": "postgresql", "postgresql": "postgresql", "mysql": "mysql",
-		"mariadb": "mariadb", "redis": "redis", "mongodb": "mongodb", "mongo": "mongodb",
-		"plex": "plex", "sonarr": "sonarr", "radarr": "radarr", "traefik": "traefik",
-		"grafana": "grafana", "prometheus": "prometheus", "homeassistant": "home-assistant",
-		"nextcloud": "nextcloud", "gitea": "gitea", "gitlab": "gitlab", "jenkins": "jenkins",
-		"minio": "minio", "rabbitmq": "rabbitmq", "elasticsearch": "elasticsearch",
-		"kibana": "kibana", "caddy": "caddy", "apache": "apache", "httpd": "apache",
-		"wordpress": "wordpress", "ghost": "ghost", "discourse": "discourse",
-		"memos": "memos", "vaultwarden": "vaultwarden", "bitwarden": "bitwarden",
-	}
-
-	if iconName, ok := iconMap[strings.ToLower(imageName)]; ok {
-		return fmt.Sprintf("https://cdn.jsdelivr.net/gh/homarr-labs/dashboard-icons/png/%s.png", iconName)
-	}
-
-	return "https://cdn.jsdelivr.net/gh/homarr-labs/dashboard-icons/png/docker.png"
-}
-
 // prettifyName converts container name to a nice title
 func prettifyName(name string) string {
 	name = strings.TrimSuffix(name, "-1")
@@ -424,6 +655,12 @@ var entryFields = map[string]bool{
 	"ui_type":             true,
 	"all_users":           true,
 	"icon":                true,
+	"icon_bg":             true,
+	"icon_padding":        true,
+	"icon_shape":          true,
+	"icon_trim":           true,
+	"badge":               true,
+	"badge_color":         true,
 	"title":               true,
 	"file_types":          true,
 	"no_display":          true,
@@ -441,6 +678,16 @@ func isEntryField(field string) bool {
 	if strings.HasPrefix(field, "control.") {
 		return true
 	}
+	// Hand-tuned icons per size (icon_64, icon_256, ...)
+	for _, size := range iconSizes {
+		if field == size.label() {
+			return true
+		}
+	}
+	// Localized titles (title.en, title.zh, ...)
+	if locale, ok := strings.CutPrefix(field, "title."); ok && isLocale(locale) {
+		return true
+	}
 	return false
 }
 
@@ -451,7 +698,8 @@ func hasDefaultEntry(labels map[string]string) bool {
 	_, hasPath := labels["watchcow.path"]
 	_, hasTitle := labels["watchcow.title"]
 	_, hasUIType := labels["watchcow.ui_type"]
-	return hasPort || hasProtocol || hasPath || hasTitle || hasUIType
+	hasLocalizedTitle := getLocalizedLabels(labels, "watchcow.title") != nil
+	return hasPort || hasProtocol || hasPath || hasTitle || hasLocalizedTitle || hasUIType
 }
 
 // parseEntry parses a single entry from labels
@@ -500,18 +748,36 @@ func parseEntry(labels map[string]string, name string, displayName string, defau
 		}
 	}
 
+	// Badges distinguish named entries sharing the app icon
+	var badge string
+	var badgeColor color.Color
+	if name != "" {
+		badge = getLabel(labels, prefix+"badge", "")
+		if val := getLabel(labels, prefix+"badge_color", ""); val != "" {
+			c, err := parseIconColor(val)
+			if err != nil {
+				slog.Warn("Ignoring invalid icon label", "label", prefix+"badge_color", "value", val, "error", err)
+			} else {
+				badgeColor = c
+			}
+		}
+	}
+
 	return Entry{
-		Name:      name,
-		Title:     title,
-		Protocol:  getLabel(labels, prefix+"protocol", "http"),
-		Port:      getLabel(labels, prefix+"service_port", ""),
-		Path:      getLabel(labels, prefix+"path", "/"),
-		UIType:    getLabel(labels, prefix+"ui_type", "url"),
-		AllUsers:  getLabel(labels, prefix+"all_users", "true") == "true",
-		Icon:      getLabel(labels, prefix+"icon", defaultIcon),
-		FileTypes: fileTypes,
-		NoDisplay: getLabel(labels, prefix+"no_display", "false") == "true",
-		Control:   control,
+		Name:       name,
+		Title:      title,
+		TitleI18n:  getLocalizedLabels(labels, prefix+"title"),
+		Protocol:   getLabel(labels, prefix+"protocol", "http"),
+		Port:       getLabel(labels, prefix+"service_port", ""),
+		Path:       getLabel(labels, prefix+"path", "/"),
+		UIType:     getLabel(labels, prefix+"ui_type", "url"),
+		AllUsers:   getLabel(labels, prefix+"all_users", "true") == "true",
+		Icon:       getLabel(labels, prefix+"icon", defaultIcon),
+		FileTypes:  fileTypes,
+		NoDisplay:  getLabel(labels, prefix+"no_display", "false") == "true",
+		Control:    control,
+		Badge:      badge,
+		BadgeColor: badgeColor,
 	}
 }
 
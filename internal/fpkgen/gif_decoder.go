@@ -0,0 +1,156 @@
+package fpkgen
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+)
+
+// maxGIFFrames limits how many frames of an animated GIF are composited
+const maxGIFFrames = 64
+
+// decodeGIF decodes a GIF image and returns its best frame.
+// Animated GIFs are composited frame by frame (honoring disposal methods) and the
+// frame with the most opaque pixels is returned, so icons whose animation starts
+// from a blank or partial frame still get a complete image. Ties keep the earliest frame.
+func decodeGIF(data []byte) (image.Image, error) {
+	// gif.DecodeAll allocates every frame up front: check the logical screen and
+	// the frame count before decoding
+	cfg, err := gif.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkIconSize(cfg.Width, cfg.Height); err != nil {
+		return nil, err
+	}
+	if pixels := gifFramePixels(data); pixels > maxGIFPixels {
+		return nil, fmt.Errorf("animated GIF too large: %d pixels in all frames (max %d)", pixels, maxGIFPixels)
+	}
+
+	anim, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(anim.Image) == 0 {
+		return nil, fmt.Errorf("invalid GIF file: no frames")
+	}
+
+	// Single frame: nothing to composite
+	if len(anim.Image) == 1 {
+		return anim.Image[0], nil
+	}
+
+	// Logical screen; fall back to the first frame bounds if the header is empty
+	bounds := image.Rect(0, 0, anim.Config.Width, anim.Config.Height)
+	if bounds.Empty() {
+		bounds = anim.Image[0].Bounds()
+	}
+
+	canvas := image.NewRGBA(bounds)
+	var best *image.RGBA
+	bestOpaque := -1
+
+	for i, frame := range anim.Image {
+		if i >= maxGIFFrames {
+			break
+		}
+
+		var previous *image.RGBA
+		disposal := byte(0)
+		if i < len(anim.Disposal) {
+			disposal = anim.Disposal[i]
+		}
+		if disposal == gif.DisposalPrevious {
+			previous = cloneRGBA(canvas)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+
+		if opaque := countOpaque(canvas); opaque > bestOpaque {
+			best = cloneRGBA(canvas)
+			bestOpaque = opaque
+		}
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+
+	return best, nil
+}
+
+// gifFramePixels sums the frame sizes of the image descriptors of a GIF stream
+// without decoding them. Parsing stops at the trailer or at the first malformed
+// or truncated block.
+func gifFramePixels(data []byte) int {
+	const headerSize = 13 // Signature, version and logical screen descriptor
+	if len(data) < headerSize {
+		return 0
+	}
+
+	pos := headerSize
+	if flags := data[10]; flags&0x80 != 0 {
+		pos += 3 << ((flags & 0x07) + 1) // Global color table
+	}
+
+	// skipSubBlocks returns the position after a sequence of data sub-blocks
+	skipSubBlocks := func(pos int) int {
+		for pos < len(data) {
+			size := int(data[pos])
+			pos++
+			if size == 0 {
+				return pos
+			}
+			pos += size
+		}
+		return len(data)
+	}
+
+	pixels := 0
+	for pos < len(data) {
+		switch data[pos] {
+		case 0x21: // Extension: introducer, label, sub-blocks
+			pos = skipSubBlocks(pos + 2)
+		case 0x2C: // Image descriptor, optional local color table, LZW code size, sub-blocks
+			if pos+10 > len(data) {
+				return pixels
+			}
+			width := int(binary.LittleEndian.Uint16(data[pos+5:]))
+			height := int(binary.LittleEndian.Uint16(data[pos+7:]))
+			pixels += width * height
+			flags := data[pos+9]
+			pos += 10
+			if flags&0x80 != 0 {
+				pos += 3 << ((flags & 0x07) + 1)
+			}
+			pos = skipSubBlocks(pos + 1)
+		default: // Trailer (0x3B) or garbage
+			return pixels
+		}
+	}
+	return pixels
+}
+
+// countOpaque returns the number of fully opaque pixels in an image
+func countOpaque(img *image.RGBA) int {
+	count := 0
+	for i := 3; i < len(img.Pix); i += 4 {
+		if img.Pix[i] == 0xFF {
+			count++
+		}
+	}
+	return count
+}
+
+// cloneRGBA returns a copy of an RGBA image
+func cloneRGBA(img *image.RGBA) *image.RGBA {
+	clone := *img
+	clone.Pix = append([]uint8(nil), img.Pix...)
+	return &clone
+}
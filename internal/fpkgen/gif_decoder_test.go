@@ -0,0 +1,126 @@
+package fpkgen
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+	"testing/quick"
+)
+
+// gifTestPalette is a small palette with a transparent first entry
+var gifTestPalette = color.Palette{color.Transparent, color.RGBA{R: 0xFF, A: 0xFF}, color.RGBA{B: 0xFF, A: 0xFF}}
+
+// newGIFFrame creates a frame filling rect with palette index idx
+func newGIFFrame(rect image.Rectangle, idx uint8) *image.Paletted {
+	frame := image.NewPaletted(rect, gifTestPalette)
+	for i := range frame.Pix {
+		frame.Pix[i] = idx
+	}
+	return frame
+}
+
+// encodeGIF encodes an animation for tests
+func encodeGIF(t *testing.T, anim *gif.GIF) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		t.Fatalf("EncodeAll() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+// TestDecodeGIF_BestFrame tests that a blank first frame is skipped
+func TestDecodeGIF_BestFrame(t *testing.T) {
+	rect := image.Rect(0, 0, 16, 16)
+	data := encodeGIF(t, &gif.GIF{
+		Image: []*image.Paletted{newGIFFrame(rect, 0), newGIFFrame(rect, 1), newGIFFrame(image.Rect(0, 0, 8, 8), 0)},
+		Delay: []int{10, 10, 10},
+	})
+
+	img, err := decodeGIF(data)
+	if err != nil {
+		t.Fatalf("decodeGIF() error = %v", err)
+	}
+	if r, _, _, a := img.At(12, 12).RGBA(); r != 0xFFFF || a != 0xFFFF {
+		t.Errorf("expected opaque red from the second frame, got r=%#x a=%#x", r, a)
+	}
+}
+
+// TestDecodeGIF_Disposal tests compositing of partial frames with disposal methods
+func TestDecodeGIF_Disposal(t *testing.T) {
+	rect := image.Rect(0, 0, 16, 16)
+	left := newGIFFrame(image.Rect(0, 0, 8, 16), 1)
+	right := newGIFFrame(image.Rect(8, 0, 16, 16), 2)
+
+	// Kept frames accumulate: the second composited frame is fully opaque
+	data := encodeGIF(t, &gif.GIF{
+		Image:    []*image.Paletted{left, right},
+		Delay:    []int{10, 10},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalNone},
+		Config:   image.Config{Width: 16, Height: 16, ColorModel: gifTestPalette},
+	})
+	img, err := decodeGIF(data)
+	if err != nil {
+		t.Fatalf("decodeGIF() error = %v", err)
+	}
+	if img.Bounds() != rect {
+		t.Errorf("bounds = %v, want %v", img.Bounds(), rect)
+	}
+	if _, _, _, a := img.At(2, 2).RGBA(); a != 0xFFFF {
+		t.Error("left half should be kept from the first frame")
+	}
+	if _, _, b, _ := img.At(12, 2).RGBA(); b != 0xFFFF {
+		t.Error("right half should come from the second frame")
+	}
+
+	// Background disposal clears the first frame: no frame is better than the first
+	data = encodeGIF(t, &gif.GIF{
+		Image:    []*image.Paletted{left, right},
+		Delay:    []int{10, 10},
+		Disposal: []byte{gif.DisposalBackground, gif.DisposalNone},
+		Config:   image.Config{Width: 16, Height: 16, ColorModel: gifTestPalette},
+	})
+	img, err = decodeGIF(data)
+	if err != nil {
+		t.Fatalf("decodeGIF() error = %v", err)
+	}
+	if _, _, _, a := img.At(12, 2).RGBA(); a != 0 {
+		t.Error("expected the earliest of equally opaque frames")
+	}
+}
+
+// Property test: GIF decoding returns the logical screen size
+// For any number of frames, decodeGIF returns an image of the animation size
+func TestProperty_GIFFrameSize(t *testing.T) {
+	f := func(frames []uint8) bool {
+		if len(frames) == 0 || len(frames) > 8 {
+			return true
+		}
+
+		rect := image.Rect(0, 0, 12, 10)
+		anim := &gif.GIF{Config: image.Config{Width: 12, Height: 10, ColorModel: gifTestPalette}}
+		for _, idx := range frames {
+			anim.Image = append(anim.Image, newGIFFrame(rect, idx%uint8(len(gifTestPalette))))
+			anim.Delay = append(anim.Delay, 1)
+		}
+
+		var buf bytes.Buffer
+		if err := gif.EncodeAll(&buf, anim); err != nil {
+			return false
+		}
+		img, err := decodeGIF(buf.Bytes())
+		return err == nil && img.Bounds() == rect
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 50}); err != nil {
+		t.Errorf("Property test failed: %v", err)
+	}
+}
+
+// TestDecodeGIF_Invalid tests error handling for corrupted GIF data
+func TestDecodeGIF_Invalid(t *testing.T) {
+	if _, err := decodeIconData([]byte("GIF89a\x10\x00")); err == nil {
+		t.Error("expected error for truncated GIF")
+	}
+}
@@ -0,0 +1,103 @@
+package fpkgen
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// maxHookScriptSize caps the size of a watchcow.hook.<name> file source
+const maxHookScriptSize = 64 * 1024
+
+// hookNames lists the fnOS lifecycle scripts in cmd/, empty unless a hook is configured
+var hookNames = []string{"install_init", "install_callback", "uninstall_init", "uninstall_callback",
+	"upgrade_init", "upgrade_callback", "config_init", "config_callback"}
+
+// HookData describes the lifecycle hook rendered by cmd_hook.tmpl
+type HookData struct {
+	Name    string // fnOS script name (e.g. uninstall_init)
+	Shebang bool   // The hook starts with #! and is executed directly instead of by bash
+}
+
+// parseHooks reads the lifecycle hook scripts of an app:
+//
+//	watchcow.hook.<name> -> script content, or a file:// source (e.g. file://./hooks/backup.sh)
+//
+// File sources are resolved and confined to roots like file:// icons.
+// Unknown hook names and unreadable files are logged and ignored.
+//
+// Docker merges the image labels into the container labels and hooks run as
+// root, so hooks inherited from imageLabels are ignored: only labels set on
+// the container itself (e.g. in the compose file) may add scripts.
+func parseHooks(labels, imageLabels map[string]string, basePath string, roots *fileRoots) map[string]string {
+	var hooks map[string]string
+	for key, value := range labels {
+		name, ok := strings.CutPrefix(key, "watchcow.hook.")
+		if !ok || strings.TrimSpace(value) == "" {
+			continue
+		}
+		if inherited, ok := imageLabels[key]; ok && inherited == value {
+			slog.Warn("Ignoring lifecycle hook set by the image, hooks must be set on the container", "label", key)
+			continue
+		}
+		if !slices.Contains(hookNames, name) {
+			slog.Warn("Ignoring unknown lifecycle hook", "label", key,
+				"error", fmt.Sprintf("expected one of %s", strings.Join(hookNames, ", ")))
+			continue
+		}
+
+		script := value
+		if strings.HasPrefix(value, "file://") {
+			data, err := readFileSource(value, basePath, roots, maxHookScriptSize)
+			if err != nil {
+				slog.Warn("Failed to load lifecycle hook", "label", key, "source", value, "error", err)
+				continue
+			}
+			script = string(data)
+		}
+
+		if hooks == nil {
+			hooks = make(map[string]string)
+		}
+		hooks[name] = script
+	}
+	return hooks
+}
+
+// writeHooks writes the lifecycle scripts of a package: a cmd_hook.tmpl wrapper
+// running cmd/hooks/<name> for configured hooks, an empty script otherwise.
+// uninstall_callback and config_callback (with a config wizard) handle their
+// wizards and run the hook themselves.
+func writeHooks(engine *TemplateEngine, appDir string, data *TemplateData, hooks map[string]string) error {
+	for _, name := range hookNames {
+		hookData := *data
+		hookData.Hook = nil
+		tmplName := "cmd_empty.tmpl"
+
+		if script, ok := hooks[name]; ok {
+			hookDir := filepath.Join(appDir, "cmd", "hooks")
+			if err := os.MkdirAll(hookDir, 0755); err != nil {
+				return fmt.Errorf("failed to create directory %s: %w", hookDir, err)
+			}
+			if err := os.WriteFile(filepath.Join(hookDir, name), []byte(script), 0755); err != nil {
+				return fmt.Errorf("failed to write hook %s: %w", name, err)
+			}
+			hookData.Hook = &HookData{Name: name, Shebang: strings.HasPrefix(script, "#!")}
+			tmplName = "cmd_hook.tmpl"
+		}
+		switch {
+		case name == "uninstall_callback":
+			tmplName = "cmd_uninstall_callback.tmpl"
+		case name == "config_callback" && len(data.ConfigEnv) > 0:
+			tmplName = "cmd_config_callback.tmpl"
+		}
+
+		if err := engine.RenderToFile(tmplName, filepath.Join(appDir, "cmd", name), &hookData, 0755); err != nil {
+			return fmt.Errorf("failed to generate cmd/%s: %w", name, err)
+		}
+	}
+	return nil
+}
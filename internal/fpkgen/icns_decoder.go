@@ -0,0 +1,270 @@
+package fpkgen
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// icnsEntry represents an icon element in an ICNS file
+type icnsEntry struct {
+	Type   string // OSType (e.g. "ic08", "il32")
+	Data   []byte // Element data without the 8 byte element header
+	Width  int
+	Height int
+}
+
+// resolution returns the total pixel count (width * height) for comparison
+func (e *icnsEntry) resolution() int {
+	return e.Width * e.Height
+}
+
+// icnsRLESizes maps legacy RLE-compressed RGB icon types to their edge length
+var icnsRLESizes = map[string]int{
+	"is32": 16,
+	"il32": 32,
+	"ih32": 48,
+	"it32": 128,
+}
+
+// icnsMaskTypes maps legacy RGB icon types to their 8-bit alpha mask type
+var icnsMaskTypes = map[string]string{
+	"is32": "s8mk",
+	"il32": "l8mk",
+	"ih32": "h8mk",
+	"it32": "t8mk",
+}
+
+// icnsARGBSizes maps ARGB icon types (RLE-compressed, "ARGB" prefixed) to their edge length
+var icnsARGBSizes = map[string]int{
+	"ic04": 16,
+	"ic05": 32,
+}
+
+// magicARGB prefixes ARGB icon data
+var magicARGB = []byte("ARGB")
+
+// decodeICNS decodes an Apple ICNS file and returns the highest resolution image.
+// PNG-encoded elements (ic07-ic14, icp4-icp6) and legacy RLE elements (is32, il32,
+// ih32, it32 with masks, ic04/ic05 ARGB) are supported; JPEG 2000 elements are skipped.
+func decodeICNS(data []byte) (image.Image, error) {
+	entries, elements, err := parseICNSElements(data)
+	if err != nil {
+		return nil, err
+	}
+	return decodeICNSImage(largestICNSEntry(entries), elements)
+}
+
+// decodeICNSSizes decodes an ICNS file like decodeICNS, keeping the other
+// sub-images for smaller icon sizes (see multiSizeIcon)
+func decodeICNSSizes(data []byte) (image.Image, error) {
+	entries, elements, err := parseICNSElements(data)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := decodeICNSImage(largestICNSEntry(entries), elements)
+	if err != nil {
+		return nil, err
+	}
+
+	variants := make([]iconVariant, 0, len(entries))
+	for _, entry := range entries {
+		variants = append(variants, iconVariant{
+			size:   max(entry.Width, entry.Height),
+			decode: func() (image.Image, error) { return decodeICNSImage(entry, elements) },
+		})
+	}
+	return newMultiSizeIcon(img, variants), nil
+}
+
+// parseICNSElements parses the elements of an ICNS file and returns the
+// decodable icon entries and all elements by type (for looking up masks)
+func parseICNSElements(data []byte) ([]*icnsEntry, map[string][]byte, error) {
+	if len(data) < 8 {
+		return nil, nil, fmt.Errorf("invalid ICNS file: too short for header")
+	}
+	if !bytes.HasPrefix(data, magicICNS) {
+		return nil, nil, fmt.Errorf("invalid ICNS file: bad magic")
+	}
+
+	// The header length may be larger than the actual data for truncated downloads
+	total := int(binary.BigEndian.Uint32(data[4:8]))
+	if total < 8 {
+		return nil, nil, fmt.Errorf("invalid ICNS file: bad length %d", total)
+	}
+	if total > len(data) {
+		total = len(data)
+	}
+
+	elements := make(map[string][]byte)
+	var entries []*icnsEntry
+
+	for offset := 8; offset+8 <= total; {
+		elemType := string(data[offset : offset+4])
+		elemLen := int(binary.BigEndian.Uint32(data[offset+4 : offset+8]))
+		if elemLen < 8 || offset+elemLen > total {
+			break // Stop at the first invalid element
+		}
+		elemData := data[offset+8 : offset+elemLen]
+		offset += elemLen
+
+		elements[elemType] = elemData
+
+		if entry := parseICNSEntry(elemType, elemData); entry != nil {
+			entries = append(entries, entry)
+		}
+	}
+
+	if len(entries) == 0 {
+		return nil, nil, fmt.Errorf("invalid ICNS file: no supported image elements found")
+	}
+	return entries, elements, nil
+}
+
+// largestICNSEntry returns the first entry with the highest resolution
+func largestICNSEntry(entries []*icnsEntry) *icnsEntry {
+	best := entries[0]
+	for _, entry := range entries[1:] {
+		if entry.resolution() > best.resolution() {
+			best = entry
+		}
+	}
+	return best
+}
+
+// parseICNSEntry identifies a decodable icon element and its dimensions
+// Returns nil for metadata, masks, JPEG 2000 data and unknown elements
+func parseICNSEntry(elemType string, data []byte) *icnsEntry {
+	// PNG data can appear in any of the modern icon types; read the real size from the header
+	if bytes.HasPrefix(data, magicPNG) {
+		cfg, err := png.DecodeConfig(bytes.NewReader(data))
+		if err != nil || checkIconSize(cfg.Width, cfg.Height) != nil {
+			return nil
+		}
+		return &icnsEntry{Type: elemType, Data: data, Width: cfg.Width, Height: cfg.Height}
+	}
+
+	if size, ok := icnsARGBSizes[elemType]; ok && bytes.HasPrefix(data, magicARGB) {
+		return &icnsEntry{Type: elemType, Data: data, Width: size, Height: size}
+	}
+
+	if size, ok := icnsRLESizes[elemType]; ok {
+		return &icnsEntry{Type: elemType, Data: data, Width: size, Height: size}
+	}
+
+	return nil
+}
+
+// decodeICNSImage decodes a single ICNS icon element.
+// elements provides the other elements of the file for looking up alpha masks.
+func decodeICNSImage(entry *icnsEntry, elements map[string][]byte) (image.Image, error) {
+	if bytes.HasPrefix(entry.Data, magicPNG) {
+		img, err := png.Decode(bytes.NewReader(entry.Data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode PNG in ICNS: %w", err)
+		}
+		return img, nil
+	}
+
+	pixels := entry.Width * entry.Height
+
+	// ARGB: "ARGB" followed by RLE-compressed A, R, G, B channels
+	if _, ok := icnsARGBSizes[entry.Type]; ok {
+		channels, err := decodeICNSRLE(entry.Data[len(magicARGB):], pixels, 4)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ICNS %s: %w", entry.Type, err)
+		}
+		return icnsChannelsToImage(entry.Width, entry.Height, channels[pixels:], channels[:pixels]), nil
+	}
+
+	// Legacy RGB: RLE-compressed R, G, B channels (it32 has 4 extra leading bytes)
+	data := entry.Data
+	if entry.Type == "it32" && len(data) >= 4 {
+		data = data[4:]
+	}
+
+	var channels []byte
+	if len(data) == pixels*4 {
+		// Uncompressed 32-bit ARGB pixels (seen in some old small icons)
+		channels = make([]byte, pixels*3)
+		for i := 0; i < pixels; i++ {
+			channels[i] = data[i*4+1]
+			channels[pixels+i] = data[i*4+2]
+			channels[2*pixels+i] = data[i*4+3]
+		}
+	} else {
+		var err error
+		channels, err = decodeICNSRLE(data, pixels, 3)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ICNS %s: %w", entry.Type, err)
+		}
+	}
+
+	// Use the matching 8-bit mask if present, otherwise the icon is opaque
+	var alpha []byte
+	if mask, ok := elements[icnsMaskTypes[entry.Type]]; ok && len(mask) >= pixels {
+		alpha = mask[:pixels]
+	}
+
+	return icnsChannelsToImage(entry.Width, entry.Height, channels, alpha), nil
+}
+
+// decodeICNSRLE decodes ICNS PackBits-style run-length encoded planar channel data.
+// A control byte below 0x80 is followed by (n+1) literal bytes; otherwise the next
+// byte is repeated (n-0x80+3) times. Returns channels*pixels bytes, channel by channel.
+func decodeICNSRLE(data []byte, pixels, channels int) ([]byte, error) {
+	out := make([]byte, 0, pixels*channels)
+	want := pixels * channels
+
+	for i := 0; i < len(data) && len(out) < want; {
+		n := int(data[i])
+		i++
+
+		if n < 0x80 {
+			count := n + 1
+			if i+count > len(data) {
+				return nil, fmt.Errorf("truncated RLE literal run")
+			}
+			out = append(out, data[i:i+count]...)
+			i += count
+		} else {
+			count := n - 0x80 + 3
+			if i >= len(data) {
+				return nil, fmt.Errorf("truncated RLE repeat run")
+			}
+			for j := 0; j < count; j++ {
+				out = append(out, data[i])
+			}
+			i++
+		}
+	}
+
+	if len(out) < want {
+		return nil, fmt.Errorf("RLE data too short: got %d bytes, want %d", len(out), want)
+	}
+	return out[:want], nil
+}
+
+// icnsChannelsToImage builds an image from planar R, G, B channels and an optional alpha channel
+func icnsChannelsToImage(width, height int, rgb, alpha []byte) *image.NRGBA {
+	pixels := width * height
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+
+	for i := 0; i < pixels; i++ {
+		a := uint8(255)
+		if alpha != nil {
+			a = alpha[i]
+		}
+		img.SetNRGBA(i%width, i/width, color.NRGBA{
+			R: rgb[i],
+			G: rgb[pixels+i],
+			B: rgb[2*pixels+i],
+			A: a,
+		})
+	}
+	return img
+}
@@ -0,0 +1,245 @@
+package fpkgen
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+	"testing/quick"
+)
+
+// **Feature: icon-format-support, Property 7: ICNS Highest Resolution Selection**
+//
+// For any valid ICNS file containing multiple images of different resolutions,
+// the decodeICNS function SHALL return the image with the largest pixel dimensions (width × height).
+
+// icnsElement is a raw ICNS element used to build test files
+type icnsElement struct {
+	Type string
+	Data []byte
+}
+
+// buildICNS assembles an ICNS file from elements
+func buildICNS(elements []icnsElement) []byte {
+	var body bytes.Buffer
+	for _, elem := range elements {
+		body.WriteString(elem.Type)
+		binary.Write(&body, binary.BigEndian, uint32(8+len(elem.Data)))
+		body.Write(elem.Data)
+	}
+
+	var out bytes.Buffer
+	out.Write(magicICNS)
+	binary.Write(&out, binary.BigEndian, uint32(8+body.Len()))
+	out.Write(body.Bytes())
+	return out.Bytes()
+}
+
+// generateICNSWithPNGImages creates a valid ICNS file with multiple PNG images of different sizes
+func generateICNSWithPNGImages(sizes []int) ([]byte, int) {
+	if len(sizes) == 0 {
+		return nil, 0
+	}
+
+	types := []string{"icp4", "icp5", "icp6", "ic07", "ic08", "ic09", "ic10", "ic11", "ic12", "ic13", "ic14"}
+
+	maxSize := 0
+	var elements []icnsElement
+	for i, size := range sizes {
+		if size > maxSize {
+			maxSize = size
+		}
+
+		img := image.NewRGBA(image.Rect(0, 0, size, size))
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				img.SetRGBA(x, y, color.RGBA{R: uint8(size % 256), G: uint8((size / 2) % 256), A: 255})
+			}
+		}
+		var buf bytes.Buffer
+		png.Encode(&buf, img)
+
+		elements = append(elements, icnsElement{Type: types[i%len(types)], Data: buf.Bytes()})
+	}
+
+	return buildICNS(elements), maxSize
+}
+
+// TestProperty_ICNSHighestResolutionSelection tests that decodeICNS always returns the highest resolution image
+func TestProperty_ICNSHighestResolutionSelection(t *testing.T) {
+	f := func(sizesInput []uint8) bool {
+		var sizes []int
+		for _, s := range sizesInput {
+			if s > 0 && s <= 128 { // Limit to 128 to keep test fast
+				sizes = append(sizes, int(s))
+			}
+		}
+		if len(sizes) < 2 {
+			return true // Skip trivial cases
+		}
+		if len(sizes) > 5 {
+			sizes = sizes[:5]
+		}
+
+		icnsData, expectedMaxSize := generateICNSWithPNGImages(sizes)
+
+		img, err := decodeICNS(icnsData)
+		if err != nil {
+			t.Logf("decodeICNS failed: %v", err)
+			return false
+		}
+
+		bounds := img.Bounds()
+		if bounds.Dx() != expectedMaxSize || bounds.Dy() != expectedMaxSize {
+			t.Logf("Expected size %dx%d, got %dx%d (sizes: %v)",
+				expectedMaxSize, expectedMaxSize, bounds.Dx(), bounds.Dy(), sizes)
+			return false
+		}
+		return true
+	}
+
+	if err := quick.Check(f, &quick.Config{MaxCount: 100}); err != nil {
+		t.Errorf("Property test failed: %v", err)
+	}
+}
+
+// TestDecodeICNS_MultipleImages tests decoding ICNS with multiple images
+func TestDecodeICNS_MultipleImages(t *testing.T) {
+	icnsData, _ := generateICNSWithPNGImages([]int{16, 128, 32, 64})
+
+	img, err := decodeICNS(icnsData)
+	if err != nil {
+		t.Fatalf("decodeICNS failed: %v", err)
+	}
+
+	bounds := img.Bounds()
+	if bounds.Dx() != 128 || bounds.Dy() != 128 {
+		t.Errorf("Expected 128x128 (largest), got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+}
+
+// TestDecodeICNS_SkipsJPEG2000 tests that JPEG 2000 elements are ignored
+func TestDecodeICNS_SkipsJPEG2000(t *testing.T) {
+	pngICNS, _ := generateICNSWithPNGImages([]int{32})
+	jp2 := []byte{0x00, 0x00, 0x00, 0x0C, 0x6A, 0x50, 0x20, 0x20, 0x0D, 0x0A, 0x87, 0x0A}
+
+	// A large JPEG 2000 element followed by a small PNG element
+	data := buildICNS([]icnsElement{{Type: "ic10", Data: jp2}, {Type: "icp5", Data: pngICNS[16:]}})
+
+	img, err := decodeICNS(data)
+	if err != nil {
+		t.Fatalf("decodeICNS failed: %v", err)
+	}
+	if img.Bounds().Dx() != 32 {
+		t.Errorf("Expected the 32x32 PNG element, got %dx%d", img.Bounds().Dx(), img.Bounds().Dy())
+	}
+
+	// Only JPEG 2000 data: nothing decodable
+	if _, err := decodeICNS(buildICNS([]icnsElement{{Type: "ic10", Data: jp2}})); err == nil {
+		t.Error("expected error for ICNS with only JPEG 2000 elements")
+	}
+}
+
+// TestDecodeICNS_LegacyRLE tests decoding of RLE-compressed RGB icons with alpha mask
+func TestDecodeICNS_LegacyRLE(t *testing.T) {
+	pixels := 16 * 16
+
+	// R: repeat run of 0xFF, G: literal runs of 0x00, B: repeat run of 0x80
+	var rle []byte
+	for n := pixels; n > 0; n -= 130 {
+		count := min(n, 130)
+		rle = append(rle, byte(count-3+0x80), 0xFF)
+	}
+	for n := pixels; n > 0; n -= 128 {
+		count := min(n, 128)
+		rle = append(rle, byte(count-1))
+		rle = append(rle, make([]byte, count)...)
+	}
+	for n := pixels; n > 0; n -= 130 {
+		count := min(n, 130)
+		rle = append(rle, byte(count-3+0x80), 0x80)
+	}
+
+	mask := bytes.Repeat([]byte{0x40}, pixels)
+	data := buildICNS([]icnsElement{{Type: "is32", Data: rle}, {Type: "s8mk", Data: mask}})
+
+	img, err := decodeICNS(data)
+	if err != nil {
+		t.Fatalf("decodeICNS failed: %v", err)
+	}
+	if img.Bounds().Dx() != 16 || img.Bounds().Dy() != 16 {
+		t.Fatalf("Expected 16x16, got %v", img.Bounds())
+	}
+
+	got := color.NRGBAModel.Convert(img.At(5, 7)).(color.NRGBA)
+	want := color.NRGBA{R: 0xFF, G: 0x00, B: 0x80, A: 0x40}
+	if got != want {
+		t.Errorf("pixel = %+v, want %+v", got, want)
+	}
+}
+
+// TestDecodeICNS_ARGB tests decoding of ic04/ic05 ARGB icons
+func TestDecodeICNS_ARGB(t *testing.T) {
+	pixels := 16 * 16
+	argb := append([]byte(nil), magicARGB...)
+	for _, v := range []byte{0xFF, 0x10, 0x20, 0x30} {
+		for n := pixels; n > 0; n -= 130 {
+			count := min(n, 130)
+			argb = append(argb, byte(count-3+0x80), v)
+		}
+	}
+
+	img, err := decodeICNS(buildICNS([]icnsElement{{Type: "ic04", Data: argb}}))
+	if err != nil {
+		t.Fatalf("decodeICNS failed: %v", err)
+	}
+
+	got := color.NRGBAModel.Convert(img.At(15, 15)).(color.NRGBA)
+	if want := (color.NRGBA{R: 0x10, G: 0x20, B: 0x30, A: 0xFF}); got != want {
+		t.Errorf("pixel = %+v, want %+v", got, want)
+	}
+}
+
+// TestDecodeICNS_InvalidHeader tests error handling for invalid ICNS data
+func TestDecodeICNS_InvalidHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"too short", []byte("icns")},
+		{"bad magic", []byte("icnx\x00\x00\x00\x08")},
+		{"bad length", []byte("icns\x00\x00\x00\x04")},
+		{"no elements", []byte("icns\x00\x00\x00\x08")},
+		{"truncated element", []byte("icns\x00\x00\x00\x20ic07\x00\x00\x01\x00\x89PNG")},
+		{"truncated RLE", buildICNS([]icnsElement{{Type: "is32", Data: []byte{0x05, 0x01}}})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeICNS(tt.data); err == nil {
+				t.Error("expected error for invalid ICNS data")
+			}
+		})
+	}
+}
+
+// TestDecodeICNSRLE tests the run-length decoder
+func TestDecodeICNSRLE(t *testing.T) {
+	// Literal run of 2 bytes, repeat run of 3 bytes
+	got, err := decodeICNSRLE([]byte{0x01, 0x0A, 0x0B, 0x80, 0x0C}, 5, 1)
+	if err != nil {
+		t.Fatalf("decodeICNSRLE() error = %v", err)
+	}
+	if want := []byte{0x0A, 0x0B, 0x0C, 0x0C, 0x0C}; !bytes.Equal(got, want) {
+		t.Errorf("decodeICNSRLE() = %v, want %v", got, want)
+	}
+
+	if _, err := decodeICNSRLE([]byte{0x05, 0x01}, 6, 1); err == nil {
+		t.Error("expected error for truncated literal run")
+	}
+	if _, err := decodeICNSRLE([]byte{0x80}, 3, 1); err == nil {
+		t.Error("expected error for truncated repeat run")
+	}
+}
@@ -52,6 +52,44 @@ func (e *icoEntry) resolution() int {
 // decodeICO decodes an ICO file and returns the highest resolution image.
 // It supports both PNG and BMP encoded images within the ICO container.
 func decodeICO(data []byte) (image.Image, error) {
+	entries, err := parseICODirectory(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Extract and decode the best image
+	best := largestICOEntry(entries)
+	return decodeICOImage(data[best.Offset:best.Offset+best.Size], best)
+}
+
+// decodeICOSizes decodes an ICO file like decodeICO, keeping the other
+// sub-images for smaller icon sizes (see multiSizeIcon)
+func decodeICOSizes(data []byte) (image.Image, error) {
+	entries, err := parseICODirectory(data)
+	if err != nil {
+		return nil, err
+	}
+
+	best := largestICOEntry(entries)
+	img, err := decodeICOImage(data[best.Offset:best.Offset+best.Size], best)
+	if err != nil {
+		return nil, err
+	}
+
+	variants := make([]iconVariant, 0, len(entries))
+	for _, entry := range entries {
+		variants = append(variants, iconVariant{
+			size: max(entry.getActualWidth(), entry.getActualHeight()),
+			decode: func() (image.Image, error) {
+				return decodeICOImage(data[entry.Offset:entry.Offset+entry.Size], entry)
+			},
+		})
+	}
+	return newMultiSizeIcon(img, variants), nil
+}
+
+// parseICODirectory parses the ICO header and returns the valid directory entries
+func parseICODirectory(data []byte) ([]*icoEntry, error) {
 	if len(data) < 6 {
 		return nil, fmt.Errorf("invalid ICO file: too short for header")
 	}
@@ -80,10 +118,8 @@ func decodeICO(data []byte) (image.Image, error) {
 		return nil, fmt.Errorf("invalid ICO file: too short for directory entries")
 	}
 
-	// Parse directory entries and find the highest resolution image
-	var bestEntry *icoEntry
-	var bestResolution int
-
+	// Parse directory entries
+	var entries []*icoEntry
 	for i := uint16(0); i < header.Count; i++ {
 		offset := 6 + int(i)*16
 		entry := parseICOEntry(data[offset : offset+16])
@@ -95,21 +131,24 @@ func decodeICO(data []byte) (image.Image, error) {
 		if int(entry.Offset)+int(entry.Size) > len(data) {
 			continue // Skip invalid entries
 		}
-
-		resolution := entry.resolution()
-		if bestEntry == nil || resolution > bestResolution {
-			bestEntry = entry
-			bestResolution = resolution
-		}
+		entries = append(entries, entry)
 	}
 
-	if bestEntry == nil {
+	if len(entries) == 0 {
 		return nil, fmt.Errorf("invalid ICO file: no valid image entries found")
 	}
+	return entries, nil
+}
 
-	// Extract and decode the best image
-	imageData := data[bestEntry.Offset : bestEntry.Offset+bestEntry.Size]
-	return decodeICOImage(imageData, bestEntry)
+// largestICOEntry returns the first entry with the highest resolution
+func largestICOEntry(entries []*icoEntry) *icoEntry {
+	best := entries[0]
+	for _, entry := range entries[1:] {
+		if entry.resolution() > best.resolution() {
+			best = entry
+		}
+	}
+	return best
 }
 
 // parseICOEntry parses a 16-byte ICO directory entry
@@ -136,7 +175,7 @@ func decodeICOImage(data []byte, entry *icoEntry) (image.Image, error) {
 
 	// Check if it's a PNG (starts with PNG signature)
 	if bytes.HasPrefix(data, magicPNG) {
-		img, _, err := image.Decode(bytes.NewReader(data))
+		img, err := decodeImageLimited(data)
 		if err != nil {
 			return nil, fmt.Errorf("failed to decode PNG in ICO: %w", err)
 		}
@@ -157,7 +196,7 @@ func decodeICOBMP(data []byte, entry *icoEntry) (image.Image, error) {
 
 	// Parse BITMAPINFOHEADER
 	headerSize := binary.LittleEndian.Uint32(data[0:4])
-	if headerSize < 40 {
+	if headerSize < 40 || int64(headerSize) > int64(len(data)) {
 		return nil, fmt.Errorf("invalid ICO BMP: unsupported header size %d", headerSize)
 	}
 
@@ -178,6 +217,11 @@ func decodeICOBMP(data []byte, entry *icoEntry) (image.Image, error) {
 		height = entry.getActualHeight()
 	}
 
+	// Reject negative (top-down) and oversized dimensions before allocating the image
+	if width <= 0 || height <= 0 || width > maxICODimension || height > maxICODimension {
+		return nil, fmt.Errorf("invalid ICO BMP: unsupported dimensions %dx%d", width, height)
+	}
+
 	// Only support uncompressed BMPs
 	if compression != 0 {
 		return nil, fmt.Errorf("invalid ICO BMP: compressed BMP not supported (compression=%d)", compression)
@@ -0,0 +1,446 @@
+package fpkgen
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Icon cache defaults
+const (
+	DefaultIconCacheMaxAge  = 7 * 24 * time.Hour
+	DefaultIconCacheMaxSize = 64 << 20
+
+	// tempFileGrace is how long temp files are kept: another process sharing the
+	// cache (e.g. the daemon while `watchcow icons prune` runs) may still write them
+	tempFileGrace = time.Hour
+)
+
+// IconCacheOptions configures an IconCache
+type IconCacheOptions struct {
+	MaxAge  time.Duration // Entries younger than this are served without revalidation (0 = always revalidate)
+	MaxSize int64         // Total size cap of cached icon data in bytes (0 = unlimited)
+	Offline bool          // Never access the network, serve cached (even stale) entries only
+}
+
+// IconCache is a persistent, content-addressed cache of downloaded icons.
+// Icon data is stored once per content hash in blobs/, and an index entry per
+// source URL in index/ records the blob and the HTTP validators (ETag/Last-Modified)
+// used for conditional revalidation once an entry is older than MaxAge.
+type IconCache struct {
+	dir  string
+	opts IconCacheOptions
+	mu   sync.Mutex       // Serializes index and blob updates
+	now  func() time.Time // Clock (replaced in tests)
+}
+
+// iconCacheEntry is the index record of a cached icon URL
+type iconCacheEntry struct {
+	URL          string    `json:"url"`
+	Blob         string    `json:"blob"` // SHA-256 of the icon data
+	Size         int64     `json:"size"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"` // Last download or successful revalidation
+	UsedAt       time.Time `json:"used_at"`    // Last time the entry was served
+}
+
+// IconCachePruneStats reports what IconCache.Prune removed
+type IconCachePruneStats struct {
+	Entries      int   // Removed index entries
+	Blobs        int   // Removed icon data files
+	BytesFreed   int64 // Size of removed icon data
+	BytesInCache int64 // Size of icon data left in the cache
+}
+
+// DefaultIconCacheDir returns the default icon cache directory:
+// $TRIM_PKGVAR/icon-cache when running as fnOS app, the user cache directory otherwise.
+// Returns empty string if no suitable directory exists.
+func DefaultIconCacheDir() string {
+	if pkgVar := os.Getenv("TRIM_PKGVAR"); pkgVar != "" {
+		return filepath.Join(pkgVar, "icon-cache")
+	}
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(cacheDir, "watchcow", "icons")
+	}
+	return ""
+}
+
+// NewIconCache opens (creating if needed) an icon cache in dir
+func NewIconCache(dir string, opts IconCacheOptions) (*IconCache, error) {
+	for _, sub := range []string{"index", "blobs"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create icon cache directory: %w", err)
+		}
+	}
+	return &IconCache{dir: dir, opts: opts, now: time.Now}, nil
+}
+
+// Fetch returns the icon data for a URL, from the cache if fresh.
+// Stale entries are revalidated with If-None-Match/If-Modified-Since. If the
+// network or server fails, or in offline mode, stale entries are served as-is.
+func (c *IconCache) Fetch(ctx context.Context, url string) ([]byte, error) {
+	return c.fetch(ctx, url, false)
+}
+
+// fetch implements Fetch; revalidate treats fresh entries as stale
+func (c *IconCache) fetch(ctx context.Context, url string, revalidate bool) ([]byte, error) {
+	entry, data := c.lookup(url)
+
+	if entry != nil && (c.opts.Offline || (!revalidate && c.now().Sub(entry.FetchedAt) < c.opts.MaxAge)) {
+		c.touch(entry, false)
+		return data, nil
+	}
+	if c.opts.Offline {
+		return nil, fmt.Errorf("icon not cached (offline mode): %s", url)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := iconClient.Do(req)
+	if err != nil {
+		if entry != nil {
+			slog.Warn("Icon revalidation failed, using cached icon", "url", url, "error", err)
+			c.touch(entry, false)
+			return data, nil
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && entry != nil:
+		c.touch(entry, true)
+		return data, nil
+
+	case resp.StatusCode == http.StatusOK:
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxIconFileSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(body) > maxIconFileSize {
+			return nil, fmt.Errorf("icon too large (> %d bytes)", maxIconFileSize)
+		}
+		// Error and login pages served with 200 must not replace or poison the cache
+		if detectFormat(body) == FormatUnknown {
+			if entry != nil {
+				slog.Warn("Icon revalidation returned no image, using cached icon", "url", url)
+				c.touch(entry, false)
+				return data, nil
+			}
+			return nil, fmt.Errorf("not an image: %s", url)
+		}
+		if err := c.store(url, body, resp.Header); err != nil {
+			// The icon is still usable without the cache
+			slog.Warn("Failed to cache icon", "url", url, "error", err)
+		}
+		return body, nil
+
+	case resp.StatusCode >= 500 && entry != nil:
+		slog.Warn("Icon revalidation failed, using cached icon", "url", url, "status", resp.StatusCode)
+		c.touch(entry, false)
+		return data, nil
+	}
+
+	return nil, &httpStatusError{Code: resp.StatusCode}
+}
+
+// Prune removes entries not used within maxUnused (0 keeps all), index entries
+// whose data is missing, unreferenced data and temporary files, then enforces MaxSize
+func (c *IconCache) Prune(maxUnused time.Duration) (IconCachePruneStats, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var stats IconCachePruneStats
+
+	entries, err := c.readIndex()
+	if err != nil {
+		return stats, err
+	}
+
+	var kept []*iconCacheEntry
+	for _, entry := range entries {
+		_, statErr := os.Stat(c.blobPath(entry.Blob))
+		if statErr != nil || (maxUnused > 0 && c.now().Sub(entry.UsedAt) > maxUnused) {
+			os.Remove(c.indexPath(entry.URL))
+			stats.Entries++
+			continue
+		}
+		kept = append(kept, entry)
+	}
+
+	removed, err := c.enforceSize(kept)
+	stats.Entries += removed
+	if err != nil {
+		return stats, err
+	}
+
+	blobs, freed, remaining, err := c.removeOrphanBlobs()
+	stats.Blobs, stats.BytesFreed, stats.BytesInCache = blobs, freed, remaining
+	return stats, err
+}
+
+// peek returns the cached data for a URL regardless of its age, without network access
+func (c *IconCache) peek(url string) ([]byte, bool) {
+	entry, data := c.lookup(url)
+	return data, entry != nil
+}
+
+// lookup returns the index entry and data for a URL, or nil if not cached
+func (c *IconCache) lookup(url string) (*iconCacheEntry, []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	raw, err := os.ReadFile(c.indexPath(url))
+	if err != nil {
+		return nil, nil
+	}
+	var entry iconCacheEntry
+	if err := json.Unmarshal(raw, &entry); err != nil || entry.URL != url {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(c.blobPath(entry.Blob))
+	if err != nil || blobHash(data) != entry.Blob {
+		return nil, nil // Missing or corrupted data: treat as not cached
+	}
+	return &entry, data
+}
+
+// touch records that an entry was served (and revalidated, if revalidated is set)
+func (c *IconCache) touch(entry *iconCacheEntry, revalidated bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry.UsedAt = c.now()
+	if revalidated {
+		entry.FetchedAt = entry.UsedAt
+	}
+	if err := c.writeEntry(entry); err != nil {
+		slog.Debug("Failed to update icon cache entry", "url", entry.URL, "error", err)
+	}
+}
+
+// store saves downloaded icon data and its validators, then enforces MaxSize
+func (c *IconCache) store(url string, data []byte, header http.Header) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	hash := blobHash(data)
+	if _, err := os.Stat(c.blobPath(hash)); err != nil {
+		if err := writeFileAtomic(c.blobPath(hash), data); err != nil {
+			return err
+		}
+	}
+
+	now := c.now()
+	entry := &iconCacheEntry{
+		URL:          url,
+		Blob:         hash,
+		Size:         int64(len(data)),
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+		FetchedAt:    now,
+		UsedAt:       now,
+	}
+	if err := c.writeEntry(entry); err != nil {
+		return err
+	}
+
+	if c.opts.MaxSize > 0 {
+		entries, err := c.readIndex()
+		if err != nil {
+			return err
+		}
+		if _, err := c.enforceSize(entries); err != nil {
+			return err
+		}
+		_, _, _, err = c.removeOrphanBlobs()
+		return err
+	}
+	return nil
+}
+
+// enforceSize removes the least recently used entries until the referenced data fits MaxSize
+// Returns the number of removed entries. Must be called with c.mu held.
+func (c *IconCache) enforceSize(entries []*iconCacheEntry) (int, error) {
+	if c.opts.MaxSize <= 0 {
+		return 0, nil
+	}
+
+	// Blobs are shared, so count each referenced blob once
+	refs := make(map[string]int)
+	var total int64
+	for _, entry := range entries {
+		if refs[entry.Blob] == 0 {
+			total += entry.Size
+		}
+		refs[entry.Blob]++
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].UsedAt.Before(entries[j].UsedAt)
+	})
+
+	removed := 0
+	for _, entry := range entries {
+		if total <= c.opts.MaxSize {
+			break
+		}
+		if err := os.Remove(c.indexPath(entry.URL)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, err
+		}
+		removed++
+		refs[entry.Blob]--
+		if refs[entry.Blob] == 0 {
+			total -= entry.Size
+		}
+	}
+	return removed, nil
+}
+
+// removeOrphanBlobs deletes data files no index entry refers to and leftover temp files
+// older than tempFileGrace.
+// Returns the number and size of removed files and the size of the remaining data.
+// Must be called with c.mu held.
+func (c *IconCache) removeOrphanBlobs() (removed int, freed, remaining int64, err error) {
+	entries, err := c.readIndex()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	referenced := make(map[string]bool)
+	for _, entry := range entries {
+		referenced[entry.Blob] = true
+	}
+
+	files, err := os.ReadDir(filepath.Join(c.dir, "blobs"))
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	for _, f := range files {
+		info, err := f.Info()
+		if err != nil {
+			continue
+		}
+		if referenced[f.Name()] {
+			remaining += info.Size()
+			continue
+		}
+		if c.activeTemp(f) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(c.dir, "blobs", f.Name())); err == nil {
+			removed++
+			freed += info.Size()
+		}
+	}
+	return removed, freed, remaining, nil
+}
+
+// readIndex reads all valid index entries, removing unreadable ones and leftover
+// temp files older than tempFileGrace
+// Must be called with c.mu held.
+func (c *IconCache) readIndex() ([]*iconCacheEntry, error) {
+	indexDir := filepath.Join(c.dir, "index")
+	files, err := os.ReadDir(indexDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*iconCacheEntry
+	for _, f := range files {
+		path := filepath.Join(indexDir, f.Name())
+		if !strings.HasSuffix(f.Name(), ".json") {
+			if !c.activeTemp(f) {
+				os.Remove(path) // Leftover temp file
+			}
+			continue
+		}
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var entry iconCacheEntry
+		if err := json.Unmarshal(raw, &entry); err != nil || entry.URL == "" {
+			os.Remove(path)
+			continue
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, nil
+}
+
+// activeTemp reports whether a file is a temp file of writeFileAtomic that may still be written
+func (c *IconCache) activeTemp(f os.DirEntry) bool {
+	if !strings.HasPrefix(f.Name(), ".tmp-") {
+		return false
+	}
+	info, err := f.Info()
+	return err == nil && c.now().Sub(info.ModTime()) < tempFileGrace
+}
+
+// writeEntry saves an index entry. Must be called with c.mu held.
+func (c *IconCache) writeEntry(entry *iconCacheEntry) error {
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.indexPath(entry.URL), raw)
+}
+
+// indexPath returns the index file of a URL
+func (c *IconCache) indexPath(url string) string {
+	return filepath.Join(c.dir, "index", blobHash([]byte(url))+".json")
+}
+
+// blobPath returns the data file of a content hash
+func (c *IconCache) blobPath(hash string) string {
+	return filepath.Join(c.dir, "blobs", hash)
+}
+
+// blobHash returns the hex SHA-256 of data
+func blobHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// writeFileAtomic writes data to a temp file and renames it into place
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,326 @@
+package fpkgen
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// cacheTestServer serves icon data with an ETag and counts requests
+type cacheTestServer struct {
+	*httptest.Server
+	data         atomic.Value // []byte
+	etag         atomic.Value // string
+	status       atomic.Int32 // Forced status (0 = normal)
+	requests     atomic.Int32
+	conditionals atomic.Int32
+}
+
+func newCacheTestServer(t *testing.T, data []byte) *cacheTestServer {
+	t.Helper()
+	s := &cacheTestServer{}
+	s.data.Store(data)
+	s.etag.Store(`"v1"`)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		if status := s.status.Load(); status != 0 {
+			w.WriteHeader(int(status))
+			return
+		}
+		etag := s.etag.Load().(string)
+		if r.Header.Get("If-None-Match") != "" {
+			s.conditionals.Add(1)
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		w.Header().Set("ETag", etag)
+		w.Write(s.data.Load().([]byte))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// newTestIconCache creates a cache in a temp dir with a controllable clock
+func newTestIconCache(t *testing.T, opts IconCacheOptions) (*IconCache, *time.Time) {
+	t.Helper()
+	cache, err := NewIconCache(t.TempDir(), opts)
+	if err != nil {
+		t.Fatalf("NewIconCache() error = %v", err)
+	}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+	return cache, &now
+}
+
+// TestIconCache_FreshAndRevalidate tests max-age and ETag revalidation
+func TestIconCache_FreshAndRevalidate(t *testing.T) {
+	icon := pngBytes(t, 16)
+	srv := newCacheTestServer(t, icon)
+	cache, now := newTestIconCache(t, IconCacheOptions{MaxAge: time.Hour})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		data, err := cache.Fetch(ctx, srv.URL+"/icon.png")
+		if err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+		if !bytes.Equal(data, icon) {
+			t.Fatal("Fetch() returned wrong data")
+		}
+	}
+	if got := srv.requests.Load(); got != 1 {
+		t.Errorf("fresh entries should not hit the network, got %d requests", got)
+	}
+
+	// Stale: revalidated with If-None-Match, 304 keeps the data
+	*now = now.Add(2 * time.Hour)
+	if _, err := cache.Fetch(ctx, srv.URL+"/icon.png"); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if srv.conditionals.Load() != 1 {
+		t.Errorf("expected a conditional request, got %d", srv.conditionals.Load())
+	}
+
+	// Revalidation refreshed the entry
+	srv.requests.Store(0)
+	*now = now.Add(30 * time.Minute)
+	cache.Fetch(ctx, srv.URL+"/icon.png")
+	if srv.requests.Load() != 0 {
+		t.Error("revalidated entry should be fresh again")
+	}
+
+	// Changed icon: new data replaces the cached one
+	newIcon := pngBytes(t, 32)
+	srv.data.Store(newIcon)
+	srv.etag.Store(`"v2"`)
+	*now = now.Add(2 * time.Hour)
+	data, err := cache.Fetch(ctx, srv.URL+"/icon.png")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if !bytes.Equal(data, newIcon) {
+		t.Error("changed icon not downloaded")
+	}
+}
+
+// TestIconCache_StaleOnFailure tests serving stale entries when the server fails
+func TestIconCache_StaleOnFailure(t *testing.T) {
+	icon := pngBytes(t, 16)
+	srv := newCacheTestServer(t, icon)
+	cache, now := newTestIconCache(t, IconCacheOptions{MaxAge: time.Minute})
+	ctx := context.Background()
+	url := srv.URL + "/icon.png"
+
+	if _, err := cache.Fetch(ctx, url); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	*now = now.Add(time.Hour)
+
+	srv.status.Store(http.StatusBadGateway)
+	if data, err := cache.Fetch(ctx, url); err != nil || !bytes.Equal(data, icon) {
+		t.Errorf("expected stale icon on server error, got err=%v", err)
+	}
+
+	srv.status.Store(http.StatusNotFound)
+	if _, err := cache.Fetch(ctx, url); err == nil {
+		t.Error("expected error when the icon is gone")
+	}
+
+	srv.status.Store(0)
+	srv.Close()
+	if data, err := cache.Fetch(ctx, url); err != nil || !bytes.Equal(data, icon) {
+		t.Errorf("expected stale icon when the server is down, got err=%v", err)
+	}
+}
+
+// TestIconCache_NotAnImage tests that pages served with 200 instead of an icon are not cached
+func TestIconCache_NotAnImage(t *testing.T) {
+	page := []byte("<!DOCTYPE html><html><body>Please log in</body></html>")
+	srv := newCacheTestServer(t, page)
+	cache, now := newTestIconCache(t, IconCacheOptions{MaxAge: time.Hour})
+	ctx := context.Background()
+	url := srv.URL + "/icon.png"
+
+	if _, err := cache.Fetch(ctx, url); err == nil {
+		t.Error("expected error for a page that is not an image")
+	}
+	if _, ok := cache.peek(url); ok {
+		t.Error("page cached as icon")
+	}
+
+	// A cached icon is kept when revalidation returns a page
+	icon := pngBytes(t, 16)
+	srv.data.Store(icon)
+	if _, err := cache.Fetch(ctx, url); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	srv.data.Store(page)
+	srv.etag.Store(`"v2"`)
+	*now = now.Add(2 * time.Hour)
+	if data, err := cache.Fetch(ctx, url); err != nil || !bytes.Equal(data, icon) {
+		t.Errorf("expected cached icon when revalidation returns a page, got err=%v", err)
+	}
+	if data, _ := cache.peek(url); !bytes.Equal(data, icon) {
+		t.Error("page replaced the cached icon")
+	}
+}
+
+// TestIconCache_Offline tests that offline mode never touches the network
+func TestIconCache_Offline(t *testing.T) {
+	icon := pngBytes(t, 16)
+	srv := newCacheTestServer(t, icon)
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	online, err := NewIconCache(dir, IconCacheOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := online.Fetch(ctx, srv.URL+"/icon.png"); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	offline, err := NewIconCache(dir, IconCacheOptions{Offline: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.requests.Store(0)
+	if data, err := offline.Fetch(ctx, srv.URL+"/icon.png"); err != nil || !bytes.Equal(data, icon) {
+		t.Errorf("expected cached icon in offline mode, got err=%v", err)
+	}
+	if _, err := offline.Fetch(ctx, srv.URL+"/other.png"); err == nil {
+		t.Error("expected error for uncached icon in offline mode")
+	}
+	if srv.requests.Load() != 0 {
+		t.Errorf("offline mode made %d requests", srv.requests.Load())
+	}
+}
+
+// TestIconCache_ContentAddressed tests that identical icons are stored once
+func TestIconCache_ContentAddressed(t *testing.T) {
+	srv := newCacheTestServer(t, pngBytes(t, 16))
+	cache, _ := newTestIconCache(t, IconCacheOptions{MaxAge: time.Hour})
+
+	for _, path := range []string{"/a.png", "/b.png", "/c.png"} {
+		if _, err := cache.Fetch(context.Background(), srv.URL+path); err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+	}
+
+	blobs, _ := os.ReadDir(filepath.Join(cache.dir, "blobs"))
+	index, _ := os.ReadDir(filepath.Join(cache.dir, "index"))
+	if len(blobs) != 1 || len(index) != 3 {
+		t.Errorf("expected 1 blob and 3 index entries, got %d and %d", len(blobs), len(index))
+	}
+}
+
+// TestIconCache_MaxSize tests least recently used eviction
+func TestIconCache_MaxSize(t *testing.T) {
+	mux := http.NewServeMux()
+	for i, size := range []int{16, 17, 18} {
+		data := pngBytes(t, size)
+		mux.HandleFunc("/"+string(rune('a'+i))+".png", func(w http.ResponseWriter, r *http.Request) { w.Write(data) })
+	}
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	// Room for about two icons
+	cache, now := newTestIconCache(t, IconCacheOptions{MaxAge: time.Hour, MaxSize: int64(len(pngBytes(t, 16))) * 5 / 2})
+	ctx := context.Background()
+
+	cache.Fetch(ctx, srv.URL+"/a.png")
+	*now = now.Add(time.Minute)
+	cache.Fetch(ctx, srv.URL+"/b.png")
+	*now = now.Add(time.Minute)
+	cache.Fetch(ctx, srv.URL+"/a.png") // a is now more recently used than b
+	*now = now.Add(time.Minute)
+	cache.Fetch(ctx, srv.URL+"/c.png")
+
+	if entry, _ := cache.lookup(srv.URL + "/b.png"); entry != nil {
+		t.Error("least recently used icon should be evicted")
+	}
+	for _, path := range []string{"/a.png", "/c.png"} {
+		if entry, _ := cache.lookup(srv.URL + path); entry == nil {
+			t.Errorf("%s should still be cached", path)
+		}
+	}
+}
+
+// TestIconCache_Prune tests removal of unused entries, orphans and corrupted files
+func TestIconCache_Prune(t *testing.T) {
+	srv := newCacheTestServer(t, pngBytes(t, 16))
+	cache, now := newTestIconCache(t, IconCacheOptions{MaxAge: time.Hour})
+	ctx := context.Background()
+
+	cache.Fetch(ctx, srv.URL+"/old.png")
+	srv.data.Store(pngBytes(t, 20))
+	*now = now.Add(48 * time.Hour)
+	cache.Fetch(ctx, srv.URL+"/new.png")
+
+	// Orphan blob, corrupted index entry and leftover temp files
+	os.WriteFile(cache.blobPath("orphan"), []byte("xx"), 0644)
+	os.WriteFile(filepath.Join(cache.dir, "index", "broken.json"), []byte("{"), 0644)
+	for _, path := range []string{filepath.Join(cache.dir, "index", ".tmp-123"), cache.blobPath(".tmp-456")} {
+		os.WriteFile(path, []byte("x"), 0644)
+		os.Chtimes(path, now.Add(-2*time.Hour), now.Add(-2*time.Hour))
+	}
+
+	// Temp files still written by another process are kept
+	active := []string{filepath.Join(cache.dir, "index", ".tmp-789"), cache.blobPath(".tmp-abc")}
+	for _, path := range active {
+		os.WriteFile(path, []byte("x"), 0644)
+		os.Chtimes(path, now.Add(-time.Minute), now.Add(-time.Minute))
+	}
+
+	stats, err := cache.Prune(24 * time.Hour)
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if stats.Entries != 1 || stats.Blobs != 3 {
+		t.Errorf("Prune() = %+v, want 1 entry and 3 blobs removed", stats)
+	}
+	if entry, _ := cache.lookup(srv.URL + "/old.png"); entry != nil {
+		t.Error("unused entry should be pruned")
+	}
+	if entry, _ := cache.lookup(srv.URL + "/new.png"); entry == nil {
+		t.Error("recent entry should be kept")
+	}
+
+	index, _ := os.ReadDir(filepath.Join(cache.dir, "index"))
+	if len(index) != 2 {
+		t.Errorf("expected the recent index entry and active temp file, got %d files", len(index))
+	}
+	for _, path := range active {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("active temp file removed: %v", err)
+		}
+	}
+}
+
+// TestIconLoader_Cache tests that icon URLs are loaded through the cache
+func TestIconLoader_Cache(t *testing.T) {
+	srv := newCacheTestServer(t, pngBytes(t, 24))
+	cache, _ := newTestIconCache(t, IconCacheOptions{MaxAge: time.Hour})
+	loader := &iconLoader{cache: cache}
+
+	for i := 0; i < 2; i++ {
+		img, err := loader.load(srv.URL + "/icon.png")
+		if err != nil {
+			t.Fatalf("load() error = %v", err)
+		}
+		if img.Bounds().Dx() != 24 {
+			t.Errorf("unexpected icon size %v", img.Bounds())
+		}
+	}
+	if srv.requests.Load() != 1 {
+		t.Errorf("expected 1 download, got %d", srv.requests.Load())
+	}
+}
@@ -0,0 +1,383 @@
+package fpkgen
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Limits for icon discovery requests
+const (
+	discoveryTimeout     = 10 * time.Second
+	maxDiscoveryPageSize = 1 << 20 // 1MB of HTML is plenty to find the <head>
+	maxManifestSize      = 256 << 10
+)
+
+// Default sizes for icons declared without a sizes attribute
+const (
+	appleTouchIconSize = 180 // iOS default apple-touch-icon size
+	vectorIconSize     = svgRenderSize
+)
+
+// iconClient is the HTTP client used to download icons.
+// The published ports of apps WatchCow discovers icons from commonly use
+// self-signed certificates, so certificate verification is skipped for them only.
+var iconClient = &http.Client{
+	Timeout:   60 * time.Second,
+	Transport: &discoveryTransport{secure: http.DefaultTransport, insecure: insecureTransport()},
+}
+
+// discoveryOrigins holds the loopback origins (scheme://host:port) icon discovery
+// probed: the published ports of apps, reached on the host
+var discoveryOrigins sync.Map
+
+// trustDiscoveryOrigin skips certificate verification for the origin of a page
+// icon discovery probes, if it is on a loopback host
+func trustDiscoveryOrigin(pageURL string) {
+	u, err := url.Parse(pageURL)
+	if err != nil || !isLoopbackHost(u.Hostname()) {
+		return
+	}
+	discoveryOrigins.Store(u.Scheme+"://"+u.Host, true)
+}
+
+// discoveryTransport routes requests to the origins probed by icon discovery
+// through an insecure transport. Configured icon URLs, including loopback ones,
+// are verified.
+type discoveryTransport struct {
+	secure   http.RoundTripper
+	insecure http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *discoveryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if _, ok := discoveryOrigins.Load(req.URL.Scheme + "://" + req.URL.Host); ok {
+		return t.insecure.RoundTrip(req)
+	}
+	return t.secure.RoundTrip(req)
+}
+
+// insecureTransport returns a clone of the default transport without certificate verification
+func insecureTransport() http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	return transport
+}
+
+// isLoopbackHost reports whether host is localhost or a loopback IP
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// iconCandidate is an icon found on a web app page or in its manifest
+type iconCandidate struct {
+	URL  string
+	Size int // Largest declared edge length (0 if unknown)
+}
+
+// discoverEntryIcons replaces the generic fallback icon of entries with the icon
+// served by the running web app. Entries are reached on host at their port and path.
+// Entries with an explicit or preset icon are left unchanged.
+func discoverEntryIcons(ctx context.Context, config *AppConfig, host string) {
+	discovered := make(map[string]string) // page URL -> icon URL ("" if none)
+
+	for i := range config.Entries {
+		entry := &config.Entries[i]
+		if entry.Icon != fallbackIconURL || entry.Port == "" {
+			continue
+		}
+
+		pageURL := entryURL(entry, host)
+		icon, ok := discovered[pageURL]
+		if !ok {
+			var err error
+			icon, err = discoverIcon(ctx, pageURL)
+			if err != nil {
+				slog.Debug("Icon discovery failed", "container", config.ContainerName, "url", pageURL, "error", err)
+			} else {
+				slog.Info("Discovered app icon", "container", config.ContainerName, "icon", icon)
+			}
+			discovered[pageURL] = icon
+		}
+		if icon == "" {
+			continue
+		}
+
+		entry.Icon = icon
+		if entry.Name == "" && config.Icon == fallbackIconURL {
+			config.Icon = icon
+		}
+	}
+}
+
+// entryURL builds the URL of an entry on the given host
+func entryURL(entry *Entry, host string) string {
+	protocol := entry.Protocol
+	if protocol == "" {
+		protocol = "http"
+	}
+	path := entry.Path
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return fmt.Sprintf("%s://%s%s", protocol, net.JoinHostPort(host, entry.Port), path)
+}
+
+// discoverIcon finds the best icon of the web app at pageURL.
+// Candidates come from <link rel="icon|apple-touch-icon"> tags, the web app manifest
+// (linked, or /manifest.json and /site.webmanifest) and /favicon.ico. They are tried
+// from the largest to the smallest; the first one that downloads and decodes wins.
+func discoverIcon(ctx context.Context, pageURL string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, discoveryTimeout)
+	defer cancel()
+
+	trustDiscoveryOrigin(pageURL)
+	body, finalURL, err := fetchLimited(ctx, pageURL, maxDiscoveryPageSize)
+	if err != nil {
+		return "", err
+	}
+
+	candidates, manifests := parseIconLinks(body, finalURL)
+
+	// Well-known manifest locations if the page does not link one
+	if len(manifests) == 0 {
+		for _, path := range []string{"/manifest.json", "/site.webmanifest"} {
+			manifests = append(manifests, finalURL.ResolveReference(&url.URL{Path: path}).String())
+		}
+	}
+	for _, manifestURL := range manifests {
+		icons, err := fetchManifestIcons(ctx, manifestURL)
+		if err != nil {
+			slog.Debug("Failed to read web app manifest", "url", manifestURL, "error", err)
+			continue
+		}
+		candidates = append(candidates, icons...)
+	}
+
+	// Always fall back to /favicon.ico
+	candidates = append(candidates, iconCandidate{URL: finalURL.ResolveReference(&url.URL{Path: "/favicon.ico"}).String()})
+
+	for _, candidate := range rankIconCandidates(candidates) {
+		data, _, err := fetchLimited(ctx, candidate.URL, maxIconFileSize)
+		if err != nil {
+			continue
+		}
+		if _, err := decodeIconData(data); err != nil {
+			continue
+		}
+		return candidate.URL, nil
+	}
+
+	return "", fmt.Errorf("no usable icon found")
+}
+
+// rankIconCandidates removes duplicates and sorts candidates from the largest to the smallest
+// Candidates of equal size keep their discovery order
+func rankIconCandidates(candidates []iconCandidate) []iconCandidate {
+	seen := make(map[string]int)
+	var ranked []iconCandidate
+	for _, c := range candidates {
+		if i, ok := seen[c.URL]; ok {
+			if c.Size > ranked[i].Size {
+				ranked[i].Size = c.Size
+			}
+			continue
+		}
+		seen[c.URL] = len(ranked)
+		ranked = append(ranked, c)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Size > ranked[j].Size
+	})
+	return ranked
+}
+
+// parseIconLinks extracts icon candidates and manifest URLs from the <head> of an HTML page
+func parseIconLinks(page []byte, base *url.URL) (icons []iconCandidate, manifests []string) {
+	z := html.NewTokenizer(bytes.NewReader(page))
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return
+		case html.StartTagToken, html.SelfClosingTagToken:
+		case html.EndTagToken:
+			if name, _ := z.TagName(); string(name) == "head" {
+				return
+			}
+			continue
+		default:
+			continue
+		}
+
+		name, hasAttr := z.TagName()
+		switch string(name) {
+		case "body":
+			return
+		case "base":
+			if href := tagAttrs(z, hasAttr)["href"]; href != "" {
+				if u, err := base.Parse(href); err == nil {
+					base = u
+				}
+			}
+		case "link":
+			attrs := tagAttrs(z, hasAttr)
+			href := strings.TrimSpace(attrs["href"])
+			if href == "" {
+				continue
+			}
+			u, err := base.Parse(href)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				continue
+			}
+
+			rels := strings.Fields(strings.ToLower(attrs["rel"]))
+			switch {
+			case hasToken(rels, "manifest"):
+				manifests = append(manifests, u.String())
+			case hasToken(rels, "apple-touch-icon"), hasToken(rels, "apple-touch-icon-precomposed"):
+				size := parseIconSizes(attrs["sizes"], attrs["type"], u.Path)
+				if size == 0 {
+					size = appleTouchIconSize
+				}
+				icons = append(icons, iconCandidate{URL: u.String(), Size: size})
+			case hasToken(rels, "icon"):
+				icons = append(icons, iconCandidate{URL: u.String(), Size: parseIconSizes(attrs["sizes"], attrs["type"], u.Path)})
+			}
+		}
+	}
+}
+
+// tagAttrs returns the attributes of the current tag (lowercase keys)
+func tagAttrs(z *html.Tokenizer, hasAttr bool) map[string]string {
+	attrs := make(map[string]string)
+	for hasAttr {
+		var key, val []byte
+		key, val, hasAttr = z.TagAttr()
+		attrs[strings.ToLower(string(key))] = string(val)
+	}
+	return attrs
+}
+
+// hasToken reports whether tokens contains token
+func hasToken(tokens []string, token string) bool {
+	for _, t := range tokens {
+		if t == token {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIconSizes returns the largest edge length of a sizes attribute (e.g. "16x16 32x32").
+// Vector icons ("any", SVG type or extension) rank as vectorIconSize. Returns 0 if unknown.
+func parseIconSizes(sizes, mimeType, path string) int {
+	largest := 0
+	for _, size := range strings.Fields(strings.ToLower(sizes)) {
+		if size == "any" {
+			largest = max(largest, vectorIconSize)
+			continue
+		}
+		w, h, ok := strings.Cut(size, "x")
+		if !ok {
+			continue
+		}
+		width, err1 := strconv.Atoi(w)
+		height, err2 := strconv.Atoi(h)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		largest = max(largest, width, height)
+	}
+
+	if largest == 0 && (strings.Contains(mimeType, "svg") || strings.HasSuffix(strings.ToLower(path), ".svg")) {
+		largest = vectorIconSize
+	}
+	return largest
+}
+
+// webManifest is the subset of a web app manifest used for icon discovery
+type webManifest struct {
+	Icons []struct {
+		Src     string `json:"src"`
+		Sizes   string `json:"sizes"`
+		Type    string `json:"type"`
+		Purpose string `json:"purpose"`
+	} `json:"icons"`
+}
+
+// fetchManifestIcons downloads a web app manifest and returns its icons
+// Monochrome icons are skipped since they are meant to be tinted by the platform
+func fetchManifestIcons(ctx context.Context, manifestURL string) ([]iconCandidate, error) {
+	data, finalURL, err := fetchLimited(ctx, manifestURL, maxManifestSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest webManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+
+	var icons []iconCandidate
+	for _, icon := range manifest.Icons {
+		if icon.Src == "" || hasToken(strings.Fields(icon.Purpose), "monochrome") {
+			continue
+		}
+		u, err := finalURL.Parse(icon.Src)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		icons = append(icons, iconCandidate{URL: u.String(), Size: parseIconSizes(icon.Sizes, icon.Type, u.Path)})
+	}
+	return icons, nil
+}
+
+// fetchLimited performs a GET request and reads at most limit bytes of a 200 response
+// Returns the body and the final URL after redirects
+func fetchLimited(ctx context.Context, rawURL string, limit int64) ([]byte, *url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := iconClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, &httpStatusError{Code: resp.StatusCode}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, nil, fmt.Errorf("response too large (> %d bytes)", limit)
+	}
+
+	return data, resp.Request.URL, nil
+}
@@ -0,0 +1,267 @@
+package fpkgen
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// pngBytes encodes a size x size PNG for test servers
+func pngBytes(t *testing.T, size int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, size, size))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// newIconServer starts a test server serving the given paths
+func newIconServer(t *testing.T, files map[string][]byte) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// TestDiscoverIcon_LargestCandidate tests picking the largest icon from links and manifest
+func TestDiscoverIcon_LargestCandidate(t *testing.T) {
+	srv := newIconServer(t, map[string][]byte{
+		"/web/": []byte(`<!DOCTYPE html><html><head>
+			<link rel="icon" href="favicon-32.png" sizes="32x32">
+			<link rel="apple-touch-icon" href="/apple-touch-icon.png">
+			<link rel="manifest" href="/app.webmanifest">
+			</head><body><link rel="icon" href="/ignored.png" sizes="1024x1024"></body></html>`),
+		"/web/favicon-32.png":   pngBytes(t, 32),
+		"/apple-touch-icon.png": pngBytes(t, 180),
+		"/app.webmanifest": []byte(`{"icons": [
+			{"src": "icons/192.png", "sizes": "192x192"},
+			{"src": "icons/512.png", "sizes": "512x512"},
+			{"src": "icons/mono.png", "sizes": "1024x1024", "purpose": "monochrome"}
+		]}`),
+		"/icons/192.png":  pngBytes(t, 192),
+		"/icons/512.png":  pngBytes(t, 512),
+		"/icons/mono.png": pngBytes(t, 1024),
+	})
+
+	got, err := discoverIcon(context.Background(), srv.URL+"/web/")
+	if err != nil {
+		t.Fatalf("discoverIcon() error = %v", err)
+	}
+	if want := srv.URL + "/icons/512.png"; got != want {
+		t.Errorf("discoverIcon() = %q, want %q", got, want)
+	}
+}
+
+// TestDiscoverIcon_SkipsBrokenCandidates tests falling back when the best icon cannot be loaded
+func TestDiscoverIcon_SkipsBrokenCandidates(t *testing.T) {
+	srv := newIconServer(t, map[string][]byte{
+		"/": []byte(`<html><head>
+			<link rel="icon" href="/missing.png" sizes="256x256">
+			<link rel="icon" href="/broken.png" sizes="128x128">
+			<link rel="shortcut icon" href="/small.png" sizes="64x64">
+			</head></html>`),
+		"/broken.png": []byte("not an image"),
+		"/small.png":  pngBytes(t, 64),
+	})
+
+	got, err := discoverIcon(context.Background(), srv.URL+"/")
+	if err != nil {
+		t.Fatalf("discoverIcon() error = %v", err)
+	}
+	if want := srv.URL + "/small.png"; got != want {
+		t.Errorf("discoverIcon() = %q, want %q", got, want)
+	}
+}
+
+// TestDiscoverIcon_WellKnownLocations tests the site.webmanifest and favicon.ico fallbacks
+func TestDiscoverIcon_WellKnownLocations(t *testing.T) {
+	icoData, _ := generateICOWithPNGImages([]int{16, 32})
+
+	srv := newIconServer(t, map[string][]byte{
+		"/":            []byte(`<html><head><title>App</title></head></html>`),
+		"/favicon.ico": icoData,
+	})
+	got, err := discoverIcon(context.Background(), srv.URL+"/")
+	if err != nil {
+		t.Fatalf("discoverIcon() error = %v", err)
+	}
+	if want := srv.URL + "/favicon.ico"; got != want {
+		t.Errorf("discoverIcon() = %q, want %q", got, want)
+	}
+
+	srv = newIconServer(t, map[string][]byte{
+		"/":                           []byte(`<html></html>`),
+		"/site.webmanifest":           []byte(`{"icons": [{"src": "/android-chrome-192x192.png", "sizes": "192x192"}]}`),
+		"/android-chrome-192x192.png": pngBytes(t, 192),
+		"/favicon.ico":                icoData,
+	})
+	got, err = discoverIcon(context.Background(), srv.URL+"/")
+	if err != nil {
+		t.Fatalf("discoverIcon() error = %v", err)
+	}
+	if want := srv.URL + "/android-chrome-192x192.png"; got != want {
+		t.Errorf("discoverIcon() = %q, want %q", got, want)
+	}
+}
+
+// TestDiscoverIcon_NoIcon tests error handling when the app has no usable icon
+func TestDiscoverIcon_NoIcon(t *testing.T) {
+	srv := newIconServer(t, map[string][]byte{"/": []byte(`<html></html>`)})
+
+	if _, err := discoverIcon(context.Background(), srv.URL+"/"); err == nil {
+		t.Error("expected error for app without icons")
+	}
+	if _, err := discoverIcon(context.Background(), srv.URL+"/missing"); err == nil {
+		t.Error("expected error for unreachable page")
+	}
+}
+
+// TestDiscoverIcon_SelfSignedLoopback tests that loopback HTTPS services with self-signed
+// certs work for discovery, while configured loopback icon URLs are still verified
+func TestDiscoverIcon_SelfSignedLoopback(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/favicon.png" {
+			w.Write(pngBytes(t, 48))
+			return
+		}
+		w.Write([]byte(`<link rel="icon" href="/favicon.png">`))
+	})
+	srv := httptest.NewTLSServer(handler)
+	defer srv.Close()
+
+	other := httptest.NewTLSServer(handler)
+	defer other.Close()
+	if _, _, err := fetchLimited(context.Background(), other.URL+"/favicon.png", maxIconFileSize); err == nil {
+		t.Error("expected certificate error for a configured loopback icon URL")
+	}
+
+	got, err := discoverIcon(context.Background(), srv.URL+"/")
+	if err != nil {
+		t.Fatalf("discoverIcon() error = %v", err)
+	}
+	if want := srv.URL + "/favicon.png"; got != want {
+		t.Errorf("discoverIcon() = %q, want %q", got, want)
+	}
+	// The discovered icon is downloaded from the probed origin
+	if _, _, err := fetchLimited(context.Background(), got, maxIconFileSize); err != nil {
+		t.Errorf("downloading discovered icon: %v", err)
+	}
+}
+
+// TestDiscoverEntryIcons tests that only entries with the fallback icon are discovered
+func TestDiscoverEntryIcons(t *testing.T) {
+	srv := newIconServer(t, map[string][]byte{
+		"/":         []byte(`<link rel="icon" href="/icon.png">`),
+		"/icon.png": pngBytes(t, 64),
+	})
+	u, _ := url.Parse(srv.URL)
+	host, port, _ := net.SplitHostPort(u.Host)
+
+	config := &AppConfig{
+		Icon: fallbackIconURL,
+		Entries: []Entry{
+			{Name: "", Protocol: "http", Port: port, Path: "/", Icon: fallbackIconURL},
+			{Name: "admin", Protocol: "http", Port: port, Path: "/", Icon: "https://example.com/admin.png"},
+			{Name: "noport", Protocol: "http", Path: "/", Icon: fallbackIconURL},
+		},
+	}
+
+	discoverEntryIcons(context.Background(), config, host)
+
+	want := srv.URL + "/icon.png"
+	if config.Entries[0].Icon != want || config.Icon != want {
+		t.Errorf("default entry icon = %q, config icon = %q, want %q", config.Entries[0].Icon, config.Icon, want)
+	}
+	if config.Entries[1].Icon != "https://example.com/admin.png" {
+		t.Errorf("explicit icon changed to %q", config.Entries[1].Icon)
+	}
+	if config.Entries[2].Icon != fallbackIconURL {
+		t.Errorf("entry without port changed to %q", config.Entries[2].Icon)
+	}
+}
+
+// TestParseIconLinks tests link parsing with <base>, rel variants and non-http schemes
+func TestParseIconLinks(t *testing.T) {
+	base, _ := url.Parse("http://app.local:8080/sub/page")
+	page := []byte(`<html><head>
+		<base href="/static/">
+		<LINK REL="Shortcut Icon" HREF="fav.ico">
+		<link rel="apple-touch-icon-precomposed" href="touch.png">
+		<link rel="icon" href="data:image/png;base64,AAAA">
+		<link rel="icon" type="image/svg+xml" href="logo.svg">
+		<link rel="stylesheet" href="style.css">
+		<link rel="manifest" href="manifest.json">
+		</head></html>`)
+
+	icons, manifests := parseIconLinks(page, base)
+
+	want := []iconCandidate{
+		{URL: "http://app.local:8080/static/fav.ico", Size: 0},
+		{URL: "http://app.local:8080/static/touch.png", Size: appleTouchIconSize},
+		{URL: "http://app.local:8080/static/logo.svg", Size: vectorIconSize},
+	}
+	if len(icons) != len(want) {
+		t.Fatalf("parseIconLinks() icons = %+v, want %+v", icons, want)
+	}
+	for i := range want {
+		if icons[i] != want[i] {
+			t.Errorf("icon[%d] = %+v, want %+v", i, icons[i], want[i])
+		}
+	}
+	if len(manifests) != 1 || manifests[0] != "http://app.local:8080/static/manifest.json" {
+		t.Errorf("manifests = %v", manifests)
+	}
+}
+
+// TestParseIconSizes tests parsing of sizes attributes
+func TestParseIconSizes(t *testing.T) {
+	tests := []struct {
+		sizes, mimeType, path string
+		want                  int
+	}{
+		{"16x16", "", "/a.png", 16},
+		{"16x16 32x32 192x192", "", "/a.png", 192},
+		{"48X48", "", "/a.png", 48},
+		{"any", "", "/a.png", vectorIconSize},
+		{"", "image/svg+xml", "/a", vectorIconSize},
+		{"", "", "/logo.SVG", vectorIconSize},
+		{"", "", "/a.png", 0},
+		{"bogus 12xz", "", "/a.png", 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseIconSizes(tt.sizes, tt.mimeType, tt.path); got != tt.want {
+			t.Errorf("parseIconSizes(%q, %q, %q) = %d, want %d", tt.sizes, tt.mimeType, tt.path, got, tt.want)
+		}
+	}
+}
+
+// TestIsLoopbackHost tests loopback host detection
+func TestIsLoopbackHost(t *testing.T) {
+	for host, want := range map[string]bool{
+		"localhost":   true,
+		"127.0.0.1":   true,
+		"127.1.2.3":   true,
+		"::1":         true,
+		"192.168.1.2": false,
+		"example.com": false,
+	} {
+		if got := isLoopbackHost(host); got != want {
+			t.Errorf("isLoopbackHost(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
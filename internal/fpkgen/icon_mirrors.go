@@ -0,0 +1,172 @@
+package fpkgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dashboardIconsBase is the canonical base URL of the dashboard-icons repository.
+// Icon URLs below it are served from the configured mirrors.
+const dashboardIconsBase = "https://cdn.jsdelivr.net/gh/homarr-labs/dashboard-icons"
+
+// DefaultIconMirrors is the default mirror list (the canonical CDN only)
+var DefaultIconMirrors = []string{dashboardIconsBase}
+
+const (
+	iconMirrorTimeout     = 15 * time.Second // Limits one download attempt so a dead mirror fails fast
+	iconMirrorCooldown    = time.Minute      // Initial time a failed mirror is skipped
+	iconMirrorMaxCooldown = time.Hour        // Cap of the exponential backoff
+)
+
+// httpStatusError is returned for unexpected HTTP response codes
+type httpStatusError struct {
+	Code int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("status %d", e.Code)
+}
+
+// mirrorURL is one download attempt of a mirrored icon
+type mirrorURL struct {
+	Base string // Mirror base URL (empty for URLs that are not mirrored)
+	URL  string
+}
+
+// mirrorHealth tracks recent failures of a mirror
+type mirrorHealth struct {
+	failures  int
+	downUntil time.Time
+}
+
+// iconMirrors holds the ordered dashboard-icons mirrors and their health.
+// A nil *iconMirrors downloads every URL as is.
+type iconMirrors struct {
+	bases  []string
+	mu     sync.Mutex
+	health map[string]*mirrorHealth
+	now    func() time.Time
+}
+
+// newIconMirrors creates the mirror list from base URLs (nil if empty)
+// e.g. https://raw.githubusercontent.com/homarr-labs/dashboard-icons/main
+func newIconMirrors(bases []string) *iconMirrors {
+	var cleaned []string
+	seen := make(map[string]bool)
+	for _, base := range bases {
+		base = strings.TrimRight(strings.TrimSpace(base), "/")
+		if base == "" || seen[base] {
+			continue
+		}
+		seen[base] = true
+		cleaned = append(cleaned, base)
+	}
+	if len(cleaned) == 0 {
+		return nil
+	}
+
+	return &iconMirrors{
+		bases:  cleaned,
+		health: make(map[string]*mirrorHealth),
+		now:    time.Now,
+	}
+}
+
+// resolve returns the URLs to try for an icon URL.
+// dashboard-icons URLs are mapped to every mirror: healthy mirrors in configured
+// order first, then mirrors in cooldown (soonest available first) as a last resort.
+func (m *iconMirrors) resolve(rawURL string) []mirrorURL {
+	rel, ok := strings.CutPrefix(rawURL, dashboardIconsBase+"/")
+	if m == nil || !ok {
+		return []mirrorURL{{URL: rawURL}}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	var healthy, down []string
+	for _, base := range m.bases {
+		if h := m.health[base]; h != nil && now.Before(h.downUntil) {
+			down = append(down, base)
+		} else {
+			healthy = append(healthy, base)
+		}
+	}
+	sort.SliceStable(down, func(i, j int) bool {
+		return m.health[down[i]].downUntil.Before(m.health[down[j]].downUntil)
+	})
+
+	urls := make([]mirrorURL, 0, len(m.bases))
+	for _, base := range append(healthy, down...) {
+		urls = append(urls, mirrorURL{Base: base, URL: base + "/" + rel})
+	}
+	return urls
+}
+
+// report records the result of a download from a mirror.
+// Missing icons (404) say nothing about the mirror and are not counted.
+func (m *iconMirrors) report(base string, err error) {
+	if m == nil || base == "" {
+		return
+	}
+
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) && statusErr.Code == http.StatusNotFound {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err == nil {
+		delete(m.health, base)
+		return
+	}
+
+	h := m.health[base]
+	if h == nil {
+		h = &mirrorHealth{}
+		m.health[base] = h
+	}
+	h.failures++
+
+	cooldown := iconMirrorCooldown
+	for i := 1; i < h.failures && cooldown < iconMirrorMaxCooldown; i++ {
+		cooldown *= 2
+	}
+	cooldown = min(cooldown, iconMirrorMaxCooldown)
+	h.downUntil = m.now().Add(cooldown)
+}
+
+// fetchMirrored downloads an icon, trying each mirror of dashboard-icons URLs in turn.
+// fetch downloads one URL; data that does not decode as an image counts as a mirror
+// failure, e.g. a blocked mirror returning an HTML page.
+func (m *iconMirrors) fetchMirrored(rawURL string, fetch func(ctx context.Context, url string) ([]byte, error)) ([]byte, error) {
+	var errs []error
+	for _, u := range m.resolve(rawURL) {
+		ctx, cancel := context.WithTimeout(context.Background(), iconMirrorTimeout)
+		data, err := fetch(ctx, u.URL)
+		cancel()
+
+		if err == nil && detectFormat(data) == FormatUnknown {
+			err = fmt.Errorf("unsupported image format")
+		}
+		m.report(u.Base, err)
+		if err == nil {
+			return data, nil
+		}
+
+		if u.Base != "" {
+			err = fmt.Errorf("%s: %w", u.Base, err)
+		}
+		errs = append(errs, err)
+	}
+	return nil, errors.Join(errs...)
+}
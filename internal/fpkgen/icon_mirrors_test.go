@@ -0,0 +1,146 @@
+package fpkgen
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newMirrorServer starts a mirror serving /png/app.png with the given handler and counts requests
+func newMirrorServer(t *testing.T, handler http.HandlerFunc) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+// TestIconMirrors_Resolve tests mapping dashboard-icons URLs to mirrors
+func TestIconMirrors_Resolve(t *testing.T) {
+	m := newIconMirrors([]string{" https://a.example/icons/ ", "", "http://nas.lan/di", "https://a.example/icons"})
+
+	got := m.resolve(fallbackIconURL)
+	want := []mirrorURL{
+		{Base: "https://a.example/icons", URL: "https://a.example/icons/png/docker.png"},
+		{Base: "http://nas.lan/di", URL: "http://nas.lan/di/png/docker.png"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("resolve() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("resolve()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// Other URLs and disabled mirrors download as is
+	other := "https://example.com/icon.png"
+	if got := m.resolve(other); len(got) != 1 || got[0].URL != other || got[0].Base != "" {
+		t.Errorf("resolve(%q) = %+v", other, got)
+	}
+	var none *iconMirrors
+	if got := none.resolve(fallbackIconURL); len(got) != 1 || got[0].URL != fallbackIconURL {
+		t.Errorf("nil mirrors resolve() = %+v", got)
+	}
+	if newIconMirrors([]string{"", " "}) != nil {
+		t.Error("expected nil mirrors for empty list")
+	}
+}
+
+// TestIconMirrors_Health tests cooldown, exponential backoff and recovery
+func TestIconMirrors_Health(t *testing.T) {
+	m := newIconMirrors([]string{"http://a", "http://b", "http://c"})
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	order := func() []string {
+		var bases []string
+		for _, u := range m.resolve(fallbackIconURL) {
+			bases = append(bases, u.Base)
+		}
+		return bases
+	}
+	assertOrder := func(want ...string) {
+		t.Helper()
+		got := order()
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("mirror order = %v, want %v", got, want)
+			}
+		}
+	}
+
+	// Missing icons do not affect the mirror
+	m.report("http://a", &httpStatusError{Code: http.StatusNotFound})
+	assertOrder("http://a", "http://b", "http://c")
+
+	// Failed mirrors move to the end, soonest available first
+	m.report("http://a", errors.New("connection refused"))
+	m.report("http://a", &httpStatusError{Code: http.StatusBadGateway})
+	m.report("http://b", errors.New("timeout"))
+	assertOrder("http://c", "http://b", "http://a")
+
+	// Backoff doubles per failure: a is down for 2 minutes, b for 1
+	now = now.Add(90 * time.Second)
+	assertOrder("http://b", "http://c", "http://a")
+	now = now.Add(time.Minute)
+	assertOrder("http://a", "http://b", "http://c")
+
+	// Backoff is capped and reset by a success
+	for i := 0; i < 20; i++ {
+		m.report("http://a", errors.New("down"))
+	}
+	if until := m.health["http://a"].downUntil; until.Sub(now) != iconMirrorMaxCooldown {
+		t.Errorf("cooldown = %v, want %v", until.Sub(now), iconMirrorMaxCooldown)
+	}
+	m.report("http://a", nil)
+	assertOrder("http://a", "http://b", "http://c")
+}
+
+// TestIconLoader_Mirrors tests falling back across mirrors and skipping dead ones
+func TestIconLoader_Mirrors(t *testing.T) {
+	icon := pngBytes(t, 32)
+	dead, deadRequests := newMirrorServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	blocked, blockedRequests := newMirrorServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html>Access denied</html>"))
+	})
+	good, goodRequests := newMirrorServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/di/png/app.png" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(icon)
+	})
+
+	loader := &iconLoader{mirrors: newIconMirrors([]string{dead.URL, blocked.URL, good.URL + "/di"})}
+	source := dashboardIconsBase + "/png/app.png"
+
+	for i := 0; i < 3; i++ {
+		img, err := loader.load(source)
+		if err != nil {
+			t.Fatalf("load() error = %v", err)
+		}
+		if img.Bounds().Dx() != 32 {
+			t.Errorf("unexpected icon size %v", img.Bounds())
+		}
+	}
+
+	// Failed mirrors are tried once, then skipped while in cooldown
+	if deadRequests.Load() != 1 || blockedRequests.Load() != 1 || goodRequests.Load() != 3 {
+		t.Errorf("requests dead=%d blocked=%d good=%d, want 1, 1, 3",
+			deadRequests.Load(), blockedRequests.Load(), goodRequests.Load())
+	}
+
+	// Icons missing on every mirror fail with all errors
+	if _, err := loader.load(dashboardIconsBase + "/png/missing.png"); err == nil {
+		t.Error("expected error for icon missing on all mirrors")
+	}
+}
@@ -0,0 +1,167 @@
+package fpkgen
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultIconRefreshInterval is how often the icons of installed apps are re-checked
+const DefaultIconRefreshInterval = 24 * time.Hour
+
+// RunIconRefresh re-checks the icons of installed apps every IconRefresh interval
+// until ctx is done. Does nothing if the interval is 0 or OnIconsReady is not set.
+func (g *Generator) RunIconRefresh(ctx context.Context) {
+	if g.iconRefresh <= 0 || g.onIconsReady == nil {
+		return
+	}
+
+	ticker := time.NewTicker(g.iconRefresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			g.RefreshIcons()
+		}
+	}
+}
+
+// RefreshIcons reloads the icons of all installed apps, revalidating cached
+// downloads, and hands a regenerated package to OnIconsReady for each app whose
+// rendered icon files changed
+func (g *Generator) RefreshIcons() {
+	for containerID, config := range g.GetAllInstalled() {
+		g.refreshIcons(containerID, config)
+	}
+}
+
+// refreshIcons re-renders the icons of one installed app
+func (g *Generator) refreshIcons(containerID string, config *AppConfig) {
+	loader := g.newIconLoader(config)
+	loader.revalidate = true
+	icons := loader.loadIcons(config)
+	icons.wait(0)
+
+	// A failing source would replace a working icon by a letter avatar
+	if icons.failed() {
+		slog.Debug("Skipping icon refresh, some icons failed to load", "appName", config.AppName)
+		return
+	}
+
+	appDir, err := g.newPackageDir(config)
+	if err != nil {
+		slog.Warn("Failed to refresh icons", "appName", config.AppName, "error", err)
+		return
+	}
+	var hash string
+	if _, err = writeIcons(appDir, config, icons); err == nil {
+		hash, err = hashIcons(appDir)
+	}
+	if err != nil || !g.iconsChanged(containerID, hash) || g.onIconsReady == nil {
+		os.RemoveAll(appDir)
+		if err != nil {
+			slog.Warn("Failed to refresh icons", "appName", config.AppName, "error", err)
+		}
+		return
+	}
+
+	slog.Info("Icons changed, regenerated fnOS app package", "appName", config.AppName, "appDir", appDir)
+	// The hash is only recorded once the app is upgraded: a failed upgrade is retried
+	if err := g.onIconsReady(config, appDir); err != nil {
+		slog.Warn("Icon upgrade failed, retrying at the next refresh", "appName", config.AppName, "error", err)
+		return
+	}
+	g.setIconHash(containerID, hash)
+}
+
+// trackIcons records the hash of the icon files of a package generated for a container
+func (g *Generator) trackIcons(containerID, appDir string) error {
+	hash, err := hashIcons(appDir)
+	if err != nil {
+		return err
+	}
+	g.setIconHash(containerID, hash)
+	return nil
+}
+
+// iconsChanged reports whether an icon hash differs from the one of the installed package
+func (g *Generator) iconsChanged(containerID, hash string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.iconHashes[containerID] != hash
+}
+
+// setIconHash records the icon hash of the installed package of a container
+func (g *Generator) setIconHash(containerID, hash string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.iconHashes == nil {
+		g.iconHashes = make(map[string]string)
+	}
+	g.iconHashes[containerID] = hash
+}
+
+// fnosAppsDir is where App Center installs apps: the package root (manifest and
+// ICON*.PNG) goes to <appsDir>/<appname> and its app directory to <appname>/target
+const fnosAppsDir = "/var/apps"
+
+// hashIcons hashes the names and contents of the PNG icon files of a package
+func hashIcons(appDir string) (string, error) {
+	return hashIconFiles(appDir, "app")
+}
+
+// hashInstalledIcons hashes the icon files of an app installed by App Center,
+// matching the hash of the package it was installed from
+func hashInstalledIcons(appDir string) (string, error) {
+	hash, err := hashIconFiles(appDir, "target")
+	if err == nil && hash == "" {
+		err = fmt.Errorf("no icons in %s", appDir)
+	}
+	return hash, err
+}
+
+// hashIconFiles hashes the PNG files of the package root and of <target>/ui/images,
+// where target is the app directory of the package. Returns "" if there are none.
+func hashIconFiles(root, target string) (string, error) {
+	files := make(map[string]string) // package path -> file
+	for dir, name := range map[string]string{
+		root: "",
+		filepath.Join(root, target, "ui", "images"): "app/ui/images/",
+	} {
+		entries, err := os.ReadDir(dir)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.EqualFold(filepath.Ext(entry.Name()), ".png") {
+				files[name+entry.Name()] = filepath.Join(dir, entry.Name())
+			}
+		}
+	}
+	if len(files) == 0 {
+		return "", nil
+	}
+
+	h := sha256.New()
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		data, err := os.ReadFile(files[name])
+		if err != nil {
+			return "", err
+		}
+		h.Write([]byte(name + "\x00"))
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
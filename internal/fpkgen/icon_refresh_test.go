@@ -0,0 +1,257 @@
+package fpkgen
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestHashIcons tests that only the names and contents of PNG files are hashed
+func TestHashIcons(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "app", "ui", "images"), 0755)
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash := func() string {
+		h, err := hashIcons(dir)
+		if err != nil {
+			t.Fatalf("hashIcons() error = %v", err)
+		}
+		return h
+	}
+
+	write("ICON.PNG", "a")
+	write("app/ui/images/icon_64.png", "b")
+	base := hash()
+
+	write("manifest", "version=2")
+	if hash() != base {
+		t.Error("non-icon files should not change the hash")
+	}
+	write("app/ui/images/icon_64.png", "c")
+	if hash() == base {
+		t.Error("changed icon should change the hash")
+	}
+	write("app/ui/images/icon_64.png", "b")
+	os.Rename(filepath.Join(dir, "app/ui/images/icon_64.png"), filepath.Join(dir, "app/ui/images/icon_admin_64.png"))
+	if hash() == base {
+		t.Error("renamed icon should change the hash")
+	}
+}
+
+// TestGenerator_RefreshIcons tests that packages are only regenerated when icons change
+func TestGenerator_RefreshIcons(t *testing.T) {
+	engine, err := NewTemplateEngine()
+	if err != nil {
+		t.Fatalf("NewTemplateEngine() error = %v", err)
+	}
+	srv := newCacheTestServer(t, pngBytes(t, 16))
+
+	for _, cached := range []bool{false, true} {
+		name := "direct"
+		if cached {
+			name = "cached"
+		}
+		t.Run(name, func(t *testing.T) {
+			srv.data.Store(pngBytes(t, 16))
+			srv.etag.Store(`"v1"`)
+			srv.status.Store(0)
+
+			var upgrades []string
+			var upgradeErr error
+			g := &Generator{
+				templateEngine: engine,
+				installed:      make(map[string]*AppConfig),
+				onIconsReady: func(config *AppConfig, appDir string) error {
+					upgrades = append(upgrades, appDir)
+					os.RemoveAll(appDir)
+					return upgradeErr
+				},
+			}
+			if cached {
+				g.iconCache, _ = newTestIconCache(t, IconCacheOptions{MaxAge: time.Hour})
+			}
+			config := &AppConfig{
+				AppName:     "watchcow.app",
+				DisplayName: "App",
+				ContainerID: "abc123",
+				Entries:     []Entry{{Icon: srv.URL + "/icon.png"}},
+			}
+			g.MarkInstalled(config.ContainerID, config)
+
+			// The first check records the installed icons
+			g.RefreshIcons()
+			upgrades = nil
+
+			g.RefreshIcons()
+			if len(upgrades) != 0 {
+				t.Errorf("unchanged icon triggered %d upgrades", len(upgrades))
+			}
+
+			// A different file rendering to the same icons is not an upgrade
+			srv.data.Store(pngBytes(t, 32))
+			srv.etag.Store(`"v2"`)
+			g.RefreshIcons()
+			if len(upgrades) != 0 {
+				t.Errorf("identically rendered icon triggered %d upgrades", len(upgrades))
+			}
+
+			// Upstream logo changed (revalidation bypasses the cache max-age)
+			var red bytes.Buffer
+			png.Encode(&red, solidImage(16, 16, image.Rect(0, 0, 16, 16)))
+			srv.data.Store(red.Bytes())
+			srv.etag.Store(`"v3"`)
+			g.RefreshIcons()
+			if len(upgrades) != 1 {
+				t.Errorf("changed icon triggered %d upgrades, want 1", len(upgrades))
+			}
+
+			// A failed upgrade is retried at the next refresh
+			var half bytes.Buffer
+			png.Encode(&half, solidImage(16, 16, image.Rect(0, 0, 8, 16)))
+			srv.data.Store(half.Bytes())
+			srv.etag.Store(`"v4"`)
+			upgrades = nil
+			upgradeErr = errors.New("appcenter-cli failed")
+			g.RefreshIcons()
+			upgradeErr = nil
+			g.RefreshIcons()
+			g.RefreshIcons()
+			if len(upgrades) != 2 {
+				t.Errorf("failed upgrade triggered %d upgrades, want 2", len(upgrades))
+			}
+
+			// A failing source never replaces the icon
+			srv.status.Store(500)
+			upgrades = nil
+			g.RefreshIcons()
+			if len(upgrades) != 0 {
+				t.Errorf("failing source triggered %d upgrades", len(upgrades))
+			}
+
+			// Uninstalled apps are forgotten
+			g.MarkUninstalled(config.ContainerID)
+			if len(g.iconHashes) != 0 {
+				t.Error("icon hash kept after uninstall")
+			}
+		})
+	}
+}
+
+// TestGenerator_RefreshIcons_FailingBadge tests that a badge failing to load does not upgrade the app
+func TestGenerator_RefreshIcons_FailingBadge(t *testing.T) {
+	engine, err := NewTemplateEngine()
+	if err != nil {
+		t.Fatalf("NewTemplateEngine() error = %v", err)
+	}
+	var red bytes.Buffer
+	png.Encode(&red, solidImage(16, 16, image.Rect(0, 0, 16, 16)))
+	srv := newCacheTestServer(t, red.Bytes())
+	absPath, _ := filepath.Abs(filepath.Join("testdata", "test.png"))
+
+	var upgrades int
+	g := &Generator{
+		templateEngine: engine,
+		installed:      make(map[string]*AppConfig),
+		onIconsReady: func(config *AppConfig, appDir string) error {
+			upgrades++
+			os.RemoveAll(appDir)
+			return nil
+		},
+	}
+	config := &AppConfig{
+		AppName:     "watchcow.app",
+		DisplayName: "App",
+		ContainerID: "abc123",
+		Entries:     []Entry{{Name: "admin", Icon: "file://" + absPath, Badge: srv.URL + "/badge.png"}},
+	}
+	g.MarkInstalled(config.ContainerID, config)
+	g.RefreshIcons()
+	upgrades = 0
+
+	srv.status.Store(500)
+	g.RefreshIcons()
+	srv.status.Store(0)
+	g.RefreshIcons()
+	if upgrades != 0 {
+		t.Errorf("failing badge triggered %d upgrades", upgrades)
+	}
+}
+
+// TestGenerator_RefreshInstalledIcons tests refreshing the icons of an app installed by an earlier run
+func TestGenerator_RefreshInstalledIcons(t *testing.T) {
+	engine, err := NewTemplateEngine()
+	if err != nil {
+		t.Fatalf("NewTemplateEngine() error = %v", err)
+	}
+	srv := newCacheTestServer(t, pngBytes(t, 16))
+
+	var upgrades int
+	g := &Generator{
+		templateEngine: engine,
+		appsDir:        t.TempDir(),
+		installed:      make(map[string]*AppConfig),
+		onIconsReady: func(config *AppConfig, appDir string) error {
+			upgrades++
+			os.RemoveAll(appDir)
+			return nil
+		},
+	}
+	config := &AppConfig{
+		AppName:     "watchcow.app",
+		DisplayName: "App",
+		ContainerID: "abc123",
+		Entries:     []Entry{{Icon: srv.URL + "/icon.png"}},
+	}
+
+	// Install the package like App Center: its app directory becomes target
+	appDir, err := g.newPackageDir(config)
+	if err != nil {
+		t.Fatalf("newPackageDir() error = %v", err)
+	}
+	defer os.RemoveAll(appDir)
+	icons := g.newIconLoader(config).loadIcons(config)
+	icons.wait(0)
+	if _, err := writeIcons(appDir, config, icons); err != nil {
+		t.Fatalf("writeIcons() error = %v", err)
+	}
+	installed := filepath.Join(g.appsDir, config.AppName)
+	if err := os.CopyFS(installed, os.DirFS(appDir)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(installed, "app"), filepath.Join(installed, "target")); err != nil {
+		t.Fatal(err)
+	}
+
+	g.trackInstalled(config.ContainerID, config)
+	if !g.IsInstalled(config.ContainerID) {
+		t.Fatal("installed app not tracked")
+	}
+
+	g.RefreshIcons()
+	if upgrades != 0 {
+		t.Errorf("unchanged installed icon triggered %d upgrades", upgrades)
+	}
+
+	var red bytes.Buffer
+	png.Encode(&red, solidImage(16, 16, image.Rect(0, 0, 16, 16)))
+	srv.data.Store(red.Bytes())
+	g.RefreshIcons()
+	if upgrades != 1 {
+		t.Errorf("changed icon triggered %d upgrades, want 1", upgrades)
+	}
+
+	// Without an installed package the first refresh reinstalls the icons
+	g.trackInstalled("def456", &AppConfig{AppName: "watchcow.missing", ContainerID: "def456"})
+	if _, ok := g.iconHashes["def456"]; ok {
+		t.Error("missing installed package should not record an icon hash")
+	}
+}
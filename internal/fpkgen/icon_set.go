@@ -0,0 +1,193 @@
+package fpkgen
+
+import (
+	"fmt"
+	"image"
+	"strings"
+	"sync"
+	"time"
+)
+
+// iconJob is one icon source loading in the background
+type iconJob struct {
+	done   chan struct{} // Closed when img/err are set
+	img    image.Image
+	err    error
+	warned sync.Once // Logs a load failure once, not on every package generation
+}
+
+// iconSet loads the icons of all entries of an app concurrently.
+// Entries sharing a source load it only once.
+type iconSet struct {
+	loader  *iconLoader
+	config  *AppConfig
+	jobs    map[string]*iconJob // Icon source -> job
+	pending map[string]bool     // Sources replaced by placeholders because they were still loading
+}
+
+// loadIcons starts loading the icons of all entries of an app, including hand-tuned
+// sizes and badges that are icon sources
+func (l *iconLoader) loadIcons(config *AppConfig) *iconSet {
+	s := &iconSet{
+		loader:  l,
+		config:  config,
+		jobs:    make(map[string]*iconJob),
+		pending: make(map[string]bool),
+	}
+	for _, entry := range config.Entries {
+		s.start(entry.Icon)
+		for _, source := range entry.SizedIcons {
+			s.start(source)
+		}
+		if badge := strings.TrimSpace(entry.Badge); isIconSource(badge) {
+			s.start(badge)
+		}
+	}
+	return s
+}
+
+// start starts loading an icon source unless it is already loading
+func (s *iconSet) start(source string) {
+	if source == "" || source == fallbackIconURL || s.jobs[source] != nil {
+		return
+	}
+	job := &iconJob{done: make(chan struct{})}
+	s.jobs[source] = job
+	go func() {
+		defer close(job.done)
+		job.img, job.err = s.loader.load(source)
+	}()
+}
+
+// wait waits up to timeout (0 = no limit) for all icons and reports whether all have finished
+func (s *iconSet) wait(timeout time.Duration) bool {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	for _, job := range s.jobs {
+		select {
+		case <-job.done:
+		case <-expired:
+			return false
+		}
+	}
+	return true
+}
+
+// entryIcon returns the icon of an entry without blocking. Entries without a usable
+// icon get a letter avatar; the generic fallback icon is skipped so unlabeled apps
+// stay distinguishable. Icons still loading are replaced by their cached copy or a
+// letter avatar, and pending is set. Returns nil if no icon could be loaded or generated.
+func (s *iconSet) entryIcon(entry Entry) (img image.Image, pending bool) {
+	if img, pending = s.loaded(entry.Icon, entry.Name); img != nil {
+		return img, pending
+	}
+	return s.loader.generatedIcon(s.config, entry), pending
+}
+
+// sizedIcon returns the hand-tuned icon of an entry for one icon size without
+// blocking, or nil if there is none or it is not available (yet)
+func (s *iconSet) sizedIcon(entry Entry, size int) (img image.Image, pending bool) {
+	return s.loaded(entry.SizedIcons[size], entry.Name)
+}
+
+// badge returns the badge of an entry without blocking. Badge icons still loading
+// are left out and marked pending, so that the package is upgraded once they have
+// arrived. Returns nil if the entry has no badge or it could not be loaded or rendered.
+func (s *iconSet) badge(entry Entry) (img image.Image, pending bool) {
+	source := strings.TrimSpace(entry.Badge)
+	if source == "" {
+		return nil, false
+	}
+	if !isIconSource(source) {
+		img, err := s.loader.badgeImage(source, entry.BadgeColor, s.config.AppName+"."+entry.Name)
+		if err != nil {
+			fmt.Printf("Warning: Failed to render badge for entry '%s': %v\n", entry.Name, err)
+		}
+		return img, false
+	}
+
+	job := s.jobs[source]
+	if job == nil {
+		return nil, false
+	}
+	select {
+	case <-job.done:
+		if job.err != nil {
+			job.warned.Do(func() {
+				fmt.Printf("Warning: Failed to load badge icon for entry '%s': %v\n", entry.Name, job.err)
+			})
+			return nil, false
+		}
+		return squareImage(job.img), false
+	default:
+		s.pending[source] = true
+		return nil, true
+	}
+}
+
+// loaded returns the loaded icon of a source without blocking. Sources still loading
+// return their cached copy if any and are marked pending. Returns nil if the source
+// is not loading, failed or has no cached copy.
+func (s *iconSet) loaded(source, entryName string) (img image.Image, pending bool) {
+	job := s.jobs[source]
+	if job == nil {
+		return nil, false
+	}
+	select {
+	case <-job.done:
+		if job.err == nil {
+			return job.img, false
+		}
+		job.warned.Do(func() {
+			fmt.Printf("Warning: Failed to load icon for entry '%s': %v\n", entryName, job.err)
+		})
+		return nil, false
+	default:
+		s.pending[source] = true
+		return s.loader.cachedIcon(source), true
+	}
+}
+
+// upgraded reports whether any icon replaced by a placeholder has loaded since.
+// Call after wait(0).
+func (s *iconSet) upgraded() bool {
+	for source := range s.pending {
+		if s.jobs[source].err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// failed reports whether any icon source, including badge icons, failed to load.
+// Call after wait(0).
+func (s *iconSet) failed() bool {
+	for _, job := range s.jobs {
+		if job.err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// cachedIcon returns the cached copy of a remote icon regardless of its age,
+// without network access. Returns nil if it is not cached.
+func (l *iconLoader) cachedIcon(source string) image.Image {
+	if l.cache == nil || !strings.HasPrefix(source, "http") {
+		return nil
+	}
+	for _, u := range l.mirrors.resolve(source) {
+		data, ok := l.cache.peek(u.URL)
+		if !ok {
+			continue
+		}
+		if img, err := decodeIconData(data); err == nil {
+			return img
+		}
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+package fpkgen
+
+import (
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowIconServer serves a PNG icon once released and counts requests
+type slowIconServer struct {
+	*httptest.Server
+	release  chan struct{}
+	requests atomic.Int32
+}
+
+func newSlowIconServer(t *testing.T, size int) *slowIconServer {
+	t.Helper()
+	icon := pngBytes(t, size)
+	s := &slowIconServer{release: make(chan struct{})}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		select {
+		case <-s.release:
+		case <-r.Context().Done():
+			return
+		}
+		if r.URL.Path == "/missing.png" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(icon)
+	}))
+	t.Cleanup(func() {
+		select {
+		case <-s.release:
+		default:
+			close(s.release)
+		}
+		s.Close()
+	})
+	return s
+}
+
+// loadPNG decodes a PNG file
+func loadPNG(t *testing.T, path string) image.Image {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+// TestIconSet_SharedSources tests that entries sharing a source load it once
+func TestIconSet_SharedSources(t *testing.T) {
+	srv := newSlowIconServer(t, 48)
+	close(srv.release)
+
+	config := &AppConfig{
+		AppName:     "watchcow.app",
+		DisplayName: "App",
+		Entries: []Entry{
+			{Name: "", Icon: srv.URL + "/a.png"},
+			{Name: "admin", Icon: srv.URL + "/a.png"},
+			{Name: "logs", Icon: srv.URL + "/b.png"},
+			{Name: "plain", Icon: fallbackIconURL},
+		},
+	}
+	icons := (&iconLoader{}).loadIcons(config)
+	if !icons.wait(0) {
+		t.Fatal("wait(0) should wait for all icons")
+	}
+	if got := srv.requests.Load(); got != 2 {
+		t.Errorf("expected 2 downloads, got %d", got)
+	}
+
+	for _, entry := range config.Entries[:3] {
+		img, pending := icons.entryIcon(entry)
+		if pending || img == nil || img.Bounds().Dx() != 48 {
+			t.Errorf("entry %q: icon %v, pending %v", entry.Name, img, pending)
+		}
+	}
+	// The fallback icon is not downloaded but replaced by a letter avatar
+	if img, _ := icons.entryIcon(config.Entries[3]); img == nil || img.Bounds().Dx() != avatarSize {
+		t.Error("expected letter avatar for the fallback icon")
+	}
+}
+
+// TestIconSet_PendingBadge tests that slow badge icons do not block the package
+func TestIconSet_PendingBadge(t *testing.T) {
+	srv := newSlowIconServer(t, 48)
+	absPath, _ := filepath.Abs(filepath.Join("testdata", "test.png"))
+	config := &AppConfig{
+		AppName:     "watchcow.app",
+		DisplayName: "App",
+		Entries:     []Entry{{Name: "admin", Icon: "file://" + absPath, Badge: srv.URL + "/badge.png"}},
+	}
+	icons := (&iconLoader{}).loadIcons(config)
+
+	if icons.wait(20 * time.Millisecond) {
+		t.Fatal("wait() should time out while the badge server is blocked")
+	}
+	appDir := t.TempDir()
+	os.MkdirAll(filepath.Join(appDir, "app", "ui", "images"), 0755)
+	pending, err := writeIcons(appDir, config, icons)
+	if err != nil || !pending {
+		t.Fatalf("writeIcons() = %v, %v, want pending", pending, err)
+	}
+	if img, pending := icons.badge(config.Entries[0]); img != nil || !pending {
+		t.Errorf("loading badge = %v, pending %v, want none and pending", img, pending)
+	}
+
+	close(srv.release)
+	icons.wait(0)
+	if !icons.upgraded() {
+		t.Fatal("arrived badge should upgrade the package")
+	}
+	if img, pending := icons.badge(config.Entries[0]); img == nil || pending {
+		t.Errorf("arrived badge = %v, pending %v", img, pending)
+	}
+}
+
+// TestIconSet_Placeholders tests placeholders for slow icons and their replacement
+func TestIconSet_Placeholders(t *testing.T) {
+	srv := newSlowIconServer(t, 48)
+	config := &AppConfig{
+		AppName:     "watchcow.app",
+		DisplayName: "App",
+		Entries:     []Entry{{Icon: srv.URL + "/icon.png"}, {Name: "gone", Icon: srv.URL + "/missing.png"}},
+	}
+	icons := (&iconLoader{}).loadIcons(config)
+
+	if icons.wait(20 * time.Millisecond) {
+		t.Fatal("wait() should time out while the server is blocked")
+	}
+	img, pending := icons.entryIcon(config.Entries[0])
+	if !pending || img == nil || img.Bounds().Dx() != avatarSize {
+		t.Errorf("expected pending letter avatar, got %v, pending %v", img, pending)
+	}
+	icons.entryIcon(config.Entries[1])
+
+	close(srv.release)
+	icons.wait(0)
+	if !icons.upgraded() {
+		t.Error("upgraded() = false after the icon arrived")
+	}
+	if img, pending := icons.entryIcon(config.Entries[0]); pending || img.Bounds().Dx() != 48 {
+		t.Errorf("expected loaded icon, got %v, pending %v", img.Bounds(), pending)
+	}
+
+	// Only failed icons were pending: nothing to upgrade
+	icons = (&iconLoader{}).loadIcons(&AppConfig{Entries: config.Entries[1:]})
+	icons.pending[config.Entries[1].Icon] = true
+	icons.wait(0)
+	if icons.upgraded() {
+		t.Error("upgraded() = true although the icon failed")
+	}
+}
+
+// TestIconSet_CachedPlaceholder tests that stale cached icons are used while revalidating
+func TestIconSet_CachedPlaceholder(t *testing.T) {
+	srv := newSlowIconServer(t, 48)
+	cache, now := newTestIconCache(t, IconCacheOptions{MaxAge: time.Hour})
+	url := srv.URL + "/icon.png"
+
+	// Cached entry gone stale: revalidation blocks on the server
+	if err := cache.store(url, pngBytes(t, 48), http.Header{}); err != nil {
+		t.Fatalf("store() error = %v", err)
+	}
+	*now = now.Add(2 * time.Hour)
+
+	config := &AppConfig{AppName: "watchcow.app", DisplayName: "App", Entries: []Entry{{Icon: url}}}
+	icons := (&iconLoader{cache: cache}).loadIcons(config)
+	icons.wait(20 * time.Millisecond)
+
+	img, pending := icons.entryIcon(config.Entries[0])
+	if !pending || img == nil || img.Bounds().Dx() != 48 {
+		t.Errorf("expected pending cached icon, got %v, pending %v", img, pending)
+	}
+}
+
+// TestGenerator_FinishIcons tests regenerating the package once placeholder icons are replaced
+func TestGenerator_FinishIcons(t *testing.T) {
+	engine, err := NewTemplateEngine()
+	if err != nil {
+		t.Fatalf("NewTemplateEngine() error = %v", err)
+	}
+	ready := make(chan string, 1)
+	g := &Generator{
+		templateEngine: engine,
+		onIconsReady:   func(config *AppConfig, appDir string) error { ready <- appDir; return nil },
+	}
+
+	srv := newSlowIconServer(t, 48)
+	config := &AppConfig{
+		AppName:     "watchcow.app",
+		DisplayName: "App",
+		Port:        "8080",
+		Entries:     []Entry{{Name: "admin", Title: "Admin", Port: "8080", Icon: srv.URL + "/icon.png"}},
+	}
+	icons := g.newIconLoader(config).loadIcons(config)
+	icons.wait(10 * time.Millisecond)
+
+	appDir, err := g.newPackageDir(config)
+	if err != nil {
+		t.Fatalf("newPackageDir() error = %v", err)
+	}
+	defer os.RemoveAll(appDir)
+	if pending, err := writeIcons(appDir, config, icons); err != nil || !pending {
+		t.Fatalf("writeIcons() = %v, %v, want pending", pending, err)
+	}
+
+	go g.finishIcons(config, icons)
+	close(srv.release)
+
+	select {
+	case dir := <-ready:
+		defer os.RemoveAll(dir)
+		for _, name := range []string{"manifest", "ICON.PNG", "app/ui/images/icon_admin_256.png"} {
+			if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+				t.Errorf("regenerated package: %v", err)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnIconsReady was not called")
+	}
+}
+
+// TestHandleIcons_NoDefaultEntry tests that the root icons reuse the first entry's icon
+func TestHandleIcons_NoDefaultEntry(t *testing.T) {
+	srv := newSlowIconServer(t, 48)
+	close(srv.release)
+
+	appDir := t.TempDir()
+	os.MkdirAll(filepath.Join(appDir, "app", "ui", "images"), 0755)
+	config := &AppConfig{
+		AppName:     "watchcow.app",
+		DisplayName: "App",
+		Entries:     []Entry{{Name: "admin", Icon: srv.URL + "/icon.png", Badge: "A"}},
+	}
+	if err := (&Generator{}).handleIcons(appDir, config); err != nil {
+		t.Fatalf("handleIcons() error = %v", err)
+	}
+	if got := srv.requests.Load(); got != 1 {
+		t.Errorf("expected the icon to be downloaded once, got %d", got)
+	}
+
+	// Root icons are the unbadged entry icon
+	root := loadPNG(t, filepath.Join(appDir, "ICON_256.PNG"))
+	admin := loadPNG(t, filepath.Join(appDir, "app", "ui", "images", "icon_admin_256.png"))
+	if imagesEqual(root, admin) {
+		t.Error("root icon should not carry the entry badge")
+	}
+}
@@ -0,0 +1,197 @@
+package fpkgen
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// IconShape is the outline icons are masked to
+type IconShape string
+
+const (
+	IconShapeNone    IconShape = ""        // Keep the image outline (default)
+	IconShapeSquare  IconShape = "square"  // Full square canvas
+	IconShapeRounded IconShape = "rounded" // Rounded square like fnOS native icons
+	IconShapeCircle  IconShape = "circle"
+)
+
+const (
+	maxIconPadding    = 40     // Maximum padding per side (percent of the icon size)
+	iconTrimAlpha     = 8      // Pixels with lower alpha count as transparent border when trimming
+	iconRoundedRadius = 0.1875 // Corner radius of rounded icons (fraction of the icon size, 48px at 256px)
+)
+
+// IconStyle controls how entry icons are composed
+type IconStyle struct {
+	Background color.Color // Canvas fill (nil = transparent)
+	Padding    int         // Padding per side in percent of the icon size
+	Shape      IconShape
+	Trim       bool // Crop transparent borders before padding to square
+}
+
+// parseIconStyle reads the icon style labels of an entry:
+//
+//	watchcow.icon_bg      -> canvas color (#RGB, #RRGGBB, #RRGGBBAA, white, black, transparent)
+//	watchcow.icon_padding -> padding per side in percent (e.g. 12 or 12%)
+//	watchcow.icon_shape   -> rounded, circle or square
+//	watchcow.icon_trim    -> true to crop transparent borders
+//
+// Named entries use watchcow.<entry>.icon_* and fall back to the app labels.
+// Invalid values are logged and ignored.
+func parseIconStyle(labels map[string]string, entryName string) IconStyle {
+	get := func(field string) (string, string) {
+		if entryName != "" {
+			key := "watchcow." + entryName + "." + field
+			if val := getLabel(labels, key, ""); val != "" {
+				return key, val
+			}
+		}
+		key := "watchcow." + field
+		return key, getLabel(labels, key, "")
+	}
+
+	var style IconStyle
+
+	if key, val := get("icon_bg"); val != "" {
+		c, err := parseIconColor(val)
+		if err != nil {
+			slog.Warn("Ignoring invalid icon label", "label", key, "value", val, "error", err)
+		} else {
+			style.Background = c
+		}
+	}
+
+	if key, val := get("icon_padding"); val != "" {
+		padding, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(val), "%"))
+		if err != nil || padding < 0 || padding > maxIconPadding {
+			slog.Warn("Ignoring invalid icon label", "label", key, "value", val,
+				"error", fmt.Sprintf("expected a percentage between 0 and %d", maxIconPadding))
+		} else {
+			style.Padding = padding
+		}
+	}
+
+	if key, val := get("icon_shape"); val != "" {
+		switch shape := IconShape(strings.ToLower(strings.TrimSpace(val))); shape {
+		case IconShapeSquare, IconShapeRounded, IconShapeCircle:
+			style.Shape = shape
+		default:
+			slog.Warn("Ignoring invalid icon label", "label", key, "value", val,
+				"error", "expected rounded, circle or square")
+		}
+	}
+
+	if _, val := get("icon_trim"); val != "" {
+		style.Trim = val == "true"
+	}
+
+	return style
+}
+
+// parseIconColor parses #RGB, #RRGGBB, #RRGGBBAA and a few color names
+func parseIconColor(s string) (color.Color, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "white":
+		return color.White, nil
+	case "black":
+		return color.Black, nil
+	case "transparent", "none":
+		return nil, nil
+	}
+
+	hex, ok := strings.CutPrefix(s, "#")
+	if !ok {
+		return nil, fmt.Errorf("unsupported color %q (use #RRGGBB)", s)
+	}
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return nil, fmt.Errorf("unsupported color %q (use #RRGGBB)", s)
+	}
+
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported color %q (use #RRGGBB)", s)
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// styleIcon composes a size x size icon from a square image:
+// background fill, padding and shape mask
+func styleIcon(squared image.Image, size int, style IconStyle) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+
+	if style.Background != nil {
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(style.Background), image.Point{}, draw.Src)
+	}
+
+	pad := int(math.Round(float64(size*style.Padding) / 100))
+	inner := image.Rect(pad, pad, size-pad, size-pad)
+	xdraw.CatmullRom.Scale(dst, inner, squared, squared.Bounds(), xdraw.Over, nil)
+
+	var radius float64
+	switch style.Shape {
+	case IconShapeRounded:
+		radius = float64(size) * iconRoundedRadius
+	case IconShapeCircle:
+		radius = float64(size) / 2
+	default:
+		return dst
+	}
+
+	masked := image.NewRGBA(dst.Bounds())
+	draw.DrawMask(masked, masked.Bounds(), dst, image.Point{}, roundedMask(size, radius), image.Point{}, draw.Src)
+	return masked
+}
+
+// trimTransparent crops fully transparent borders (alpha below iconTrimAlpha)
+// Returns the image as-is if it has no such border or no visible pixel at all.
+func trimTransparent(src image.Image) image.Image {
+	b := src.Bounds()
+	var visible image.Rectangle
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, a := src.At(x, y).RGBA(); a>>8 >= iconTrimAlpha {
+				visible = visible.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	if visible.Empty() || visible == b {
+		return src
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, visible.Dx(), visible.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, visible.Min, draw.Src)
+	return dst
+}
+
+// roundedMask creates an anti-aliased rounded square mask (a circle if radius is size/2)
+func roundedMask(size int, radius float64) *image.Alpha {
+	mask := image.NewAlpha(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			// Distance from the pixel center to the nearest corner circle center
+			px, py := float64(x)+0.5, float64(y)+0.5
+			cx := math.Min(math.Max(px, radius), float64(size)-radius)
+			cy := math.Min(math.Max(py, radius), float64(size)-radius)
+			d := math.Hypot(px-cx, py-cy)
+
+			coverage := math.Min(math.Max(radius-d+0.5, 0), 1)
+			mask.SetAlpha(x, y, color.Alpha{A: uint8(coverage*0xFF + 0.5)})
+		}
+	}
+	return mask
+}
@@ -0,0 +1,220 @@
+package fpkgen
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/docker/go-connections/nat"
+)
+
+// alphaAt returns the 8-bit alpha of a pixel
+func alphaAt(img image.Image, x, y int) uint8 {
+	_, _, _, a := img.At(x, y).RGBA()
+	return uint8(a >> 8)
+}
+
+// solidImage creates a w x h image with an opaque red rect inside a transparent canvas
+func solidImage(w, h int, rect image.Rectangle) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, rect, image.NewUniform(color.RGBA{0xFF, 0, 0, 0xFF}), image.Point{}, draw.Src)
+	return img
+}
+
+// TestParseIconColor tests color label parsing
+func TestParseIconColor(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    color.Color
+		wantErr bool
+	}{
+		{"#1e88e5", color.NRGBA{0x1E, 0x88, 0xE5, 0xFF}, false},
+		{"#FFF", color.NRGBA{0xFF, 0xFF, 0xFF, 0xFF}, false},
+		{" #00000080 ", color.NRGBA{0, 0, 0, 0x80}, false},
+		{"white", color.White, false},
+		{"Black", color.Black, false},
+		{"transparent", nil, false},
+		{"1e88e5", nil, true},
+		{"#12345", nil, true},
+		{"#gggggg", nil, true},
+		{"red", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseIconColor(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseIconColor(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseIconColor(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+// TestParseIconStyle tests app-level labels, entry overrides and invalid values
+func TestParseIconStyle(t *testing.T) {
+	labels := map[string]string{
+		"watchcow.icon_bg":            "#ffffff",
+		"watchcow.icon_padding":       "12%",
+		"watchcow.icon_shape":         "Rounded",
+		"watchcow.icon_trim":          "true",
+		"watchcow.admin.icon_bg":      "transparent",
+		"watchcow.admin.icon_shape":   "circle",
+		"watchcow.admin.icon_trim":    "false",
+		"watchcow.bad.icon_padding":   "80",
+		"watchcow.bad.icon_shape":     "hexagon",
+		"watchcow.bad.icon_bg":        "blue",
+		"watchcow.plain.service_port": "8080",
+	}
+
+	app := parseIconStyle(labels, "")
+	want := IconStyle{Background: color.NRGBA{0xFF, 0xFF, 0xFF, 0xFF}, Padding: 12, Shape: IconShapeRounded, Trim: true}
+	if app != want {
+		t.Errorf("app style = %+v, want %+v", app, want)
+	}
+
+	// Entry labels override the app labels
+	admin := parseIconStyle(labels, "admin")
+	want = IconStyle{Background: nil, Padding: 12, Shape: IconShapeCircle, Trim: false}
+	if admin != want {
+		t.Errorf("admin style = %+v, want %+v", admin, want)
+	}
+
+	// Invalid entry labels are ignored, not inherited
+	bad := parseIconStyle(labels, "bad")
+	want = IconStyle{Padding: 0, Shape: IconShapeNone, Trim: true}
+	if bad != want {
+		t.Errorf("bad style = %+v, want %+v", bad, want)
+	}
+
+	if got := parseIconStyle(nil, ""); got != (IconStyle{}) {
+		t.Errorf("no labels style = %+v", got)
+	}
+}
+
+// TestPrepareIcons_DefaultStyle tests that the default style keeps the previous behavior
+func TestPrepareIcons_DefaultStyle(t *testing.T) {
+	src := solidImage(100, 50, image.Rect(0, 0, 100, 50))
+	icon64, icon256 := prepareIcon(src, 64, IconStyle{}), prepareIcon(src, 256, IconStyle{})
+
+	if icon64.Bounds().Dx() != 64 || icon256.Bounds().Dx() != 256 {
+		t.Fatalf("unexpected sizes %v, %v", icon64.Bounds(), icon256.Bounds())
+	}
+	// Letterboxed on a transparent canvas
+	if alphaAt(icon256, 128, 10) != 0 || alphaAt(icon256, 128, 128) != 0xFF {
+		t.Error("expected transparent padding above a centered wide image")
+	}
+}
+
+// TestPrepareIcons_Styled tests background, padding, shapes and trimming
+func TestPrepareIcons_Styled(t *testing.T) {
+	white := color.NRGBA{0xFF, 0xFF, 0xFF, 0xFF}
+
+	t.Run("background and padding", func(t *testing.T) {
+		src := solidImage(32, 32, image.Rect(0, 0, 32, 32))
+		icon := prepareIcon(src, 256, IconStyle{Background: white, Padding: 25})
+
+		if got := color.RGBAModel.Convert(icon.At(10, 128)); got != (color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}) {
+			t.Errorf("padding pixel = %v, want white", got)
+		}
+		if got := color.RGBAModel.Convert(icon.At(128, 128)); got != (color.RGBA{0xFF, 0, 0, 0xFF}) {
+			t.Errorf("center pixel = %v, want red", got)
+		}
+	})
+
+	t.Run("shapes", func(t *testing.T) {
+		src := solidImage(32, 32, image.Rect(0, 0, 32, 32))
+		for _, tt := range []struct {
+			shape  IconShape
+			corner uint8
+		}{
+			{IconShapeSquare, 0xFF},
+			{IconShapeRounded, 0},
+			{IconShapeCircle, 0},
+		} {
+			icon64, icon256 := prepareIcon(src, 64, IconStyle{Shape: tt.shape}), prepareIcon(src, 256, IconStyle{Shape: tt.shape})
+			for _, icon := range []image.Image{icon64, icon256} {
+				size := icon.Bounds().Dx()
+				if got := alphaAt(icon, 0, 0); got != tt.corner {
+					t.Errorf("%s %dpx corner alpha = %d, want %d", tt.shape, size, got, tt.corner)
+				}
+				// Anti-aliased edges may be slightly translucent
+				if got := alphaAt(icon, size/2, 0); got < 0xF0 {
+					t.Errorf("%s %dpx top edge alpha = %d, want opaque", tt.shape, size, got)
+				}
+			}
+		}
+
+		// Circle cuts more than the rounded corner
+		rounded := prepareIcon(src, 256, IconStyle{Shape: IconShapeRounded})
+		circle := prepareIcon(src, 256, IconStyle{Shape: IconShapeCircle})
+		if alphaAt(rounded, 30, 30) != 0xFF || alphaAt(circle, 30, 30) != 0 {
+			t.Error("expected rounded corner to keep (30,30) and circle to cut it")
+		}
+	})
+
+	t.Run("trim", func(t *testing.T) {
+		// Wide logo with a large transparent border
+		src := solidImage(200, 200, image.Rect(50, 90, 150, 110))
+		plain := prepareIcon(src, 256, IconStyle{})
+		trimmed := prepareIcon(src, 256, IconStyle{Trim: true})
+
+		if alphaAt(plain, 10, 128) != 0 {
+			t.Error("untrimmed logo should not reach the edge")
+		}
+		if alphaAt(trimmed, 10, 128) != 0xFF {
+			t.Error("trimmed logo should fill the icon width")
+		}
+	})
+}
+
+// TestTrimTransparent tests cropping of transparent borders
+func TestTrimTransparent(t *testing.T) {
+	src := solidImage(100, 100, image.Rect(10, 20, 60, 30))
+	src.Set(0, 0, color.RGBA{0, 0, 0, 2}) // Nearly invisible noise is trimmed too
+
+	if got := trimTransparent(src).Bounds(); got != image.Rect(0, 0, 50, 10) {
+		t.Errorf("trimTransparent() bounds = %v, want 50x10", got)
+	}
+
+	empty := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	if trimTransparent(empty) != image.Image(empty) {
+		t.Error("fully transparent image should be returned as-is")
+	}
+	full := solidImage(10, 10, image.Rect(0, 0, 10, 10))
+	if trimTransparent(full) != image.Image(full) {
+		t.Error("image without border should be returned as-is")
+	}
+}
+
+// TestExtractConfig_IconStyle tests that every entry gets its icon style
+func TestExtractConfig_IconStyle(t *testing.T) {
+	g := &Generator{}
+	container := newTestContainer("myapp:latest", map[string]string{
+		"watchcow.enable":               "true",
+		"watchcow.service_port":         "8080",
+		"watchcow.icon_shape":           "rounded",
+		"watchcow.admin.service_port":   "8081",
+		"watchcow.admin.icon_shape":     "circle",
+		"watchcow.admin.icon_padding":   "10",
+		"watchcow.preview.icon_padding": "5",
+	}, nat.PortMap{})
+	config := g.extractConfig(container, nil)
+
+	styles := make(map[string]IconStyle)
+	for _, entry := range config.Entries {
+		styles[entry.Name] = entry.IconStyle
+	}
+
+	if styles[""].Shape != IconShapeRounded {
+		t.Errorf("default entry style = %+v", styles[""])
+	}
+	if styles["admin"].Shape != IconShapeCircle || styles["admin"].Padding != 10 {
+		t.Errorf("admin entry style = %+v", styles["admin"])
+	}
+	if styles["preview"].Shape != IconShapeRounded || styles["preview"].Padding != 5 {
+		t.Errorf("preview entry style = %+v", styles["preview"])
+	}
+}
@@ -0,0 +1,65 @@
+package fpkgen
+
+import (
+	"image"
+	"sort"
+)
+
+// iconVariant is one sub-image of a multi-resolution icon file (ICO, ICNS)
+type iconVariant struct {
+	size   int                         // Edge length (larger dimension)
+	decode func() (image.Image, error) // Decodes the sub-image on demand
+}
+
+// multiSizeIcon is a decoded multi-resolution icon file. It draws as its largest
+// image; forSize picks the sub-image closest to an output size instead.
+type multiSizeIcon struct {
+	image.Image
+	variants []iconVariant // Sorted by size, at most one per size
+}
+
+// newMultiSizeIcon wraps the largest image of an icon file with its other
+// sub-images. Returns largest as is if there are none.
+func newMultiSizeIcon(largest image.Image, variants []iconVariant) image.Image {
+	sort.SliceStable(variants, func(i, j int) bool { return variants[i].size < variants[j].size })
+
+	// Sub-images of the same size are redundant; keep the first one like the decoders do
+	unique := variants[:0]
+	for _, v := range variants {
+		if len(unique) == 0 || unique[len(unique)-1].size != v.size {
+			unique = append(unique, v)
+		}
+	}
+	if len(unique) <= 1 {
+		return largest
+	}
+	return &multiSizeIcon{Image: largest, variants: unique}
+}
+
+// forSize returns the smallest sub-image at least size pixels large, which
+// downscales best, or the largest image. Sub-images failing to decode are skipped.
+func (m *multiSizeIcon) forSize(size int) image.Image {
+	b := m.Bounds()
+	largest := max(b.Dx(), b.Dy())
+	for _, v := range m.variants {
+		if v.size < size {
+			continue
+		}
+		if v.size >= largest {
+			break
+		}
+		if img, err := v.decode(); err == nil {
+			return img
+		}
+	}
+	return m.Image
+}
+
+// iconForSize returns the image to render at size: the closest sub-image of
+// multi-resolution icons, other images as is
+func iconForSize(img image.Image, size int) image.Image {
+	if m, ok := img.(*multiSizeIcon); ok {
+		return m.forSize(size)
+	}
+	return img
+}
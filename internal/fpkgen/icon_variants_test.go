@@ -0,0 +1,175 @@
+package fpkgen
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"testing/quick"
+)
+
+// TestMultiSizeIcon_ForSize tests picking the closest ICO and ICNS sub-image per output size
+func TestMultiSizeIcon_ForSize(t *testing.T) {
+	ico, _ := generateICOWithPNGImages([]int{16, 256, 48, 128})
+	icns, _ := generateICNSWithPNGImages([]int{16, 256, 48, 128})
+
+	for name, data := range map[string][]byte{"ico": ico, "icns": icns} {
+		t.Run(name, func(t *testing.T) {
+			img, err := decodeIconData(data)
+			if err != nil {
+				t.Fatalf("decodeIconData() error = %v", err)
+			}
+			if img.Bounds().Dx() != 256 {
+				t.Errorf("icon draws as %dpx, want the largest image", img.Bounds().Dx())
+			}
+
+			for size, want := range map[int]int{1: 16, 16: 16, 32: 48, 64: 128, 128: 128, 256: 256, 512: 256} {
+				if got := iconForSize(img, size).Bounds().Dx(); got != want {
+					t.Errorf("iconForSize(%d) = %dpx, want %dpx", size, got, want)
+				}
+			}
+		})
+	}
+
+	// Single-image files are not wrapped
+	single, _ := generateICOWithPNGImages([]int{48})
+	if img, _ := decodeIconData(single); img == nil || iconForSize(img, 16) != img {
+		t.Error("single-image ICO should decode to a plain image")
+	}
+}
+
+// TestProperty_MultiSizeIconForSize tests that forSize returns the smallest sub-image
+// not smaller than the requested size, or the largest one
+func TestProperty_MultiSizeIconForSize(t *testing.T) {
+	f := func(rawSizes []uint8, rawTarget uint16) bool {
+		var sizes []int
+		seen := make(map[int]bool)
+		for _, s := range rawSizes {
+			size := int(s)%64 + 1
+			if !seen[size] && len(sizes) < 8 {
+				seen[size] = true
+				sizes = append(sizes, size)
+			}
+		}
+		if len(sizes) == 0 {
+			return true
+		}
+		target := int(rawTarget)%80 + 1
+
+		data, largest := generateICOWithPNGImages(sizes)
+		img, err := decodeIconData(data)
+		if err != nil {
+			t.Logf("decodeIconData failed: %v", err)
+			return false
+		}
+
+		sort.Ints(sizes)
+		want := largest
+		for _, size := range sizes {
+			if size >= target {
+				want = size
+				break
+			}
+		}
+		return iconForSize(img, target).Bounds().Dx() == want
+	}
+
+	if err := quick.Check(f, &quick.Config{MaxCount: 100}); err != nil {
+		t.Errorf("Property failed: %v", err)
+	}
+}
+
+// TestParseSizedIcons tests app-level and entry hand-tuned icon labels
+func TestParseSizedIcons(t *testing.T) {
+	labels := map[string]string{
+		"watchcow.icon":            "https://example.com/app.png",
+		"watchcow.icon_64":         "https://example.com/app-64.png",
+		"watchcow.admin.icon_256":  "https://example.com/admin-256.png",
+		"watchcow.logs.icon":       "https://example.com/logs.png",
+		"watchcow.icon_128":        "https://example.com/unsupported.png",
+		"watchcow.other.icon_64":   "",
+		"watchcow.unrelated_label": "x",
+	}
+	appIcon := labels["watchcow.icon"]
+
+	tests := []struct {
+		entry Entry
+		want  map[int]string
+	}{
+		{Entry{Icon: appIcon}, map[int]string{64: "https://example.com/app-64.png"}},
+		{Entry{Name: "admin", Icon: appIcon}, map[int]string{
+			64:  "https://example.com/app-64.png",
+			256: "https://example.com/admin-256.png",
+		}},
+		// Entries with their own icon do not use the app's hand-tuned icons
+		{Entry{Name: "logs", Icon: labels["watchcow.logs.icon"]}, nil},
+	}
+	for _, tt := range tests {
+		got := parseSizedIcons(labels, tt.entry, appIcon)
+		if len(got) != len(tt.want) {
+			t.Errorf("entry %q: got %v, want %v", tt.entry.Name, got, tt.want)
+			continue
+		}
+		for size, source := range tt.want {
+			if got[size] != source {
+				t.Errorf("entry %q size %d: got %q, want %q", tt.entry.Name, size, got[size], source)
+			}
+		}
+	}
+
+	// Per-size labels alone define a named entry
+	if !isEntryField("icon_64") || !isEntryField("icon_256") || isEntryField("icon_128") {
+		t.Error("isEntryField() should accept exactly the supported icon sizes")
+	}
+}
+
+// TestWriteIcons_SizedIcons tests that hand-tuned icons replace the downscaled icon of their size
+func TestWriteIcons_SizedIcons(t *testing.T) {
+	dir := t.TempDir()
+	writePNG := func(name string, size int, c color.RGBA) string {
+		img := image.NewRGBA(image.Rect(0, 0, size, size))
+		for i := range img.Pix {
+			img.Pix[i] = [4]uint8{c.R, c.G, c.B, c.A}[i%4]
+		}
+		var buf bytes.Buffer
+		png.Encode(&buf, img)
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return "file://" + path
+	}
+	red := color.RGBA{R: 0xFF, A: 0xFF}
+	blue := color.RGBA{B: 0xFF, A: 0xFF}
+
+	appDir := filepath.Join(dir, "app")
+	os.MkdirAll(filepath.Join(appDir, "app", "ui", "images"), 0755)
+	config := &AppConfig{
+		AppName:     "watchcow.app",
+		DisplayName: "App",
+		Entries: []Entry{{
+			Icon:       writePNG("icon.png", 512, red),
+			SizedIcons: map[int]string{64: writePNG("icon-64.png", 64, blue)},
+		}},
+	}
+	if err := (&Generator{}).handleIcons(appDir, config); err != nil {
+		t.Fatalf("handleIcons() error = %v", err)
+	}
+
+	for name, want := range map[string]color.RGBA{
+		"ICON.PNG":                   blue,
+		"ICON_256.PNG":               red,
+		"app/ui/images/icon_64.png":  blue,
+		"app/ui/images/icon_256.png": red,
+	} {
+		img := loadPNG(t, filepath.Join(appDir, name))
+		r, g, b, a := img.At(img.Bounds().Dx()/2, img.Bounds().Dy()/2).RGBA()
+		if got := (color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)}); got != want {
+			t.Errorf("%s: center pixel %v, want %v", name, got, want)
+		}
+	}
+}
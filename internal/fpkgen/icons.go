@@ -2,16 +2,14 @@ package fpkgen
 
 import (
 	"bytes"
+	"context"
 	"embed"
 	"fmt"
 	"image"
 	"image/png"
-	"io"
-	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
 	xdraw "golang.org/x/image/draw"
 
@@ -19,101 +17,144 @@ import (
 	_ "image/jpeg"
 
 	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
 	_ "golang.org/x/image/webp"
 )
 
 //go:embed defaults/ICON.PNG defaults/ICON_256.PNG
 var defaultIcons embed.FS
 
-// handleIcons downloads/generates and saves all required icon files for all entries
+// maxIconFileSize limits the size of downloaded icon files
+const maxIconFileSize = 10 << 20
+
+// handleIcons loads/generates and saves all required icon files for all entries,
+// waiting for all icons to load
 func (g *Generator) handleIcons(appDir string, config *AppConfig) error {
+	// Resolves relative file:// paths and container:// paths of this app
+	icons := g.newIconLoader(config).loadIcons(config)
+	icons.wait(0)
+
+	_, err := writeIcons(appDir, config, icons)
+	return err
+}
+
+// iconSize is one size of the icon files of a package
+type iconSize struct {
+	px   int    // Width and height in pixels
+	root string // File name of the app icon in the package root
+}
+
+// iconSizes lists the icon sizes written for each entry, as icon_<px>.png (default
+// entry) or icon_<name>_<px>.png (named entry) in app/ui/images
+var iconSizes = []iconSize{
+	{px: 64, root: "ICON.PNG"},
+	{px: 256, root: "ICON_256.PNG"},
+}
+
+// label returns the label field of hand-tuned icons of this size (e.g. icon_64)
+func (s iconSize) label() string {
+	return fmt.Sprintf("icon_%d", s.px)
+}
+
+// fileName returns the file name of the icon of an entry in app/ui/images
+func (s iconSize) fileName(entryName string) string {
+	if entryName == "" {
+		return fmt.Sprintf("icon_%d.png", s.px)
+	}
+	return fmt.Sprintf("icon_%s_%d.png", entryName, s.px)
+}
+
+// parseSizedIcons reads the hand-tuned icon sources of an entry per icon size:
+//
+//	watchcow.icon_64  -> icon source used for the 64x64 icons instead of watchcow.icon
+//	watchcow.icon_256 -> icon source used for the 256x256 icons
+//
+// Named entries use watchcow.<entry>.icon_<size> and fall back to the app labels
+// as long as they show the app icon (appIcon).
+func parseSizedIcons(labels map[string]string, entry Entry, appIcon string) map[int]string {
+	var sources map[int]string
+	for _, size := range iconSizes {
+		source := ""
+		if entry.Name != "" {
+			source = getLabel(labels, "watchcow."+entry.Name+"."+size.label(), "")
+		}
+		if source == "" && (entry.Name == "" || entry.Icon == appIcon) {
+			source = getLabel(labels, "watchcow."+size.label(), "")
+		}
+		if source != "" {
+			if sources == nil {
+				sources = make(map[int]string)
+			}
+			sources[size.px] = source
+		}
+	}
+	return sources
+}
+
+// writeIcons saves the icon files of all entries.
+// Icons still loading are replaced by placeholders; pending reports whether any were.
+func writeIcons(appDir string, config *AppConfig, icons *iconSet) (pending bool, err error) {
 	var defaultIcon image.Image
+	uiImagesDir := filepath.Join(appDir, "app", "ui", "images")
 
-	// Get base path from container labels for resolving relative file:// paths
-	basePath := getBasePath(config.Labels)
+	// The root icons are the default entry's, or the first entry's without badge
+	rootEntry := 0
+	for i, entry := range config.Entries {
+		if entry.Name == "" {
+			rootEntry = i
+			break
+		}
+	}
 
 	// Process each entry's icon
-	for _, entry := range config.Entries {
-		entryIcon, err := loadIconFromSource(entry.Icon, basePath)
-		if err != nil {
-			fmt.Printf("Warning: Failed to load icon for entry '%s': %v\n", entry.Name, err)
-		}
+	for i, entry := range config.Entries {
+		entryIcon, entryPending := icons.entryIcon(entry)
+		pending = pending || entryPending
 
-		// Use default icon if loading failed
+		// Use default icon if no icon could be loaded or generated
 		if entryIcon == nil {
 			if defaultIcon == nil {
 				defaultIcon, err = loadDefaultIcon()
 				if err != nil {
-					return fmt.Errorf("failed to load default icon: %w", err)
+					return pending, fmt.Errorf("failed to load default icon: %w", err)
 				}
 			}
 			entryIcon = defaultIcon
 		}
 
-		// Pad to square and resize to required sizes
-		icon64, icon256 := prepareIcons(entryIcon)
+		badge, badgePending := icons.badge(entry)
+		pending = pending || badgePending
 
-		// Generate icon filenames based on entry name
-		// Default entry: icon_64.png, icon_256.png
-		// Named entry: icon_<name>_64.png, icon_<name>_256.png
-		var icon64Name, icon256Name string
-		if entry.Name == "" {
-			icon64Name = "icon_64.png"
-			icon256Name = "icon_256.png"
-		} else {
-			icon64Name = fmt.Sprintf("icon_%s_64.png", entry.Name)
-			icon256Name = fmt.Sprintf("icon_%s_256.png", entry.Name)
-		}
-
-		// Save to ui/images directory
-		uiImagesDir := filepath.Join(appDir, "app", "ui", "images")
-		if err := saveImage(icon64, filepath.Join(uiImagesDir, icon64Name)); err != nil {
-			return fmt.Errorf("failed to save icon %s: %w", icon64Name, err)
-		}
-		if err := saveImage(icon256, filepath.Join(uiImagesDir, icon256Name)); err != nil {
-			return fmt.Errorf("failed to save icon %s: %w", icon256Name, err)
-		}
-
-		// For default entry, also save to root directory as ICON.PNG and ICON_256.PNG
-		if entry.Name == "" {
-			if err := saveImage(icon64, filepath.Join(appDir, "ICON.PNG")); err != nil {
-				return fmt.Errorf("failed to save ICON.PNG: %w", err)
+		for _, size := range iconSizes {
+			// Hand-tuned icons of this size replace the downscaled entry icon
+			src := entryIcon
+			sizeIcon, sizePending := icons.sizedIcon(entry, size.px)
+			pending = pending || sizePending
+			if sizeIcon != nil {
+				src = sizeIcon
 			}
-			if err := saveImage(icon256, filepath.Join(appDir, "ICON_256.PNG")); err != nil {
-				return fmt.Errorf("failed to save ICON_256.PNG: %w", err)
-			}
-		}
-	}
 
-	// If no entries had a default entry, use first entry's icon for root icons
-	if len(config.Entries) > 0 {
-		hasDefaultEntry := false
-		for _, entry := range config.Entries {
-			if entry.Name == "" {
-				hasDefaultEntry = true
-				break
+			// Pad to square and resize in the entry's icon style
+			icon := prepareIcon(src, size.px, entry.IconStyle)
+			if i == rootEntry {
+				if err := saveImage(icon, filepath.Join(appDir, size.root)); err != nil {
+					return pending, fmt.Errorf("failed to save %s: %w", size.root, err)
+				}
 			}
-		}
 
-		if !hasDefaultEntry {
-			// Use first entry's icon for root icons
-			firstEntry := config.Entries[0]
-			entryIcon, _ := loadIconFromSource(firstEntry.Icon, basePath)
-			if entryIcon == nil {
-				if defaultIcon == nil {
-					defaultIcon, _ = loadDefaultIcon()
-				}
-				entryIcon = defaultIcon
+			// Composite the entry badge over the styled icon
+			if badge != nil {
+				icon = applyBadge(icon, badge)
 			}
-			if entryIcon != nil {
-				icon64, icon256 := prepareIcons(entryIcon)
-				saveImage(icon64, filepath.Join(appDir, "ICON.PNG"))
-				saveImage(icon256, filepath.Join(appDir, "ICON_256.PNG"))
+
+			name := size.fileName(entry.Name)
+			if err := saveImage(icon, filepath.Join(uiImagesDir, name)); err != nil {
+				return pending, fmt.Errorf("failed to save icon %s: %w", name, err)
 			}
 		}
 	}
 
-	return nil
+	return pending, nil
 }
 
 // getBasePath extracts the compose working directory from container labels
@@ -148,118 +189,205 @@ func resolveFilePath(fileURL string, basePath string) (string, error) {
 	return filepath.Join(basePath, path), nil
 }
 
-// loadIconFromSource loads an icon from URL or local file path
+// iconLoader loads the icons of one app
+type iconLoader struct {
+	basePath    string              // Base directory for relative file:// paths (compose working directory)
+	containerID string              // Container for container:// paths
+	copier      containerFileCopier // Copies files out of the container (nil if unavailable)
+	cache       *IconCache          // Cache for downloaded icons (nil if disabled)
+	mirrors     *iconMirrors        // Mirrors of dashboard-icons URLs (nil downloads URLs as is)
+	fonts       *iconFonts          // Fonts of generated icons (nil uses the embedded font only)
+	appName     string              // Background color key of generated icons
+	roots       *fileRoots          // Allowed directories of file:// paths (nil allows any path)
+	revalidate  bool                // Revalidate cached icons even if fresh
+}
+
+// newIconLoader creates an icon loader for an app
+func (g *Generator) newIconLoader(config *AppConfig) *iconLoader {
+	loader := &iconLoader{
+		basePath:    getBasePath(config.Labels),
+		containerID: config.ContainerID,
+		cache:       g.iconCache,
+		mirrors:     g.iconMirrors,
+		fonts:       g.iconFonts,
+		appName:     config.AppName,
+		roots:       g.fileRoots,
+	}
+	if g.dockerClient != nil {
+		loader.copier = g.dockerClient
+	}
+	return loader
+}
+
+// generatedIcon renders the letter avatar of an entry from the app display name,
+// used if no icon is configured or it cannot be loaded.
+// Returns nil if no letters can be rendered.
+func (l *iconLoader) generatedIcon(config *AppConfig, entry Entry) image.Image {
+	// Named entries get their own color
+	key := config.AppName
+	if entry.Name != "" {
+		key += "." + entry.Name
+	}
+	img, err := l.fonts.letterAvatar(config.DisplayName, key)
+	if err != nil {
+		fmt.Printf("Warning: Failed to generate icon for entry '%s': %v\n", entry.Name, err)
+		return nil
+	}
+	return img
+}
+
+// loadIconFromSource loads an icon from URL, local file path or data URI
 // basePath: the base directory for resolving relative file:// paths (compose working directory)
 func loadIconFromSource(iconSource string, basePath string) (image.Image, error) {
+	return (&iconLoader{basePath: basePath}).load(iconSource)
+}
+
+// load loads an icon from URL, local file path, container file path, data URI or emoji
+func (l *iconLoader) load(iconSource string) (image.Image, error) {
 	if iconSource == "" {
 		return nil, fmt.Errorf("empty icon source")
 	}
 
 	if strings.HasPrefix(iconSource, "file://") {
-		// Resolve file:// path (supports both absolute and relative paths)
-		localPath, err := resolveFilePath(iconSource, basePath)
+		// Resolve file:// path (supports both absolute and relative paths) within the allowed roots
+		localPath, err := l.roots.resolve(iconSource, l.basePath)
 		if err != nil {
 			return nil, fmt.Errorf("failed to resolve file path: %w", err)
 		}
 		return loadLocalIcon(localPath)
+	} else if strings.HasPrefix(iconSource, "data:") {
+		// Icon embedded in the label
+		data, err := decodeDataURI(iconSource)
+		if err != nil {
+			return nil, err
+		}
+		return decodeIconData(data)
+	} else if strings.HasPrefix(iconSource, "emoji:") {
+		// Render the emoji with the icon fonts
+		return l.fonts.emojiIcon(iconSource, l.appName)
+	} else if strings.HasPrefix(iconSource, "container://") {
+		// Copy from the container filesystem
+		return l.loadContainerIcon(iconSource)
 	} else if strings.HasPrefix(iconSource, "http") {
-		// Download from URL
-		return downloadIcon(iconSource)
+		// Download from URL (through the cache if enabled)
+		return l.loadRemoteIcon(iconSource)
 	}
 
 	return nil, fmt.Errorf("unsupported icon source: %s", iconSource)
 }
 
-// loadDefaultIcon loads the embedded default icon
-func loadDefaultIcon() (image.Image, error) {
-	data, err := defaultIcons.ReadFile("defaults/ICON_256.PNG")
+// loadRemoteIcon downloads an icon URL, trying each mirror for dashboard-icons URLs
+func (l *iconLoader) loadRemoteIcon(url string) (image.Image, error) {
+	data, err := l.mirrors.fetchMirrored(url, l.fetch)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to download icon: %w", err)
 	}
 
-	img, _, err := image.Decode(bytes.NewReader(data))
-	if err != nil {
-		return nil, err
-	}
+	return decodeIconData(data)
+}
 
-	return img, nil
+// fetch downloads one icon URL, through the icon cache if enabled
+func (l *iconLoader) fetch(ctx context.Context, url string) ([]byte, error) {
+	if l.cache != nil {
+		return l.cache.fetch(ctx, url, l.revalidate)
+	}
+	data, _, err := fetchLimited(ctx, url, maxIconFileSize)
+	return data, err
 }
 
-// loadLocalIcon loads an icon from local file path
-// Supports multiple formats: PNG, JPEG, WebP, BMP, ICO
-func loadLocalIcon(path string) (image.Image, error) {
-	data, err := os.ReadFile(path)
+// loadContainerIcon loads a container:// icon from the app's container
+func (l *iconLoader) loadContainerIcon(iconSource string) (image.Image, error) {
+	containerPath, err := parseContainerPath(iconSource)
 	if err != nil {
-		return nil, fmt.Errorf("file not found: %s", path)
+		return nil, err
 	}
-
-	// Detect format using magic bytes
-	format := detectFormat(data)
-
-	// For ICO format, use custom decoder
-	if format == FormatICO {
-		img, err := decodeICO(data)
-		if err != nil {
-			return nil, fmt.Errorf("failed to decode ICO image: %w", err)
-		}
-		return img, nil
+	if l.copier == nil || l.containerID == "" {
+		return nil, fmt.Errorf("container:// icons require a container: %s", iconSource)
 	}
 
-	// For other formats (PNG, JPEG, WebP, BMP), use standard image.Decode
-	// The decoders are registered via imports at the top of this file
-	if format == FormatUnknown {
-		return nil, fmt.Errorf("unsupported image format: detected %s", format)
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), containerIconTimeout)
+	defer cancel()
 
-	img, _, err := image.Decode(bytes.NewReader(data))
+	data, err := readContainerFile(ctx, l.copier, l.containerID, containerPath, maxIconFileSize)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode %s image: %w", format, err)
+		return nil, err
 	}
 
-	return img, nil
+	return decodeIconData(data)
 }
 
-// downloadIcon downloads an icon from URL
-// Supports multiple formats: PNG, JPEG, WebP, BMP, ICO
-func downloadIcon(url string) (image.Image, error) {
-	client := &http.Client{
-		Timeout: 60 * time.Second,
+// loadDefaultIcon loads the embedded default icon
+func loadDefaultIcon() (image.Image, error) {
+	data, err := defaultIcons.ReadFile("defaults/ICON_256.PNG")
+	if err != nil {
+		return nil, err
 	}
 
-	resp, err := client.Get(url)
+	img, _, err := image.Decode(bytes.NewReader(data))
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to download icon: status %d", resp.StatusCode)
-	}
+	return img, nil
+}
 
-	// Read the body
-	body, err := io.ReadAll(resp.Body)
+// loadLocalIcon loads an icon from local file path
+// Supports multiple formats: PNG, JPEG, WebP, BMP, ICO, SVG, GIF, TIFF, ICNS
+func loadLocalIcon(path string) (image.Image, error) {
+	data, err := readIconFile(path)
 	if err != nil {
 		return nil, err
 	}
 
+	return decodeIconData(data)
+}
+
+// decodeIconData decodes icon data of any supported format
+// The format is detected from magic bytes, not from the file extension or Content-Type
+func decodeIconData(data []byte) (image.Image, error) {
 	// Detect format using magic bytes
-	format := detectFormat(body)
+	format := detectFormat(data)
+
+	switch format {
+	case FormatUnknown:
+		return nil, fmt.Errorf("unsupported image format: detected %s", format)
 
-	// For ICO format, use custom decoder
-	if format == FormatICO {
-		img, err := decodeICO(body)
+	case FormatICO:
+		// For ICO format, use custom decoder keeping all sub-images
+		img, err := decodeICOSizes(data)
 		if err != nil {
 			return nil, fmt.Errorf("failed to decode ICO image: %w", err)
 		}
 		return img, nil
-	}
 
-	// For other formats (PNG, JPEG, WebP, BMP), use standard image.Decode
-	if format == FormatUnknown {
-		return nil, fmt.Errorf("unsupported image format: detected %s", format)
+	case FormatICNS:
+		// For ICNS format, use custom decoder keeping all sub-images
+		img, err := decodeICNSSizes(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode ICNS image: %w", err)
+		}
+		return img, nil
+
+	case FormatGIF:
+		// Pick the best frame of animated GIFs instead of the first one
+		img, err := decodeGIF(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode GIF image: %w", err)
+		}
+		return img, nil
+
+	case FormatSVG:
+		// Rasterize vector icons at the largest icon size
+		img, err := decodeSVG(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode SVG image: %w", err)
+		}
+		return img, nil
 	}
 
-	img, _, err := image.Decode(bytes.NewReader(body))
+	// For other formats (PNG, JPEG, WebP, BMP, TIFF), use standard image.Decode
+	// The decoders are registered via imports at the top of this file
+	img, err := decodeImageLimited(data)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode %s image: %w", format, err)
 	}
@@ -267,19 +395,14 @@ func downloadIcon(url string) (image.Image, error) {
 	return img, nil
 }
 
-// prepareIcons pads a non-square image to square and resizes to 64x64 and 256x256
-func prepareIcons(src image.Image) (icon64, icon256 image.Image) {
-	squared := squareImage(src)
-	icon64 = resizeImage(squared, 64, 64)
-	icon256 = resizeImage(squared, 256, 256)
-	return
-}
-
-// resizeImage resizes an image to the specified dimensions
-func resizeImage(src image.Image, width, height int) image.Image {
-	dst := image.NewRGBA(image.Rect(0, 0, width, height))
-	xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), xdraw.Over, nil)
-	return dst
+// prepareIcon pads a non-square image to square and resizes it to size x size,
+// applying the entry's icon style. Multi-resolution icons use their closest sub-image.
+func prepareIcon(src image.Image, size int, style IconStyle) image.Image {
+	src = iconForSize(src, size)
+	if style.Trim {
+		src = trimTransparent(src)
+	}
+	return styleIcon(squareImage(src), size, style)
 }
 
 // squareImage pads a non-square image to make it square, centering the original image
@@ -0,0 +1,74 @@
+package fpkgen
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"os"
+)
+
+// Limits for decoding untrusted icon files. WatchCow runs as root on the NAS, so a
+// hostile or broken icon must not be able to exhaust memory.
+const (
+	maxIconDimension = 4096             // Maximum width or height of a decoded icon
+	maxIconPixels    = 4096 * 4096      // Maximum pixel count of a decoded icon
+	maxGIFPixels     = 64 * 1024 * 1024 // Maximum pixel count of all frames of an animated GIF
+	maxICODimension  = 1024             // Maximum size of BMP images inside ICO files (normally <= 256)
+)
+
+// checkIconSize rejects image dimensions before a canvas is allocated
+func checkIconSize(width, height int) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("invalid image dimensions %dx%d", width, height)
+	}
+	if width > maxIconDimension || height > maxIconDimension || width*height > maxIconPixels {
+		return fmt.Errorf("image too large: %dx%d (max %dx%d)", width, height, maxIconDimension, maxIconDimension)
+	}
+	return nil
+}
+
+// decodeImageLimited decodes an image of a registered format (PNG, JPEG, WebP, BMP, TIFF)
+// after checking its dimensions with image.DecodeConfig
+func decodeImageLimited(data []byte) (image.Image, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkIconSize(cfg.Width, cfg.Height); err != nil {
+		return nil, fmt.Errorf("%s: %w", format, err)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// readIconFile reads a local icon file, rejecting non-regular and oversized files
+func readIconFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("file not found: %s", path)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("not a regular file: %s", path)
+	}
+	if info.Size() > maxIconFileSize {
+		return nil, fmt.Errorf("file too large: %s (%d bytes, max %d)", path, info.Size(), maxIconFileSize)
+	}
+
+	// The file may grow between Stat and Read
+	data := make([]byte, info.Size())
+	n, err := f.ReadAt(data, 0)
+	if err != nil && n != len(data) {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return data[:n], nil
+}
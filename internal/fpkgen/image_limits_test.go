@@ -0,0 +1,201 @@
+package fpkgen
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// pngWithSize encodes a 1x1 PNG and patches its IHDR to claim width x height
+func pngWithSize(t testing.TB, width, height uint32) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	binary.BigEndian.PutUint32(data[16:20], width)
+	binary.BigEndian.PutUint32(data[20:24], height)
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+// icoWithBMP wraps a BITMAPINFOHEADER (plus pixel data) in a single-entry ICO file
+func icoWithBMP(headerSize uint32, width, height int32, bitCount uint16, pixels int) []byte {
+	bmp := make([]byte, 40+pixels)
+	binary.LittleEndian.PutUint32(bmp[0:4], headerSize)
+	binary.LittleEndian.PutUint32(bmp[4:8], uint32(width))
+	binary.LittleEndian.PutUint32(bmp[8:12], uint32(height))
+	binary.LittleEndian.PutUint16(bmp[12:14], 1)
+	binary.LittleEndian.PutUint16(bmp[14:16], bitCount)
+
+	data := []byte{0, 0, 1, 0, 1, 0}
+	entry := make([]byte, 16)
+	binary.LittleEndian.PutUint16(entry[6:8], bitCount)
+	binary.LittleEndian.PutUint32(entry[8:12], uint32(len(bmp)))
+	binary.LittleEndian.PutUint32(entry[12:16], 22)
+	data = append(data, entry...)
+	return append(data, bmp...)
+}
+
+// TestDecodeIconData_DimensionLimit tests that oversized images are rejected before decoding
+func TestDecodeIconData_DimensionLimit(t *testing.T) {
+	for _, size := range [][2]uint32{{50000, 50000}, {maxIconDimension + 1, 1}, {1, 100000}} {
+		if _, err := decodeIconData(pngWithSize(t, size[0], size[1])); err == nil || !strings.Contains(err.Error(), "too large") {
+			t.Errorf("%dx%d PNG: error = %v, want too large", size[0], size[1], err)
+		}
+	}
+
+	// The limit itself is accepted by the check
+	if err := checkIconSize(maxIconDimension, maxIconDimension); err != nil {
+		t.Errorf("checkIconSize(max) error = %v", err)
+	}
+	for _, size := range [][2]int{{0, 10}, {10, -1}} {
+		if err := checkIconSize(size[0], size[1]); err == nil {
+			t.Errorf("checkIconSize(%v) expected error", size)
+		}
+	}
+}
+
+// TestDecodeICO_Hostile tests ICO files with bogus BMP headers and dimensions
+func TestDecodeICO_Hostile(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"huge header size", icoWithBMP(0xFFFFFFF0, 16, 32, 32, 0)},
+		{"header beyond data", icoWithBMP(4096, 16, 32, 32, 64)},
+		{"huge dimensions", icoWithBMP(40, 100000, 200000, 32, 0)},
+		{"negative width", icoWithBMP(40, -16, 32, 32, 0)},
+		{"negative height", icoWithBMP(40, 16, -32, 32, 0)},
+		{"oversized PNG", append(icoWithBMP(40, 16, 32, 32, 0)[:22], pngWithSize(t, 50000, 50000)...)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeICO(tt.data); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+
+	// Truncated pixel data decodes without panicking
+	if _, err := decodeICO(icoWithBMP(40, 16, 32, 32, 10)); err != nil {
+		t.Errorf("truncated pixel data error = %v", err)
+	}
+}
+
+// TestDecodeGIF_FrameBudget tests that animations with too many large frames are rejected
+func TestDecodeGIF_FrameBudget(t *testing.T) {
+	// Hand-written stream: 4096x4096 screen with five empty full-size frames
+	data := []byte("GIF89a")
+	data = binary.LittleEndian.AppendUint16(data, 4096)
+	data = binary.LittleEndian.AppendUint16(data, 4096)
+	data = append(data, 0, 0, 0)
+	for range 5 {
+		data = append(data, 0x2C, 0, 0, 0, 0)
+		data = binary.LittleEndian.AppendUint16(data, 4096)
+		data = binary.LittleEndian.AppendUint16(data, 4096)
+		data = append(data, 0, 2, 0) // No local color table, LZW code size, empty data
+	}
+	data = append(data, 0x3B)
+
+	if got := gifFramePixels(data); got != 5*4096*4096 {
+		t.Errorf("gifFramePixels() = %d, want %d", got, 5*4096*4096)
+	}
+	if _, err := decodeGIF(data); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("decodeGIF() error = %v, want too large", err)
+	}
+
+	// Encoded animations are counted by their frame sizes
+	anim := encodeGIF(t, &gif.GIF{
+		Image: []*image.Paletted{newGIFFrame(image.Rect(0, 0, 16, 16), 1), newGIFFrame(image.Rect(2, 2, 6, 6), 2)},
+		Delay: []int{10, 10},
+	})
+	if got := gifFramePixels(anim); got != 16*16+4*4 {
+		t.Errorf("gifFramePixels() = %d, want %d", got, 16*16+4*4)
+	}
+}
+
+// TestLoadLocalIcon_Limits tests that non-regular and oversized files are not read
+func TestLoadLocalIcon_Limits(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := loadLocalIcon(dir); err == nil || !strings.Contains(err.Error(), "not a regular file") {
+		t.Errorf("directory: error = %v", err)
+	}
+
+	big := filepath.Join(dir, "big.png")
+	f, err := os.Create(big)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Truncate(maxIconFileSize + 1) // Sparse file
+	f.Close()
+	if _, err := loadLocalIcon(big); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("oversized file: error = %v", err)
+	}
+}
+
+// addTestdataSeeds adds the testdata icons to a fuzz corpus
+func addTestdataSeeds(f *testing.F) {
+	paths, _ := filepath.Glob(filepath.Join("testdata", "*"))
+	for _, path := range paths {
+		if strings.HasSuffix(path, ".go") {
+			continue
+		}
+		if data, err := os.ReadFile(path); err == nil {
+			f.Add(data)
+		}
+	}
+}
+
+// checkFuzzedImage fails if a decoded image exceeds the decoding limits
+func checkFuzzedImage(t *testing.T, img image.Image, err error) {
+	if err != nil {
+		return
+	}
+	if b := img.Bounds(); b.Dx() > maxIconDimension || b.Dy() > maxIconDimension {
+		t.Errorf("decoded image %v exceeds the dimension limit", b)
+	}
+}
+
+// FuzzDetectFormat tests that format detection never panics
+func FuzzDetectFormat(f *testing.F) {
+	addTestdataSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if format := detectFormat(data); format < FormatUnknown || format > FormatICNS {
+			t.Errorf("detectFormat() returned invalid format %d", format)
+		}
+	})
+}
+
+// FuzzDecodeICO tests the ICO decoder with hostile input
+func FuzzDecodeICO(f *testing.F) {
+	addTestdataSeeds(f)
+	f.Add(icoWithBMP(40, 16, 32, 8, 512))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		img, err := decodeICO(data)
+		checkFuzzedImage(t, img, err)
+	})
+}
+
+// FuzzLoadIconFromSource tests the full local icon pipeline with hostile files
+func FuzzLoadIconFromSource(f *testing.F) {
+	addTestdataSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		path := filepath.Join(t.TempDir(), "icon")
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		img, err := loadIconFromSource("file://"+path, "")
+		checkFuzzedImage(t, img, err)
+	})
+}
@@ -0,0 +1,56 @@
+package fpkgen
+
+import (
+	"strings"
+
+	"github.com/distribution/reference"
+)
+
+// ImageRef holds the components of a Docker image reference
+// e.g. "registry.local:5000/team/jellyfin:10.9" or "jellyfin/jellyfin@sha256:..."
+type ImageRef struct {
+	Original   string // Reference as given in the container config
+	Registry   string // Registry host (e.g. "docker.io", "registry.local:5000")
+	Repository string // Repository path without registry (e.g. "library/nginx", "team/jellyfin")
+	Familiar   string // Short repository name as shown by docker (e.g. "nginx", "team/jellyfin")
+	Name       string // Last path component of the repository (e.g. "jellyfin")
+	Tag        string // Tag (empty if not set)
+	Digest     string // Digest (e.g. "sha256:...", empty if not pinned)
+}
+
+// parseImageRef parses an image reference using Docker's normalization rules
+// If the reference cannot be parsed (e.g. a bare image ID), only Original is set
+func parseImageRef(image string) ImageRef {
+	ref := ImageRef{Original: image}
+
+	named, err := reference.ParseNormalizedNamed(strings.TrimSpace(image))
+	if err != nil {
+		return ref
+	}
+
+	ref.Registry = reference.Domain(named)
+	ref.Repository = reference.Path(named)
+	ref.Familiar = reference.FamiliarName(named)
+	ref.Name = ref.Repository[strings.LastIndex(ref.Repository, "/")+1:]
+
+	if tagged, ok := named.(reference.Tagged); ok {
+		ref.Tag = tagged.Tag()
+	}
+	if digested, ok := named.(reference.Digested); ok {
+		ref.Digest = digested.Digest().String()
+	}
+
+	return ref
+}
+
+// DisplayString returns the familiar name with its tag (without digest),
+// falling back to the original reference if it could not be parsed
+func (r ImageRef) DisplayString() string {
+	if r.Familiar == "" {
+		return r.Original
+	}
+	if r.Tag != "" {
+		return r.Familiar + ":" + r.Tag
+	}
+	return r.Familiar
+}
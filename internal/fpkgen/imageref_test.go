@@ -0,0 +1,96 @@
+package fpkgen
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParseImageRef tests parsing of image references into their components
+func TestParseImageRef(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+
+	tests := []struct {
+		image string
+		want  ImageRef
+	}{
+		{
+			image: "nginx",
+			want:  ImageRef{Registry: "docker.io", Repository: "library/nginx", Familiar: "nginx", Name: "nginx"},
+		},
+		{
+			image: "nginx:1.27-alpine",
+			want:  ImageRef{Registry: "docker.io", Repository: "library/nginx", Familiar: "nginx", Name: "nginx", Tag: "1.27-alpine"},
+		},
+		{
+			image: "registry.local:5000/team/jellyfin:10.9",
+			want: ImageRef{Registry: "registry.local:5000", Repository: "team/jellyfin", Familiar: "registry.local:5000/team/jellyfin",
+				Name: "jellyfin", Tag: "10.9"},
+		},
+		{
+			image: "jellyfin@" + digest,
+			want:  ImageRef{Registry: "docker.io", Repository: "library/jellyfin", Familiar: "jellyfin", Name: "jellyfin", Digest: digest},
+		},
+		{
+			image: "lscr.io/linuxserver/sonarr:latest@" + digest,
+			want: ImageRef{Registry: "lscr.io", Repository: "linuxserver/sonarr", Familiar: "lscr.io/linuxserver/sonarr",
+				Name: "sonarr", Tag: "latest", Digest: digest},
+		},
+		{
+			image: "ghcr.io/Invalid/UPPER",
+			want:  ImageRef{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			tt.want.Original = tt.image
+			if got := parseImageRef(tt.image); got != tt.want {
+				t.Errorf("parseImageRef(%q) = %+v, want %+v", tt.image, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestImageRef_DisplayString tests the short display form used in descriptions
+func TestImageRef_DisplayString(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("b", 64)
+
+	tests := map[string]string{
+		"nginx:latest":                           "nginx:latest",
+		"docker.io/library/nginx":                "nginx",
+		"jellyfin/jellyfin@" + digest:            "jellyfin/jellyfin",
+		"registry.local:5000/team/jellyfin:10.9": "registry.local:5000/team/jellyfin:10.9",
+		"sha256:" + strings.Repeat("c", 64):      "sha256:" + strings.Repeat("c", 64),
+	}
+
+	for image, want := range tests {
+		if got := parseImageRef(image).DisplayString(); got != want {
+			t.Errorf("parseImageRef(%q).DisplayString() = %q, want %q", image, got, want)
+		}
+	}
+}
+
+// TestGuessIcon_ImageRef tests icon guessing with registry ports, tags and digests
+func TestGuessIcon_ImageRef(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("d", 64)
+	jellyfinIcon := "https://cdn.jsdelivr.net/gh/homarr-labs/dashboard-icons/png/jellyfin.png"
+
+	catalog, err := LoadPresetCatalog("")
+	if err != nil {
+		t.Fatalf("LoadPresetCatalog() error = %v", err)
+	}
+
+	for _, image := range []string{
+		"jellyfin/jellyfin",
+		"registry.local:5000/team/jellyfin:10.9",
+		"jellyfin@" + digest,
+	} {
+		if got := catalog.guessIcon(parseImageRef(image)); got != jellyfinIcon {
+			t.Errorf("guessIcon(%q) = %q, want %q", image, got, jellyfinIcon)
+		}
+	}
+
+	if got := catalog.guessIcon(parseImageRef("registry.local:5000/unknown")); !strings.HasSuffix(got, "/docker.png") {
+		t.Errorf("guessIcon(unknown) = %q, want docker fallback", got)
+	}
+}
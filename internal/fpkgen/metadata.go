@@ -0,0 +1,119 @@
+package fpkgen
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// OCI image annotation keys used as metadata fallbacks
+const (
+	ociTitle       = "org.opencontainers.image.title"
+	ociDescription = "org.opencontainers.image.description"
+	ociVersion     = "org.opencontainers.image.version"
+	ociAuthors     = "org.opencontainers.image.authors"
+	ociURL         = "org.opencontainers.image.url"
+	ociSource      = "org.opencontainers.image.source"
+)
+
+// versionPattern matches the numeric part of a version string (e.g. "v10.9.1-alpine" -> "10.9.1")
+var versionPattern = regexp.MustCompile(`^[vV]?(\d+(?:\.\d+){0,3})`)
+
+// appMetadata holds the resolved identity metadata of an app
+type appMetadata struct {
+	DisplayName string
+	Description string
+	Version     string
+	Maintainer  string
+	Homepage    string
+}
+
+// resolveMetadata resolves app metadata through the chain
+// watchcow label -> OCI image label -> image preset / image tag -> default
+//
+// imageLabels come from the image config (ImageInspect). OCI labels are looked up in
+// the container labels first: Docker inherits them from the image, and labels set on
+// the container override those of the image.
+func resolveMetadata(labels, imageLabels map[string]string, containerName string, image ImageRef, preset *Preset) appMetadata {
+	oci := func(key string) string {
+		if val := strings.TrimSpace(labels[key]); val != "" {
+			return val
+		}
+		return strings.TrimSpace(imageLabels[key])
+	}
+
+	meta := appMetadata{
+		DisplayName: getLabel(labels, "watchcow.display_name", ""),
+		Description: getLabel(labels, "watchcow.desc", ""),
+		Version:     getLabel(labels, "watchcow.version", ""),
+		Maintainer:  getLabel(labels, "watchcow.maintainer", ""),
+		Homepage:    getLabel(labels, "watchcow.homepage", ""),
+	}
+
+	// Display name: label -> OCI title -> preset -> prettified container name
+	if meta.DisplayName == "" {
+		meta.DisplayName = firstLine(oci(ociTitle))
+	}
+	if meta.DisplayName == "" && preset != nil {
+		meta.DisplayName = preset.DisplayName
+	}
+	if meta.DisplayName == "" {
+		meta.DisplayName = prettifyName(containerName)
+	}
+
+	// Description: label -> OCI description -> preset -> image name
+	if meta.Description == "" {
+		meta.Description = oci(ociDescription)
+	}
+	if meta.Description == "" && preset != nil {
+		meta.Description = preset.Description
+	}
+	if meta.Description == "" {
+		meta.Description = fmt.Sprintf("Docker container: %s", image.DisplayString())
+	}
+
+	// Version: label -> OCI version -> image tag -> 1.0.0
+	if meta.Version == "" {
+		meta.Version = normalizeVersion(oci(ociVersion))
+	}
+	if meta.Version == "" {
+		meta.Version = normalizeVersion(image.Tag)
+	}
+	if meta.Version == "" {
+		meta.Version = "1.0.0"
+	}
+
+	// Maintainer: label -> OCI authors -> WatchCow
+	if meta.Maintainer == "" {
+		meta.Maintainer = firstLine(oci(ociAuthors))
+	}
+	if meta.Maintainer == "" {
+		meta.Maintainer = "WatchCow"
+	}
+
+	// Homepage: label -> OCI url -> OCI source
+	if meta.Homepage == "" {
+		meta.Homepage = firstLine(oci(ociURL))
+	}
+	if meta.Homepage == "" {
+		meta.Homepage = firstLine(oci(ociSource))
+	}
+
+	return meta
+}
+
+// normalizeVersion extracts a manifest-compatible numeric version
+// Returns empty string if the value does not start with a version number
+func normalizeVersion(v string) string {
+	m := versionPattern.FindStringSubmatch(strings.TrimSpace(v))
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// firstLine returns the first line of s, trimmed
+func firstLine(s string) string {
+	s, _, _ = strings.Cut(s, "\n")
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,209 @@
+package fpkgen
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestResolveMetadata_Defaults tests the final defaults of the metadata chain
+func TestResolveMetadata_Defaults(t *testing.T) {
+	meta := resolveMetadata(nil, nil, "my_app-1", parseImageRef("myapp"), nil)
+
+	if meta.DisplayName != "My App" {
+		t.Errorf("DisplayName = %q, want %q", meta.DisplayName, "My App")
+	}
+	if meta.Description != "Docker container: myapp" {
+		t.Errorf("Description = %q", meta.Description)
+	}
+	if meta.Version != "1.0.0" {
+		t.Errorf("Version = %q, want %q", meta.Version, "1.0.0")
+	}
+	if meta.Maintainer != "WatchCow" {
+		t.Errorf("Maintainer = %q, want %q", meta.Maintainer, "WatchCow")
+	}
+	if meta.Homepage != "" {
+		t.Errorf("Homepage = %q, want empty", meta.Homepage)
+	}
+}
+
+// TestResolveMetadata_OCILabels tests fallback to OCI image labels
+func TestResolveMetadata_OCILabels(t *testing.T) {
+	imageLabels := map[string]string{
+		ociTitle:       "Jellyfin",
+		ociDescription: "The Free Software Media System",
+		ociVersion:     "10.9.11",
+		ociAuthors:     "Jellyfin Contributors\nsecond line",
+		ociSource:      "https://github.com/jellyfin/jellyfin",
+	}
+
+	meta := resolveMetadata(nil, imageLabels, "jellyfin", parseImageRef("jellyfin/jellyfin:latest"), nil)
+
+	if meta.DisplayName != "Jellyfin" {
+		t.Errorf("DisplayName = %q", meta.DisplayName)
+	}
+	if meta.Description != "The Free Software Media System" {
+		t.Errorf("Description = %q", meta.Description)
+	}
+	if meta.Version != "10.9.11" {
+		t.Errorf("Version = %q", meta.Version)
+	}
+	if meta.Maintainer != "Jellyfin Contributors" {
+		t.Errorf("Maintainer = %q", meta.Maintainer)
+	}
+	if meta.Homepage != "https://github.com/jellyfin/jellyfin" {
+		t.Errorf("Homepage = %q", meta.Homepage)
+	}
+}
+
+// TestResolveMetadata_LabelsWin tests that watchcow labels take precedence
+func TestResolveMetadata_LabelsWin(t *testing.T) {
+	labels := map[string]string{
+		"watchcow.display_name": "Media",
+		"watchcow.desc":         "My media server",
+		"watchcow.version":      "2.0.0",
+		"watchcow.maintainer":   "me",
+		"watchcow.homepage":     "https://example.com",
+	}
+	imageLabels := map[string]string{
+		ociTitle:       "Jellyfin",
+		ociDescription: "The Free Software Media System",
+		ociVersion:     "10.9.11",
+		ociAuthors:     "Jellyfin Contributors",
+		ociURL:         "https://jellyfin.org",
+	}
+
+	meta := resolveMetadata(labels, imageLabels, "jellyfin", parseImageRef("jellyfin/jellyfin:10.9.11"), nil)
+
+	want := appMetadata{
+		DisplayName: "Media",
+		Description: "My media server",
+		Version:     "2.0.0",
+		Maintainer:  "me",
+		Homepage:    "https://example.com",
+	}
+	if meta != want {
+		t.Errorf("resolveMetadata() = %+v, want %+v", meta, want)
+	}
+}
+
+// TestResolveMetadata_ContainerOCILabels tests OCI labels inherited into container labels
+func TestResolveMetadata_ContainerOCILabels(t *testing.T) {
+	labels := map[string]string{
+		ociURL: "https://usememos.com",
+	}
+
+	meta := resolveMetadata(labels, nil, "memos", parseImageRef("neosmemo/memos:stable"), nil)
+	if meta.Homepage != "https://usememos.com" {
+		t.Errorf("Homepage = %q", meta.Homepage)
+	}
+
+	// Labels set on the container override those of the image
+	imageLabels := map[string]string{ociURL: "https://example.com", ociTitle: "Memos"}
+	meta = resolveMetadata(labels, imageLabels, "memos", parseImageRef("neosmemo/memos:stable"), nil)
+	if meta.Homepage != "https://usememos.com" || meta.DisplayName != "Memos" {
+		t.Errorf("Homepage = %q, DisplayName = %q", meta.Homepage, meta.DisplayName)
+	}
+}
+
+// TestManifest_OCILabelInjection tests that OCI labels cannot add manifest keys
+func TestManifest_OCILabelInjection(t *testing.T) {
+	engine, err := NewTemplateEngine()
+	if err != nil {
+		t.Fatalf("NewTemplateEngine() error = %v", err)
+	}
+
+	imageLabels := map[string]string{
+		ociTitle: "Evil\ninstall_type=root",
+		ociURL:   "https://example.com\r\nos_min_version=9.9.9",
+	}
+	meta := resolveMetadata(nil, imageLabels, "evil", parseImageRef("evil/evil"), nil)
+	if meta.DisplayName != "Evil" || meta.Homepage != "https://example.com" {
+		t.Errorf("DisplayName = %q, Homepage = %q", meta.DisplayName, meta.Homepage)
+	}
+
+	config := &AppConfig{
+		AppName:     "watchcow.evil",
+		DisplayName: "Evil\ninjected_name=1",
+		Maintainer:  "me\rinjected_maintainer=1",
+		Homepage:    "https://example.com\ninjected_url=1",
+	}
+	out, err := engine.Render("manifest.tmpl", NewTemplateData(config))
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	for _, line := range strings.FieldsFunc(string(out), func(r rune) bool { return r == '\n' || r == '\r' }) {
+		if strings.HasPrefix(line, "injected_") {
+			t.Errorf("manifest has injected line %q:\n%s", line, out)
+		}
+	}
+}
+
+// TestResolveMetadata_VersionFromTag tests deriving the version from the image tag
+func TestResolveMetadata_VersionFromTag(t *testing.T) {
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{"jellyfin/jellyfin:10.9.1", "10.9.1"},
+		{"nginx:v1.27-alpine", "1.27"},
+		{"nginx:latest", "1.0.0"},
+		{"nginx", "1.0.0"},
+		{"registry.local:5000/team/app", "1.0.0"},
+		{"registry.local:5000/team/app:3.1", "3.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			if got := resolveMetadata(nil, nil, "app", parseImageRef(tt.image), nil).Version; got != tt.want {
+				t.Errorf("Version = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestNormalizeVersion tests version normalization
+func TestNormalizeVersion(t *testing.T) {
+	tests := map[string]string{
+		"1.2.3":        "1.2.3",
+		"v2.0":         "2.0",
+		"10.9.1-beta":  "10.9.1",
+		" 2024.10.1 ":  "2024.10.1",
+		"1.2.3.4.5":    "1.2.3.4",
+		"stable":       "",
+		"":             "",
+		"sha-abc1234":  "",
+		"2.1.0+build7": "2.1.0",
+	}
+
+	for in, want := range tests {
+		if got := normalizeVersion(in); got != want {
+			t.Errorf("normalizeVersion(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// TestManifest_Homepage tests that the homepage is rendered as maintainer_url
+func TestManifest_Homepage(t *testing.T) {
+	engine, err := NewTemplateEngine()
+	if err != nil {
+		t.Fatalf("NewTemplateEngine() error = %v", err)
+	}
+
+	config := &AppConfig{AppName: "watchcow.app", Maintainer: "me", Homepage: "https://example.com"}
+	out, err := engine.Render("manifest.tmpl", NewTemplateData(config))
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if !strings.Contains(string(out), "maintainer=me\nmaintainer_url=https://example.com\n") {
+		t.Errorf("manifest missing maintainer_url:\n%s", out)
+	}
+
+	config.Homepage = ""
+	out, err = engine.Render("manifest.tmpl", NewTemplateData(config))
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if strings.Contains(string(out), "maintainer_url") {
+		t.Errorf("manifest should omit empty maintainer_url:\n%s", out)
+	}
+}
@@ -1,6 +1,8 @@
 package fpkgen
 
 import (
+	"errors"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -234,3 +236,152 @@ func TestProperty_GetBasePathReturnsEmptyWithoutLabel(t *testing.T) {
 		t.Errorf("Property test failed: %v", err)
 	}
 }
+
+// TestWithinRoots tests matching paths against literal and glob roots
+func TestWithinRoots(t *testing.T) {
+	roots := []string{"/home/user/project", "/vol*"}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/home/user/project/icon.png", true},
+		{"/home/user/project/icons/app.png", true},
+		{"/home/user/project2/icon.png", false},
+		{"/home/user/icon.png", false},
+		{"/vol1/docker/icon.png", true},
+		{"/vol02/icon.png", true},
+		{"/volume/icon.png", true},
+		{"/var/vol1/icon.png", false},
+		{"/root/.ssh/id_rsa", false},
+		{"/", false},
+	}
+
+	for _, tt := range tests {
+		if got := withinRoots(tt.path, roots); got != tt.want {
+			t.Errorf("withinRoots(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	if !withinRoots("/root/.ssh/id_rsa", []string{"/"}) {
+		t.Error("root / should allow any path")
+	}
+}
+
+// Property test: paths escaping the base path with .. are rejected before touching the filesystem
+func TestProperty_FileRootsRejectTraversal(t *testing.T) {
+	roots := newFileRoots(nil)
+	f := func(name string) bool {
+		name = strings.ReplaceAll(name, "/", "_")
+		if name == "" || name == "." || name == ".." {
+			return true
+		}
+		_, err := roots.resolve("file://../../"+name, "/nonexistent/base")
+		return errors.Is(err, errOutsideRoots)
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 100}); err != nil {
+		t.Errorf("Property test failed: %v", err)
+	}
+}
+
+// TestFileRoots_Resolve tests confinement of file:// paths including symlinks
+func TestFileRoots_Resolve(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"vol1/project", "vol1/docker", "secret"} {
+		os.MkdirAll(filepath.Join(dir, sub), 0755)
+	}
+	write := func(path string) string {
+		path = filepath.Join(dir, path)
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	icon := write("vol1/project/icon.png")
+	volIcon := write("vol1/docker/icon.png")
+	secret := write("secret/id_rsa")
+	os.Symlink(secret, filepath.Join(dir, "vol1", "project", "escape.png"))
+	os.Symlink(volIcon, filepath.Join(dir, "vol1", "project", "shared.png"))
+
+	base := filepath.Join(dir, "vol1", "project")
+	roots := newFileRoots([]string{filepath.Join(dir, "vol*"), "relative/ignored", ""})
+
+	tests := []struct {
+		name    string
+		source  string
+		want    string
+		wantErr string
+	}{
+		{"relative", "file://icon.png", icon, ""},
+		{"absolute in volume", "file://" + volIcon, volIcon, ""},
+		{"symlink into volume", "file://shared.png", volIcon, ""},
+		{"traversal", "file://../../secret/id_rsa", "", errOutsideRoots.Error()},
+		{"absolute outside", "file://" + secret, "", errOutsideRoots.Error()},
+		{"symlink outside", "file://escape.png", "", errOutsideRoots.Error()},
+		{"missing outside", "file:///root/.ssh/missing", "", errOutsideRoots.Error()},
+		{"missing inside", "file://missing.png", "", "file not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := roots.resolve(tt.source, base)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("resolve(%q) error = %v, want %q", tt.source, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("resolve(%q) = %q, %v, want %q", tt.source, got, err, tt.want)
+			}
+		})
+	}
+
+	// The compose working directory comes from a label and does not widen the roots
+	for _, untrusted := range []string{"/", filepath.Join(dir, "secret")} {
+		if _, err := roots.resolve("file://id_rsa", untrusted); !errors.Is(err, errOutsideRoots) {
+			t.Errorf("resolve() with base %s error = %v, want outside roots", untrusted, err)
+		}
+		if _, err := roots.resolve("file://"+secret, untrusted); !errors.Is(err, errOutsideRoots) {
+			t.Errorf("resolve(%s) with base %s error = %v, want outside roots", secret, untrusted, err)
+		}
+	}
+
+	// nil roots and / allow any path
+	for _, r := range []*fileRoots{nil, newFileRoots([]string{"/"})} {
+		if _, err := r.resolve("file://"+secret, base); err != nil {
+			t.Errorf("unrestricted resolve() error = %v", err)
+		}
+	}
+}
+
+// TestIconLoader_Roots tests that the icon loader and description files honor the roots
+func TestIconLoader_Roots(t *testing.T) {
+	absPath, _ := filepath.Abs(filepath.Join("testdata", "test.png"))
+	loader := &iconLoader{basePath: t.TempDir(), roots: newFileRoots(nil)}
+
+	if _, err := loader.load("file://" + absPath); !errors.Is(err, errOutsideRoots) {
+		t.Errorf("load() error = %v, want outside roots", err)
+	}
+	if _, err := loadDescriptionFile("file:///etc/passwd", loader.basePath, loader.roots); !errors.Is(err, errOutsideRoots) {
+		t.Errorf("loadDescriptionFile() error = %v, want outside roots", err)
+	}
+
+	loader.roots = newFileRoots([]string{filepath.Dir(absPath)})
+	if _, err := loader.load("file://" + absPath); err != nil {
+		t.Errorf("load() error = %v", err)
+	}
+}
+
+// TestDefaultIconRoots tests the default roots inside and outside fnOS
+func TestDefaultIconRoots(t *testing.T) {
+	t.Setenv("TRIM_PKGVAR", "")
+	if got := DefaultIconRoots(); len(got) != 1 || got[0] != "/vol*" {
+		t.Errorf("DefaultIconRoots() = %v", got)
+	}
+
+	t.Setenv("TRIM_PKGVAR", "/var/apps/watchcow/var")
+	got := DefaultIconRoots()
+	if len(got) != 2 || got[1] != "/var/apps/watchcow/var/icons" {
+		t.Errorf("DefaultIconRoots() = %v", got)
+	}
+}
@@ -0,0 +1,205 @@
+package fpkgen
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+
+	dockercontainer "github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed presets/presets.yaml
+var builtinPresets []byte
+
+// dashboardIconsURL is the URL template for dashboard-icons PNG icons
+const dashboardIconsURL = dashboardIconsBase + "/png/%s.png"
+
+// fallbackIconURL is the generic icon for images without label or preset icon.
+// Entries still using it are candidates for icon discovery.
+var fallbackIconURL = fmt.Sprintf(dashboardIconsURL, "docker")
+
+// PresetEntry describes an additional named entry supplied by a preset
+type PresetEntry struct {
+	Name      string `yaml:"name"`
+	Title     string `yaml:"title"`
+	Protocol  string `yaml:"protocol"`
+	Port      string `yaml:"port"` // Container port (defaults to the preset port)
+	Path      string `yaml:"path"`
+	UIType    string `yaml:"ui_type"`
+	AllUsers  *bool  `yaml:"all_users"`
+	NoDisplay bool   `yaml:"no_display"`
+}
+
+// Preset holds zero-config defaults for a well-known image
+type Preset struct {
+	Icon        string        `yaml:"icon"` // dashboard-icons name or icon URL
+	DisplayName string        `yaml:"display_name"`
+	Description string        `yaml:"desc"`
+	Protocol    string        `yaml:"protocol"`
+	Port        string        `yaml:"port"` // Container port of the web UI
+	Path        string        `yaml:"path"`
+	UIType      string        `yaml:"ui_type"`
+	Entries     []PresetEntry `yaml:"entries"`
+}
+
+// IconURL returns the preset icon as a loadable icon source (empty if unset)
+func (p *Preset) IconURL() string {
+	if p == nil || p.Icon == "" {
+		return ""
+	}
+	if strings.Contains(p.Icon, "://") || strings.HasPrefix(p.Icon, "data:") {
+		return p.Icon
+	}
+	return fmt.Sprintf(dashboardIconsURL, p.Icon)
+}
+
+// PresetCatalog maps image repositories to presets
+type PresetCatalog struct {
+	presets map[string]*Preset
+}
+
+// LoadPresetCatalog loads the built-in presets, extended/overridden by an optional user file
+// Presets in the user file replace built-in presets with the same key.
+func LoadPresetCatalog(overridePath string) (*PresetCatalog, error) {
+	catalog := &PresetCatalog{presets: make(map[string]*Preset)}
+
+	if err := catalog.merge(builtinPresets); err != nil {
+		return nil, fmt.Errorf("failed to parse built-in presets: %w", err)
+	}
+
+	if overridePath != "" {
+		data, err := os.ReadFile(overridePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read preset file: %w", err)
+		}
+		if err := catalog.merge(data); err != nil {
+			return nil, fmt.Errorf("failed to parse preset file %s: %w", overridePath, err)
+		}
+	}
+
+	return catalog, nil
+}
+
+// merge adds all presets from YAML data to the catalog
+func (c *PresetCatalog) merge(data []byte) error {
+	var presets map[string]*Preset
+	if err := yaml.Unmarshal(data, &presets); err != nil {
+		return err
+	}
+	for key, preset := range presets {
+		if preset == nil {
+			continue
+		}
+		c.presets[strings.ToLower(key)] = preset
+	}
+	return nil
+}
+
+// Lookup finds the preset for an image, from the most to the least specific key
+// Returns nil if no preset matches
+func (c *PresetCatalog) Lookup(image ImageRef) *Preset {
+	if c == nil || image.Repository == "" {
+		return nil
+	}
+
+	keys := []string{
+		image.Registry + "/" + image.Repository,
+		image.Repository,
+		image.Familiar,
+		image.Name,
+	}
+	for _, key := range keys {
+		if preset, ok := c.presets[strings.ToLower(key)]; ok {
+			return preset
+		}
+	}
+	return nil
+}
+
+// guessIcon tries to guess an appropriate icon URL based on the image preset
+// Returns fallbackIconURL if the image has no preset icon
+func (c *PresetCatalog) guessIcon(image ImageRef) string {
+	if icon := c.Lookup(image).IconURL(); icon != "" {
+		return icon
+	}
+	return fallbackIconURL
+}
+
+// presetEntries converts the additional preset entries to entries
+// Container ports are mapped to published host ports, falling back to defaultPort
+func presetEntries(preset *Preset, container *dockercontainer.InspectResponse, displayName, icon, defaultPort string) []Entry {
+	var entries []Entry
+	for _, pe := range preset.Entries {
+		if pe.Name == "" {
+			continue
+		}
+
+		title := pe.Title
+		if title == "" {
+			title = displayName + " - " + pe.Name
+		}
+		port := pe.Port
+		if port == "" {
+			port = preset.Port
+		}
+		allUsers := true
+		if pe.AllUsers != nil {
+			allUsers = *pe.AllUsers
+		}
+
+		entries = append(entries, Entry{
+			Name:      pe.Name,
+			Title:     title,
+			Protocol:  firstNonEmpty(pe.Protocol, preset.Protocol, "http"),
+			Port:      firstNonEmpty(hostPortFor(container, port), defaultPort),
+			Path:      firstNonEmpty(pe.Path, "/"),
+			UIType:    firstNonEmpty(pe.UIType, preset.UIType, "url"),
+			AllUsers:  allUsers,
+			Icon:      icon,
+			NoDisplay: pe.NoDisplay,
+		})
+	}
+	return entries
+}
+
+// hostPortFor returns the host port published for a container port
+// With host networking the container port is used directly. Returns empty string if unpublished.
+func hostPortFor(container *dockercontainer.InspectResponse, containerPort string) string {
+	if containerPort == "" || container == nil || container.HostConfig == nil {
+		return ""
+	}
+
+	if container.HostConfig.NetworkMode.IsHost() {
+		return containerPort
+	}
+
+	port := nat.Port(containerPort + "/tcp")
+	var bindingSets [][]nat.PortBinding
+	if container.NetworkSettings != nil {
+		bindingSets = append(bindingSets, container.NetworkSettings.Ports[port])
+	}
+	bindingSets = append(bindingSets, container.HostConfig.PortBindings[port])
+
+	for _, bindings := range bindingSets {
+		for _, binding := range bindings {
+			if binding.HostPort != "" {
+				return binding.HostPort
+			}
+		}
+	}
+
+	return ""
+}
+
+// firstNonEmpty returns the first non-empty value
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
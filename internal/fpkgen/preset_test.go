@@ -0,0 +1,234 @@
+package fpkgen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	dockercontainer "github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+)
+
+// newTestContainer creates a minimal inspected container for extractConfig tests
+func newTestContainer(image string, labels map[string]string, ports nat.PortMap) *dockercontainer.InspectResponse {
+	return &dockercontainer.InspectResponse{
+		ContainerJSONBase: &dockercontainer.ContainerJSONBase{
+			ID:         "0123456789abcdef",
+			Name:       "/app",
+			HostConfig: &dockercontainer.HostConfig{PortBindings: ports},
+		},
+		Config: &dockercontainer.Config{Image: image, Labels: labels},
+	}
+}
+
+// TestLoadPresetCatalog_Builtin tests that the embedded presets parse
+func TestLoadPresetCatalog_Builtin(t *testing.T) {
+	catalog, err := LoadPresetCatalog("")
+	if err != nil {
+		t.Fatalf("LoadPresetCatalog() error = %v", err)
+	}
+
+	for key, preset := range catalog.presets {
+		if preset.Icon == "" {
+			t.Errorf("preset %q has no icon", key)
+		}
+		for _, entry := range preset.Entries {
+			if entry.Name == "" {
+				t.Errorf("preset %q has an entry without name", key)
+			}
+		}
+	}
+}
+
+// TestLoadPresetCatalog_Override tests extending and overriding presets from a user file
+func TestLoadPresetCatalog_Override(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "presets.yaml")
+	data := `
+jellyfin:
+  icon: https://example.com/jellyfin.png
+  display_name: My Jellyfin
+  port: "8920"
+  protocol: https
+My-App:
+  icon: myapp
+  port: "3000"
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	catalog, err := LoadPresetCatalog(path)
+	if err != nil {
+		t.Fatalf("LoadPresetCatalog() error = %v", err)
+	}
+
+	jellyfin := catalog.Lookup(parseImageRef("jellyfin/jellyfin"))
+	if jellyfin == nil || jellyfin.DisplayName != "My Jellyfin" || jellyfin.Port != "8920" {
+		t.Errorf("jellyfin preset not overridden: %+v", jellyfin)
+	}
+	if got := jellyfin.IconURL(); got != "https://example.com/jellyfin.png" {
+		t.Errorf("IconURL() = %q", got)
+	}
+
+	myApp := catalog.Lookup(parseImageRef("ghcr.io/me/my-app:1.0"))
+	if myApp == nil || myApp.Port != "3000" {
+		t.Fatalf("my-app preset not found: %+v", myApp)
+	}
+	if got := myApp.IconURL(); got != "https://cdn.jsdelivr.net/gh/homarr-labs/dashboard-icons/png/myapp.png" {
+		t.Errorf("IconURL() = %q", got)
+	}
+
+	// Built-in presets not in the file are kept
+	if catalog.Lookup(parseImageRef("portainer/portainer-ce")) == nil {
+		t.Error("built-in portainer-ce preset lost after merge")
+	}
+}
+
+// TestLoadPresetCatalog_Errors tests error handling for invalid preset files
+func TestLoadPresetCatalog_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := LoadPresetCatalog(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("expected error for missing preset file")
+	}
+
+	invalid := filepath.Join(dir, "invalid.yaml")
+	if err := os.WriteFile(invalid, []byte("jellyfin: [not, a, preset"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPresetCatalog(invalid); err == nil {
+		t.Error("expected error for invalid preset file")
+	}
+}
+
+// TestPresetCatalog_Lookup tests matching from the most to the least specific key
+func TestPresetCatalog_Lookup(t *testing.T) {
+	catalog := &PresetCatalog{presets: map[string]*Preset{
+		"lscr.io/linuxserver/app": {DisplayName: "registry"},
+		"team/app":                {DisplayName: "repository"},
+		"library/nginx":           {DisplayName: "official"},
+		"app":                     {DisplayName: "name"},
+	}}
+
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{"lscr.io/linuxserver/app:latest", "registry"},
+		{"team/app", "repository"},
+		{"registry.local:5000/team/app", "repository"},
+		{"nginx:alpine", "official"},
+		{"other/app@sha256:0123456789012345678901234567890123456789012345678901234567890123", "name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			preset := catalog.Lookup(parseImageRef(tt.image))
+			if preset == nil {
+				t.Fatalf("Lookup(%q) = nil, want %q", tt.image, tt.want)
+			}
+			if preset.DisplayName != tt.want {
+				t.Errorf("Lookup(%q) = %q, want %q", tt.image, preset.DisplayName, tt.want)
+			}
+		})
+	}
+
+	if preset := catalog.Lookup(parseImageRef("unknown/image")); preset != nil {
+		t.Errorf("Lookup(unknown) = %+v, want nil", preset)
+	}
+	if preset := catalog.Lookup(parseImageRef("sha256:0123")); preset != nil {
+		t.Errorf("Lookup(image ID) = %+v, want nil", preset)
+	}
+}
+
+// TestHostPortFor tests mapping of container ports to published host ports
+func TestHostPortFor(t *testing.T) {
+	container := newTestContainer("app", nil, nat.PortMap{
+		"8096/tcp": {{HostIP: "0.0.0.0", HostPort: "18096"}},
+	})
+	container.NetworkSettings = &dockercontainer.NetworkSettings{
+		NetworkSettingsBase: dockercontainer.NetworkSettingsBase{
+			Ports: nat.PortMap{"9443/tcp": {{HostIP: "0.0.0.0", HostPort: "19443"}}},
+		},
+	}
+
+	if got := hostPortFor(container, "8096"); got != "18096" {
+		t.Errorf("hostPortFor(8096) = %q, want 18096", got)
+	}
+	if got := hostPortFor(container, "9443"); got != "19443" {
+		t.Errorf("hostPortFor(9443) = %q, want 19443", got)
+	}
+	if got := hostPortFor(container, "80"); got != "" {
+		t.Errorf("hostPortFor(80) = %q, want empty", got)
+	}
+
+	container.HostConfig.NetworkMode = "host"
+	if got := hostPortFor(container, "80"); got != "80" {
+		t.Errorf("hostPortFor(80) with host network = %q, want 80", got)
+	}
+}
+
+// TestExtractConfig_Preset tests zero-config defaults from image presets
+func TestExtractConfig_Preset(t *testing.T) {
+	catalog, err := LoadPresetCatalog("")
+	if err != nil {
+		t.Fatalf("LoadPresetCatalog() error = %v", err)
+	}
+	g := &Generator{presets: catalog}
+
+	container := newTestContainer("gitlab/gitlab-ce:17.5.1-ce.0", map[string]string{"watchcow.enable": "true"}, nat.PortMap{
+		"22/tcp": {{HostPort: "2222"}},
+		"80/tcp": {{HostPort: "8929"}},
+	})
+	config := g.extractConfig(container, nil)
+
+	if config.DisplayName != "GitLab" {
+		t.Errorf("DisplayName = %q, want GitLab", config.DisplayName)
+	}
+	if config.Port != "8929" {
+		t.Errorf("Port = %q, want 8929 (published preset port)", config.Port)
+	}
+	if config.Icon != "https://cdn.jsdelivr.net/gh/homarr-labs/dashboard-icons/png/gitlab.png" {
+		t.Errorf("Icon = %q", config.Icon)
+	}
+	if len(config.Entries) != 2 {
+		t.Fatalf("expected default + preset entry, got %d", len(config.Entries))
+	}
+	admin := config.Entries[1]
+	if admin.Name != "admin" || admin.Path != "/admin" || admin.Port != "8929" || admin.AllUsers {
+		t.Errorf("unexpected preset entry: %+v", admin)
+	}
+}
+
+// TestExtractConfig_PresetLabelsWin tests that labels take precedence over presets
+func TestExtractConfig_PresetLabelsWin(t *testing.T) {
+	catalog, err := LoadPresetCatalog("")
+	if err != nil {
+		t.Fatalf("LoadPresetCatalog() error = %v", err)
+	}
+	g := &Generator{presets: catalog}
+
+	labels := map[string]string{
+		"watchcow.enable":       "true",
+		"watchcow.display_name": "Media",
+		"watchcow.service_port": "9000",
+		"watchcow.path":         "/",
+		"watchcow.icon":         "file:///icons/media.png",
+	}
+	container := newTestContainer("jellyfin/jellyfin", labels, nat.PortMap{"8096/tcp": {{HostPort: "8096"}}})
+	config := g.extractConfig(container, nil)
+
+	if config.DisplayName != "Media" || config.Port != "9000" || config.Path != "/" || config.Icon != "file:///icons/media.png" {
+		t.Errorf("labels not preferred over preset: %+v", config)
+	}
+	if len(config.Entries) != 1 || config.Entries[0].Path != "/" {
+		t.Errorf("unexpected entries: %+v", config.Entries)
+	}
+
+	// Without labels the preset path applies
+	container = newTestContainer("jellyfin/jellyfin", map[string]string{"watchcow.enable": "true"}, nat.PortMap{"8096/tcp": {{HostPort: "8096"}}})
+	config = g.extractConfig(container, nil)
+	if config.Path != "/web/" || config.Entries[0].Path != "/web/" {
+		t.Errorf("preset path not applied: config=%q entry=%q", config.Path, config.Entries[0].Path)
+	}
+}
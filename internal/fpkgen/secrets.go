@@ -0,0 +1,70 @@
+package fpkgen
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// redactedValue replaces the values of secret environment variables
+const redactedValue = "******"
+
+// secretEnvPattern matches names of environment variables that usually hold secrets
+var secretEnvPattern = regexp.MustCompile(`(?i)(PASSWORD|PASSWD|PASSPHRASE|SECRET|TOKEN|CREDENTIAL|APIKEY|(^|_)(KEY|SALT|PW|DSN|DATABASE_URL)$)`)
+
+// secretEnv classifies the environment variables of a container:
+//
+//	watchcow.secret_env        -> KEY1,KEY2: secrets besides the names matching secretEnvPattern
+//	watchcow.secret_env.render -> KEY1,KEY2: secrets that may be rendered into packages
+type secretEnv struct {
+	extra  []string
+	render []string
+}
+
+// newSecretEnv reads the secret labels of a container
+func newSecretEnv(labels map[string]string) *secretEnv {
+	return &secretEnv{
+		extra:  splitList(labels["watchcow.secret_env"]),
+		render: splitList(labels["watchcow.secret_env.render"]),
+	}
+}
+
+// splitList splits a comma-separated label value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// isSecret reports whether an environment variable holds a secret
+func (s *secretEnv) isSecret(name string) bool {
+	return secretEnvPattern.MatchString(name) || slices.Contains(s.extra, name)
+}
+
+// hidden reports whether the value of an environment variable must not appear in
+// logs or generated packages: secrets not explicitly allowed by watchcow.secret_env.render
+func (s *secretEnv) hidden(name string) bool {
+	return s.isSecret(name) && !slices.Contains(s.render, name)
+}
+
+// redact returns env (KEY=VALUE) with the values of hidden variables replaced by redactedValue
+func (s *secretEnv) redact(env []string) []string {
+	var redacted []string
+	for _, e := range env {
+		if name, _, ok := strings.Cut(e, "="); ok && s.hidden(name) {
+			e = name + "=" + redactedValue
+		}
+		redacted = append(redacted, e)
+	}
+	return redacted
+}
+
+// RedactEnv returns env (KEY=VALUE) of a container with the given labels with the
+// values of all secrets redacted for logging, including those allowed into packages
+func RedactEnv(labels map[string]string, env []string) []string {
+	return (&secretEnv{extra: splitList(labels["watchcow.secret_env"])}).redact(env)
+}
@@ -0,0 +1,194 @@
+package fpkgen
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+// TestSecretEnv tests classifying environment variable names as secrets
+func TestSecretEnv(t *testing.T) {
+	secrets := newSecretEnv(map[string]string{
+		"watchcow.secret_env":        "SMTP_URL, LICENSE",
+		"watchcow.secret_env.render": "ADMIN_TOKEN",
+	})
+
+	tests := []struct {
+		name   string
+		secret bool
+		hidden bool
+	}{
+		{"DB_PASSWORD", true, true},
+		{"mysql_root_passwd", true, true},
+		{"GITHUB_TOKEN", true, true},
+		{"JWT_SECRET_FILE", true, true},
+		{"AWS_SECRET_ACCESS_KEY", true, true},
+		{"OPENAI_APIKEY", true, true},
+		{"KEY", true, true},
+		{"DATABASE_URL", true, true},
+		{"SMTP_URL", true, true},
+		{"LICENSE", true, true},
+		{"ADMIN_TOKEN", true, false},
+		{"TZ", false, false},
+		{"KEYBOARD_LAYOUT", false, false},
+		{"MONKEY_MODE", false, false},
+		{"PUID", false, false},
+	}
+	for _, tt := range tests {
+		if got := secrets.isSecret(tt.name); got != tt.secret {
+			t.Errorf("isSecret(%s) = %v, want %v", tt.name, got, tt.secret)
+		}
+		if got := secrets.hidden(tt.name); got != tt.hidden {
+			t.Errorf("hidden(%s) = %v, want %v", tt.name, got, tt.hidden)
+		}
+	}
+
+	env := []string{"TZ=UTC", "DB_PASSWORD=hunter2", "ADMIN_TOKEN=visible", "NOVALUE"}
+	want := []string{"TZ=UTC", "DB_PASSWORD=" + redactedValue, "ADMIN_TOKEN=visible", "NOVALUE"}
+	if got := secrets.redact(env); !reflect.DeepEqual(got, want) {
+		t.Errorf("redact() = %v, want %v", got, want)
+	}
+
+	// Logs never show secrets
+	labels := map[string]string{"watchcow.secret_env": "LICENSE", "watchcow.secret_env.render": "ADMIN_TOKEN"}
+	logged := RedactEnv(labels, []string{"LICENSE=abc", "ADMIN_TOKEN=visible", "TZ=UTC"})
+	if want := []string{"LICENSE=" + redactedValue, "ADMIN_TOKEN=" + redactedValue, "TZ=UTC"}; !reflect.DeepEqual(logged, want) {
+		t.Errorf("RedactEnv() = %v, want %v", logged, want)
+	}
+}
+
+// TestProperty_RedactHidesSecretValues tests that redacted secrets never keep their value
+func TestProperty_RedactHidesSecretValues(t *testing.T) {
+	secrets := newSecretEnv(nil)
+	f := func(value string) bool {
+		if strings.Contains(value, redactedValue) {
+			return true
+		}
+		for _, e := range secrets.redact([]string{"APP_SECRET=" + value, "PLAIN=" + value}) {
+			if strings.HasPrefix(e, "APP_SECRET=") && e != "APP_SECRET="+redactedValue {
+				return false
+			}
+			if strings.HasPrefix(e, "PLAIN=") && e != "PLAIN="+value {
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 100}); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestGenerateFromContainer_SecretsNotRendered tests that secret values stay out of generated packages
+func TestGenerateFromContainer_SecretsNotRendered(t *testing.T) {
+	catalog, err := LoadPresetCatalog("")
+	if err != nil {
+		t.Fatalf("LoadPresetCatalog() error = %v", err)
+	}
+	dir := t.TempDir()
+	writeTemplates(t, dir, map[string]string{"LICENSE.tmpl": `{{.Environment | join "\n"}}`})
+	engine, err := LoadTemplateEngine(dir)
+	if err != nil {
+		t.Fatalf("LoadTemplateEngine() error = %v", err)
+	}
+	g := &Generator{presets: catalog, templateEngine: engine, stateDir: t.TempDir()}
+
+	container := newTestContainer("nginx:latest", map[string]string{
+		"watchcow.enable":              "true",
+		"watchcow.config.env":          "TZ,DB_PASSWORD,PIN",
+		"watchcow.config.env.PIN.type": "number",
+		"watchcow.secret_env":          "PIN",
+		"watchcow.secret_env.render":   "PUBLIC_TOKEN",
+	}, nil)
+	container.Config.Env = []string{"TZ=UTC", "DB_PASSWORD=hunter2", "PIN=4711", "PUBLIC_TOKEN=shown"}
+	config := g.extractConfig(container, nil)
+
+	wantEnv := []string{"TZ=UTC", "DB_PASSWORD=" + redactedValue, "PIN=" + redactedValue, "PUBLIC_TOKEN=shown"}
+	if !reflect.DeepEqual(config.Environment, wantEnv) {
+		t.Errorf("Environment = %v, want %v", config.Environment, wantEnv)
+	}
+	wantFields := []ConfigEnv{
+		{Key: "TZ", Type: "text", Label: "TZ", Value: "UTC"},
+		{Key: "DB_PASSWORD", Type: "password", Label: "DB_PASSWORD", Secret: true},
+		{Key: "PIN", Type: "number", Label: "PIN", Secret: true},
+	}
+	if !reflect.DeepEqual(config.ConfigEnv, wantFields) {
+		t.Errorf("ConfigEnv = %+v, want %+v", config.ConfigEnv, wantFields)
+	}
+
+	appDir := filepath.Join(t.TempDir(), "app")
+	if err := g.GenerateFromConfig(config, appDir); err != nil {
+		t.Fatalf("GenerateFromConfig() error = %v", err)
+	}
+	err = filepath.WalkDir(appDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, secret := range []string{"hunter2", "4711"} {
+			if strings.Contains(string(data), secret) {
+				t.Errorf("%s contains secret %q", path, secret)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	license, _ := os.ReadFile(filepath.Join(appDir, "LICENSE"))
+	if !strings.Contains(string(license), "PUBLIC_TOKEN=shown") {
+		t.Errorf("explicitly allowed secret not rendered: %q", license)
+	}
+}
+
+// TestGenerateFromConfig_SecretConfigFields tests that empty secret fields keep their current value
+func TestGenerateFromConfig_SecretConfigFields(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+	engine, err := NewTemplateEngine()
+	if err != nil {
+		t.Fatalf("NewTemplateEngine() error = %v", err)
+	}
+	g := &Generator{templateEngine: engine, stateDir: t.TempDir()}
+	if err := createStateDir(g.stateDir); err != nil {
+		t.Fatal(err)
+	}
+	appDir := filepath.Join(t.TempDir(), "app")
+	config := &AppConfig{
+		AppName:     "watchcow.app",
+		ContainerID: "abc123",
+		ConfigEnv: []ConfigEnv{
+			{Key: "DB_PASSWORD", Type: "password", Label: "DB_PASSWORD", Secret: true},
+			{Key: "API_TOKEN", Type: "password", Label: "API_TOKEN", Secret: true},
+		},
+	}
+	if err := g.GenerateFromConfig(config, appDir); err != nil {
+		t.Fatalf("GenerateFromConfig() error = %v", err)
+	}
+
+	cmd := exec.Command(bash, filepath.Join(appDir, "cmd", "config_callback"))
+	cmd.Env = append(os.Environ(), "wizard_env_DB_PASSWORD=", "wizard_env_API_TOKEN=new")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("running cmd/config_callback: %v\n%s", err, out)
+	}
+	info, err := os.Stat(configRequestFile(g.stateDir, "watchcow.app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("config request permissions = %o, want 600", perm)
+	}
+	requests := g.TakeConfigRequests()
+	if len(requests) != 1 || !reflect.DeepEqual(requests[0].Env, map[string]string{"API_TOKEN": "new"}) {
+		t.Errorf("config requests = %+v", requests)
+	}
+}
@@ -0,0 +1,45 @@
+package fpkgen
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// stateSubdirs lists the state directory subdirectories app scripts write to
+var stateSubdirs = []string{"uninstalled", "config"}
+
+// DefaultStateDir returns the default directory of files shared with the scripts
+// of generated apps: $TRIM_PKGVAR/state when running as fnOS app, the user cache
+// directory otherwise. Returns empty string if no suitable directory exists.
+func DefaultStateDir() string {
+	if pkgVar := os.Getenv("TRIM_PKGVAR"); pkgVar != "" {
+		return filepath.Join(pkgVar, "state")
+	}
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(cacheDir, "watchcow", "state")
+	}
+	return ""
+}
+
+// createStateDir creates the subdirectories of the state directory. App scripts
+// run as root but WatchCow reads and removes the files they write.
+func createStateDir(stateDir string) error {
+	for _, sub := range stateSubdirs {
+		if err := os.MkdirAll(filepath.Join(stateDir, sub), 0755); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stateFile returns the file of an app in a state subdirectory, named by the
+// escaped app name. Returns empty string without a state directory.
+func stateFile(stateDir, sub, appName string) string {
+	name := url.PathEscape(appName)
+	if stateDir == "" || strings.Trim(name, ".") == "" {
+		return ""
+	}
+	return filepath.Join(stateDir, sub, name)
+}
@@ -0,0 +1,100 @@
+package fpkgen
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+)
+
+// svgRenderSize is the edge length SVG icons are rasterized at.
+// It matches the largest icon size so prepareIcon never upscales a vector icon.
+const svgRenderSize = 256
+
+// svgSniffLength limits how far into the data the SVG root element is searched
+const svgSniffLength = 4096
+
+// utf8BOM is the UTF-8 byte order mark some editors prepend to SVG files
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// isSVG reports whether data is an SVG document.
+// The XML declaration, comments and DOCTYPE before the root element are skipped;
+// the root element itself must be <svg>, so HTML pages with inline SVG are rejected.
+func isSVG(data []byte) bool {
+	if len(data) > svgSniffLength {
+		data = data[:svgSniffLength]
+	}
+	data = bytes.TrimPrefix(data, utf8BOM)
+
+	for {
+		data = bytes.TrimLeft(data, " \t\r\n")
+		if len(data) == 0 || data[0] != '<' {
+			return false
+		}
+
+		var end []byte
+		switch {
+		case bytes.HasPrefix(data, []byte("<?")):
+			end = []byte("?>")
+		case bytes.HasPrefix(data, []byte("<!--")):
+			end = []byte("-->")
+		case bytes.HasPrefix(data, []byte("<!")):
+			end = []byte(">")
+		default:
+			root := bytes.TrimPrefix(data[1:], []byte("svg:"))
+			if len(root) < 4 || !bytes.EqualFold(root[:3], []byte("svg")) {
+				return false
+			}
+			switch root[3] {
+			case ' ', '\t', '\r', '\n', '>', '/':
+				return true
+			}
+			return false
+		}
+
+		i := bytes.Index(data, end)
+		if i < 0 {
+			return false
+		}
+		data = data[i+len(end):]
+	}
+}
+
+// decodeSVG rasterizes an SVG document to a svgRenderSize x svgRenderSize image.
+// The viewBox (or width/height) is scaled to fit and centered, preserving the aspect ratio.
+// Elements the renderer does not support (text, filters, masks) are skipped.
+func decodeSVG(data []byte) (image.Image, error) {
+	icon, err := oksvg.ReadIconStream(bytes.NewReader(data), oksvg.IgnoreErrorMode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SVG: %w", err)
+	}
+
+	vb := icon.ViewBox
+	for _, v := range []float64{vb.X, vb.Y, vb.W, vb.H} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("SVG has no usable viewBox or width/height")
+		}
+	}
+	if vb.W <= 0 || vb.H <= 0 {
+		return nil, fmt.Errorf("SVG has no usable viewBox or width/height")
+	}
+
+	// Fit the viewBox into the target square, centered (preserveAspectRatio="xMidYMid meet")
+	size := float64(svgRenderSize)
+	scale := math.Min(size/vb.W, size/vb.H)
+	offsetX := (size - vb.W*scale) / 2
+	offsetY := (size - vb.H*scale) / 2
+	icon.Transform = rasterx.Identity.
+		Translate(offsetX, offsetY).
+		Scale(scale, scale).
+		Translate(-vb.X, -vb.Y)
+
+	img := image.NewRGBA(image.Rect(0, 0, svgRenderSize, svgRenderSize))
+	scanner := rasterx.NewScannerGV(svgRenderSize, svgRenderSize, img, img.Bounds())
+	icon.Draw(rasterx.NewDasher(svgRenderSize, svgRenderSize, scanner), 1.0)
+
+	return img, nil
+}
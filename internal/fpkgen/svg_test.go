@@ -0,0 +1,135 @@
+package fpkgen
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/quick"
+)
+
+// TestIsSVG tests SVG detection with prologs and non-SVG documents
+func TestIsSVG(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want bool
+	}{
+		{"plain", `<svg xmlns="http://www.w3.org/2000/svg"></svg>`, true},
+		{"xml declaration", `<?xml version="1.0"?>` + "\n" + `<svg viewBox="0 0 1 1"/>`, true},
+		{"bom and whitespace", "\xEF\xBB\xBF  \n<svg>", true},
+		{"comment and doctype", `<!-- logo --><!DOCTYPE svg PUBLIC "-//W3C//DTD SVG 1.1//EN" "x.dtd"><svg/>`, true},
+		{"namespace prefix", `<svg:svg xmlns:svg="http://www.w3.org/2000/svg"/>`, true},
+		{"uppercase", `<SVG>`, true},
+		{"html with inline svg", `<!DOCTYPE html><html><body><svg></svg></body></html>`, false},
+		{"svgz-like name", `<svgfoo>`, false},
+		{"unterminated comment", `<!-- <svg>`, false},
+		{"text", `svg`, false},
+		{"empty", ``, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSVG([]byte(tt.data)); got != tt.want {
+				t.Errorf("isSVG(%q) = %v, want %v", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDetectFormat_SVG tests that SVG is detected and binary formats still win
+func TestDetectFormat_SVG(t *testing.T) {
+	if got := detectFormat([]byte(`<svg viewBox="0 0 24 24"></svg>`)); got != FormatSVG {
+		t.Errorf("detectFormat(SVG data) = %v, want %v", got, FormatSVG)
+	}
+}
+
+// Property test: SVG detection is consistent
+// For any content after an <svg> root tag, detectFormat returns FormatSVG
+func TestProperty_SVGDetection(t *testing.T) {
+	f := func(suffix []byte) bool {
+		data := append([]byte(`<?xml version="1.0"?><svg `), suffix...)
+		return detectFormat(data) == FormatSVG
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 100}); err != nil {
+		t.Errorf("Property test failed: %v", err)
+	}
+}
+
+// TestDecodeSVG_ViewBox tests that the viewBox is fitted and centered in the output
+func TestDecodeSVG_ViewBox(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "test.svg"))
+	if err != nil {
+		t.Fatalf("Failed to read test.svg: %v", err)
+	}
+
+	img, err := decodeSVG(data)
+	if err != nil {
+		t.Fatalf("decodeSVG() error = %v", err)
+	}
+
+	// 64x32 viewBox scaled to 256 wide: content occupies rows 64..192
+	checks := []struct {
+		name       string
+		x, y       int
+		wantOpaque bool
+		wantRed    bool
+		wantBlue   bool
+	}{
+		{"top padding", 128, 20, false, false, false},
+		{"bottom padding", 128, 236, false, false, false},
+		{"red square", 64, 128, true, true, false},
+		{"gradient circle", 192, 128, true, false, true},
+	}
+
+	for _, c := range checks {
+		r, g, b, a := img.At(c.x, c.y).RGBA()
+		if opaque := a > 0xF000; opaque != c.wantOpaque {
+			t.Errorf("%s: alpha = %#x, want opaque=%v", c.name, a, c.wantOpaque)
+		}
+		if c.wantRed && (r < 0xF000 || g > 0x1000 || b > 0x1000) {
+			t.Errorf("%s: color = (%#x, %#x, %#x), want red", c.name, r, g, b)
+		}
+		if c.wantBlue && (b < 0x8000 || r > 0x1000 || g > 0x1000) {
+			t.Errorf("%s: color = (%#x, %#x, %#x), want blue", c.name, r, g, b)
+		}
+	}
+}
+
+// TestDecodeSVG_WidthHeight tests SVGs without viewBox fall back to width/height
+func TestDecodeSVG_WidthHeight(t *testing.T) {
+	data := []byte(`<svg xmlns="http://www.w3.org/2000/svg" width="16" height="16"><rect width="16" height="16" fill="lime"/></svg>`)
+
+	img, err := decodeSVG(data)
+	if err != nil {
+		t.Fatalf("decodeSVG() error = %v", err)
+	}
+	if img.Bounds() != image.Rect(0, 0, svgRenderSize, svgRenderSize) {
+		t.Errorf("bounds = %v", img.Bounds())
+	}
+	if _, g, _, a := img.At(250, 250).RGBA(); g < 0xF000 || a < 0xF000 {
+		t.Errorf("expected lime fill at the corner, got g=%#x a=%#x", g, a)
+	}
+}
+
+// TestDecodeSVG_Errors tests error handling for SVGs that cannot be rendered
+func TestDecodeSVG_Errors(t *testing.T) {
+	tests := map[string]string{
+		"no size":     `<svg xmlns="http://www.w3.org/2000/svg"><rect width="1" height="1"/></svg>`,
+		"zero width":  `<svg viewBox="0 0 0 10"></svg>`,
+		"bad viewBox": `<svg viewBox="0 0 10"></svg>`,
+		"NaN width":   `<svg viewBox="0 0 NaN 10"></svg>`,
+		"NaN height":  `<svg width="10" height="NaN"></svg>`,
+		"NaN origin":  `<svg viewBox="NaN 0 10 10"></svg>`,
+		"Inf width":   `<svg viewBox="0 0 Inf 10"></svg>`,
+		"bad xml":     `<svg viewBox="0 0 10 10"><path d="M0 0"`,
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := decodeIconData([]byte(data)); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
@@ -5,8 +5,12 @@ import (
 	"embed"
 	"encoding/json"
 	"fmt"
+	"io"
+	"maps"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"text/template"
 )
 
@@ -16,12 +20,26 @@ var templateFS embed.FS
 // TemplateEngine handles template loading and rendering
 type TemplateEngine struct {
 	templates map[string]*template.Template
+	sets      map[string]*TemplateEngine // Named template sets selected by watchcow.template_set
 }
 
 // NewTemplateEngine creates a new template engine with embedded templates
 func NewTemplateEngine() (*TemplateEngine, error) {
+	return LoadTemplateEngine("")
+}
+
+// LoadTemplateEngine creates a template engine with the embedded templates,
+// overridden by the templates in overrideDir (empty = none):
+//
+//	<overrideDir>/<name>.tmpl       -> replaces the embedded template of the same name
+//	<overrideDir>/<set>/<name>.tmpl -> replaces it for containers labeled watchcow.template_set=<set>
+//
+// Other files are ignored. All templates are test-rendered, so errors in any of
+// them are reported here with file and line instead of at package generation.
+func LoadTemplateEngine(overrideDir string) (*TemplateEngine, error) {
 	engine := &TemplateEngine{
 		templates: make(map[string]*template.Template),
+		sets:      make(map[string]*TemplateEngine),
 	}
 
 	// Load all embedded templates
@@ -41,7 +59,7 @@ func NewTemplateEngine() (*TemplateEngine, error) {
 			return nil, fmt.Errorf("failed to read template %s: %w", name, err)
 		}
 
-		tmpl, err := template.New(name).Parse(string(content))
+		tmpl, err := template.New(name).Funcs(templateFuncs).Parse(string(content))
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
 		}
@@ -49,9 +67,124 @@ func NewTemplateEngine() (*TemplateEngine, error) {
 		engine.templates[name] = tmpl
 	}
 
+	if overrideDir != "" {
+		if err := engine.loadOverrides(overrideDir); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := engine.validate(); err != nil {
+		return nil, err
+	}
+	for name, set := range engine.sets {
+		if err := set.validate(); err != nil {
+			return nil, fmt.Errorf("template set %s: %w", name, err)
+		}
+	}
+
 	return engine, nil
 }
 
+// loadOverrides loads the template overrides and template sets of a directory
+func (e *TemplateEngine) loadOverrides(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read template directory: %w", err)
+	}
+
+	// Template sets start from the overridden default templates
+	var sets []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			sets = append(sets, entry.Name())
+			continue
+		}
+		if err := e.loadOverride(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range sets {
+		set := &TemplateEngine{templates: maps.Clone(e.templates)}
+		files, err := os.ReadDir(filepath.Join(dir, name))
+		if err != nil {
+			return fmt.Errorf("failed to read template set %s: %w", name, err)
+		}
+		for _, file := range files {
+			if file.IsDir() {
+				continue
+			}
+			if err := set.loadOverride(filepath.Join(dir, name, file.Name())); err != nil {
+				return err
+			}
+		}
+		e.sets[name] = set
+	}
+
+	return nil
+}
+
+// loadOverride replaces the template named like a file by the file's content
+func (e *TemplateEngine) loadOverride(path string) error {
+	name := filepath.Base(path)
+	if !strings.HasSuffix(name, ".tmpl") {
+		return nil
+	}
+	if _, ok := e.templates[name]; !ok {
+		return fmt.Errorf("unknown template %s (expected one of %s)", path, strings.Join(e.ListTemplates(), ", "))
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read template %s: %w", path, err)
+	}
+
+	// Named by path so errors point to the file
+	tmpl, err := template.New(path).Funcs(templateFuncs).Parse(string(content))
+	if err != nil {
+		return fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+
+	e.templates[name] = tmpl
+	return nil
+}
+
+// validate renders all templates with sample data to catch errors like unknown fields
+func (e *TemplateEngine) validate() error {
+	data := NewTemplateData(&AppConfig{
+		AppName:     "watchcow.sample",
+		Version:     "1.0.0",
+		DisplayName: "Sample",
+		Port:        "8080",
+		Entries:     []Entry{{Title: "Sample", Port: "8080"}, {Name: "admin", Title: "Sample - admin"}},
+	})
+	data.Hook = &HookData{Name: "uninstall_init"}
+	data.UninstallMarker = "/var/lib/watchcow/state/uninstalled/watchcow.sample"
+	data.ConfigRequest = "/var/lib/watchcow/state/config/watchcow.sample"
+	data.ConfigEnv = []ConfigEnv{
+		{Key: "TZ", Type: "text", Label: "TZ", Description: "Time zone", Value: "Asia/Shanghai"},
+		{Key: "LOG_LEVEL", Type: "select", Label: "LOG_LEVEL", Options: []string{"info", "debug"}, Value: "info"},
+	}
+	for _, name := range e.ListTemplates() {
+		if err := e.templates[name].Execute(io.Discard, data); err != nil {
+			return fmt.Errorf("invalid template %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Set returns the template set selected by watchcow.template_set=name.
+// Returns the default templates and false if there is no such set.
+func (e *TemplateEngine) Set(name string) (*TemplateEngine, bool) {
+	if name == "" {
+		return e, true
+	}
+	if set, ok := e.sets[name]; ok {
+		return set, true
+	}
+	return e, false
+}
+
 // Render renders a template with the given data
 func (e *TemplateEngine) Render(templateName string, data interface{}) ([]byte, error) {
 	tmpl, ok := e.templates[templateName]
@@ -83,12 +216,13 @@ func (e *TemplateEngine) RenderToFile(templateName, filePath string, data interf
 	return os.WriteFile(filePath, content, perm)
 }
 
-// ListTemplates returns all available template names
+// ListTemplates returns all available template names, sorted
 func (e *TemplateEngine) ListTemplates() []string {
 	names := make([]string, 0, len(e.templates))
 	for name := range e.templates {
 		names = append(names, name)
 	}
+	sort.Strings(names)
 	return names
 }
 
@@ -101,16 +235,17 @@ type UIConfigControl struct {
 
 // UIConfigEntry represents a single entry in UI config JSON
 type UIConfigEntry struct {
-	Title     string           `json:"title"`
-	Icon      string           `json:"icon"`
-	Type      string           `json:"type"`
-	Protocol  string           `json:"protocol"`
-	Port      string           `json:"port"`
-	URL       string           `json:"url"`
-	AllUsers  bool             `json:"allUsers"`
-	FileTypes []string         `json:"fileTypes,omitempty"`
-	NoDisplay bool             `json:"noDisplay,omitempty"`
-	Control   *UIConfigControl `json:"control,omitempty"`
+	Title     string            `json:"title"`
+	TitleI18n map[string]string `json:"titleI18n,omitempty"`
+	Icon      string            `json:"icon"`
+	Type      string            `json:"type"`
+	Protocol  string            `json:"protocol"`
+	Port      string            `json:"port"`
+	URL       string            `json:"url"`
+	AllUsers  bool              `json:"allUsers"`
+	FileTypes []string          `json:"fileTypes,omitempty"`
+	NoDisplay bool              `json:"noDisplay,omitempty"`
+	Control   *UIConfigControl  `json:"control,omitempty"`
 }
 
 // UIConfig represents the complete UI config JSON structure
@@ -136,6 +271,7 @@ func GenerateUIConfigJSON(data *TemplateData) ([]byte, error) {
 
 		config.URL[entry.FullName] = &UIConfigEntry{
 			Title:     entry.Title,
+			TitleI18n: entry.TitleI18n,
 			Icon:      entry.Icon,
 			Type:      entry.UIType,
 			Protocol:  entry.Protocol,
@@ -160,9 +296,10 @@ type EntryControlData struct {
 
 // EntryData holds data for a single UI entry in template rendering
 type EntryData struct {
-	Name      string // Entry name (empty for default)
-	FullName  string // Full entry name: AppName or AppName.EntryName
-	Title     string // Display title
+	Name      string            // Entry name (empty for default)
+	FullName  string            // Full entry name: AppName or AppName.EntryName
+	Title     string            // Display title
+	TitleI18n map[string]string // Localized titles (locale -> title)
 	Protocol  string
 	Port      string
 	Path      string
@@ -182,12 +319,19 @@ type TemplateData struct {
 	DisplayName string
 	Description string
 	Maintainer  string
+	Homepage    string
 
 	// Container
 	ContainerID   string
 	ContainerName string
 	Image         string
 
+	// Image reference components
+	ImageRegistry   string
+	ImageRepository string
+	ImageTag        string
+	ImageDigest     string
+
 	// Network/UI (legacy single entry - kept for backward compatibility)
 	Protocol string
 	Port     string
@@ -200,35 +344,62 @@ type TemplateData struct {
 	DefaultLaunchEntry string // The entry name to use for desktop_applaunchname (first entry's FullName)
 
 	// Collections
-	Ports       []string
-	Volumes     []VolumeMapping
-	Environment []string
+	Ports        []string
+	Volumes      []VolumeMapping
+	NamedVolumes []string // Names of the named volumes in Volumes
+	Environment  []string
+	ConfigEnv    []ConfigEnv // Fields of the config wizard (wizard/config, empty = none)
 
 	// Other
 	RestartPolicy string
 	Icon          string
+	Labels        map[string]string // Container labels (e.g. for custom templates)
+
+	// Docker Compose project of the container (empty if not started by Compose)
+	ComposeProject string
+	ComposeDir     string
+
+	// Lifecycle hook being rendered (cmd_hook.tmpl and cmd_uninstall_callback.tmpl, nil if not configured)
+	Hook *HookData
+
+	// File the uninstall callback writes the container ID to so that WatchCow does not
+	// reinstall an app uninstalled from App Center (empty without a state directory)
+	UninstallMarker string
+
+	// File the config callback writes new environment values to (empty without a state directory)
+	ConfigRequest string
 }
 
 // NewTemplateData creates TemplateData from AppConfig
 func NewTemplateData(config *AppConfig) *TemplateData {
 	data := &TemplateData{
-		AppName:       config.AppName,
-		Version:       config.Version,
-		DisplayName:   config.DisplayName,
-		Description:   escapeForTemplate(config.Description),
-		Maintainer:    config.Maintainer,
-		ContainerID:   config.ContainerID,
-		ContainerName: config.ContainerName,
-		Image:         config.Image,
-		Protocol:      config.Protocol,
-		Port:          config.Port,
-		Path:          config.Path,
-		UIType:        config.UIType,
-		AllUsers:      config.AllUsers,
-		Volumes:       config.Volumes,
-		Environment:   config.Environment,
-		RestartPolicy: config.RestartPolicy,
-		Icon:          config.Icon,
+		AppName:         config.AppName,
+		Version:         config.Version,
+		DisplayName:     escapeForTemplate(config.DisplayName),
+		Description:     escapeForTemplate(renderDescription(config.Description)),
+		Maintainer:      escapeForTemplate(config.Maintainer),
+		Homepage:        escapeForTemplate(config.Homepage),
+		ContainerID:     config.ContainerID,
+		ContainerName:   config.ContainerName,
+		Image:           config.Image,
+		ImageRegistry:   config.ImageRef.Registry,
+		ImageRepository: config.ImageRef.Repository,
+		ImageTag:        config.ImageRef.Tag,
+		ImageDigest:     config.ImageRef.Digest,
+		Protocol:        config.Protocol,
+		Port:            config.Port,
+		Path:            config.Path,
+		UIType:          config.UIType,
+		AllUsers:        config.AllUsers,
+		Volumes:         config.Volumes,
+		NamedVolumes:    namedVolumes(config.Volumes),
+		Environment:     config.Environment,
+		ConfigEnv:       config.ConfigEnv,
+		RestartPolicy:   config.RestartPolicy,
+		Icon:            config.Icon,
+		Labels:          config.Labels,
+		ComposeProject:  config.Labels["com.docker.compose.project"],
+		ComposeDir:      getBasePath(config.Labels),
 	}
 
 	// Set defaults
@@ -293,6 +464,7 @@ func NewTemplateData(config *AppConfig) *TemplateData {
 			Name:      entry.Name,
 			FullName:  fullName,
 			Title:     entry.Title,
+			TitleI18n: entry.TitleI18n,
 			Protocol:  protocol,
 			Port:      entry.Port,
 			Path:      path,
@@ -319,6 +491,7 @@ func NewTemplateData(config *AppConfig) *TemplateData {
 // escapeForTemplate escapes special characters for template output
 func escapeForTemplate(s string) string {
 	// For manifest, replace newlines
+	s = replaceAll(s, "\r", " ")
 	s = replaceAll(s, "\n", " ")
 	return s
 }
@@ -0,0 +1,53 @@
+package fpkgen
+
+import (
+	"encoding/json"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs are the helper functions available in all templates:
+//
+//	{{.Maintainer | default "WatchCow"}}  -> fallback for empty values
+//	{{.Environment | join ","}}           -> joins a list
+//	{{.ContainerName | shellQuote}}       -> single-quoted shell word
+//	{{.Labels | json}}                    -> JSON encoding
+//	{{.Description | oneLine}}            -> newlines collapsed for manifest values
+//	{{lower .AppName}}, upper, trim       -> string helpers
+var templateFuncs = template.FuncMap{
+	"default":    templateDefault,
+	"join":       templateJoin,
+	"shellQuote": shellQuote,
+	"json":       templateJSON,
+	"oneLine":    escapeForTemplate,
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"trim":       strings.TrimSpace,
+}
+
+// templateDefault returns val, or def if val is empty
+func templateDefault(def, val string) string {
+	if val == "" {
+		return def
+	}
+	return val
+}
+
+// templateJoin joins elems with sep (separator first for use in pipelines)
+func templateJoin(sep string, elems []string) string {
+	return strings.Join(elems, sep)
+}
+
+// shellQuote quotes s as a single shell word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// templateJSON encodes v as JSON
+func templateJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,175 @@
+package fpkgen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTemplates writes template files below dir
+func writeTemplates(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestLoadTemplateEngine_Overrides tests replacing embedded templates and selecting template sets
+func TestLoadTemplateEngine_Overrides(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplates(t, dir, map[string]string{
+		"LICENSE.tmpl":               "Custom license for {{.DisplayName}}",
+		"README.md":                  "not a template",
+		"internal/cmd_main.tmpl":     "#!/bin/sh\necho {{.ContainerName | shellQuote}}",
+		"internal/LICENSE.tmpl":      "Internal use only",
+		"internal/notes/manual.tmpl": "ignored",
+	})
+
+	engine, err := LoadTemplateEngine(dir)
+	if err != nil {
+		t.Fatalf("LoadTemplateEngine() error = %v", err)
+	}
+	data := NewTemplateData(&AppConfig{AppName: "watchcow.app", DisplayName: "App", ContainerName: "it's"})
+
+	render := func(e *TemplateEngine, name string) string {
+		t.Helper()
+		out, err := e.Render(name, data)
+		if err != nil {
+			t.Fatalf("Render(%s) error = %v", name, err)
+		}
+		return string(out)
+	}
+
+	if got := render(engine, "LICENSE.tmpl"); got != "Custom license for App" {
+		t.Errorf("overridden LICENSE = %q", got)
+	}
+	if got := render(engine, "manifest.tmpl"); !strings.Contains(got, "appname=watchcow.app") {
+		t.Errorf("embedded manifest not kept: %q", got)
+	}
+
+	set, ok := engine.Set("internal")
+	if !ok {
+		t.Fatal("template set internal not found")
+	}
+	if got := render(set, "cmd_main.tmpl"); got != "#!/bin/sh\necho 'it'\\''s'" {
+		t.Errorf("template set cmd/main = %q", got)
+	}
+	if got := render(set, "LICENSE.tmpl"); got != "Internal use only" {
+		t.Errorf("template set LICENSE = %q", got)
+	}
+	if got := render(set, "manifest.tmpl"); !strings.Contains(got, "appname=watchcow.app") {
+		t.Errorf("template set should fall back to the default manifest: %q", got)
+	}
+
+	if fallback, ok := engine.Set("missing"); ok || fallback != engine {
+		t.Error("unknown template set should return the default templates")
+	}
+}
+
+// TestLoadTemplateEngine_Errors tests that invalid overrides are reported with file and line
+func TestLoadTemplateEngine_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			name:  "parse error",
+			files: map[string]string{"manifest.tmpl": "appname={{.AppName}}\ndesc={{.Description"},
+			want:  []string{"manifest.tmpl:2"},
+		},
+		{
+			name:  "unknown field",
+			files: map[string]string{"set/LICENSE.tmpl": "line one\n{{.Licence}}"},
+			want:  []string{"template set set", filepath.Join("set", "LICENSE.tmpl") + ":2", "Licence"},
+		},
+		{
+			name:  "unknown function",
+			files: map[string]string{"LICENSE.tmpl": "{{.DisplayName | shout}}"},
+			want:  []string{"LICENSE.tmpl:1", "shout"},
+		},
+		{
+			name:  "unknown template",
+			files: map[string]string{"manfest.tmpl": "appname={{.AppName}}"},
+			want:  []string{"unknown template", "manfest.tmpl", "manifest.tmpl"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTemplates(t, dir, tt.files)
+			_, err := LoadTemplateEngine(dir)
+			if err == nil {
+				t.Fatal("LoadTemplateEngine() error = nil")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+
+	if _, err := LoadTemplateEngine(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("missing template directory should be an error")
+	}
+}
+
+// TestTemplateFuncs tests the template helper functions
+func TestTemplateFuncs(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplates(t, dir, map[string]string{
+		"LICENSE.tmpl": `{{.Maintainer | default "nobody"}}|{{.Environment | join ","}}|{{.Labels | json}}|{{.DisplayName | oneLine | upper}}|{{trim "  x "}}`,
+	})
+	engine, err := LoadTemplateEngine(dir)
+	if err != nil {
+		t.Fatalf("LoadTemplateEngine() error = %v", err)
+	}
+
+	out, err := engine.Render("LICENSE.tmpl", NewTemplateData(&AppConfig{
+		DisplayName: "My\nApp",
+		Environment: []string{"A=1", "B=2"},
+		Labels:      map[string]string{"watchcow.enable": "true"},
+	}))
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	want := `nobody|A=1,B=2|{"watchcow.enable":"true"}|MY APP|x`
+	if string(out) != want {
+		t.Errorf("Render() = %q, want %q", out, want)
+	}
+}
+
+// TestGenerateFromConfig_TemplateSet tests that packages use the template set of the app
+func TestGenerateFromConfig_TemplateSet(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplates(t, dir, map[string]string{"custom/LICENSE.tmpl": "Custom"})
+	engine, err := LoadTemplateEngine(dir)
+	if err != nil {
+		t.Fatalf("LoadTemplateEngine() error = %v", err)
+	}
+	g := &Generator{templateEngine: engine}
+
+	for set, want := range map[string]string{"custom": "Custom", "missing": "MIT"} {
+		appDir := filepath.Join(t.TempDir(), "app")
+		config := &AppConfig{AppName: "watchcow.app", DisplayName: "App", TemplateSet: set}
+		if err := g.GenerateFromConfig(config, appDir); err != nil {
+			t.Fatalf("GenerateFromConfig() error = %v", err)
+		}
+		license, err := os.ReadFile(filepath.Join(appDir, "LICENSE"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(license), want) {
+			t.Errorf("set %q: LICENSE = %q, want %q", set, license, want)
+		}
+	}
+}
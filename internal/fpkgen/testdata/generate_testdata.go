@@ -10,12 +10,15 @@ import (
 	"fmt"
 	"image"
 	"image/color"
+	"image/color/palette"
+	"image/gif"
 	"image/jpeg"
 	"image/png"
 	"os"
 	"os/exec"
 
 	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
 )
 
 func main() {
@@ -71,6 +74,27 @@ func main() {
 	}
 	fmt.Println("Created test_multi.ico")
 
+	// Generate animated GIF whose first frame is blank
+	if err := saveAnimatedGIF("test.gif", img); err != nil {
+		fmt.Printf("Failed to create test.gif: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("Created test.gif")
+
+	// Generate TIFF
+	if err := saveTIFF("test.tiff", img); err != nil {
+		fmt.Printf("Failed to create test.tiff: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("Created test.tiff")
+
+	// Generate ICNS with a legacy 16x16 RLE icon and a 64x64 PNG icon
+	if err := saveICNS("test.icns", img16, img64); err != nil {
+		fmt.Printf("Failed to create test.icns: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("Created test.icns")
+
 	// Generate invalid binary file
 	if err := os.WriteFile("invalid.bin", []byte{0x12, 0x34, 0x56, 0x78, 0x9A, 0xBC, 0xDE, 0xF0}, 0644); err != nil {
 		fmt.Printf("Failed to create invalid.bin: %v\n", err)
@@ -205,3 +229,92 @@ func saveICO(filename string, images []image.Image) error {
 
 	return os.WriteFile(filename, icoData, 0644)
 }
+
+func saveAnimatedGIF(filename string, img image.Image) error {
+	bounds := img.Bounds()
+
+	// Frame 1: fully transparent
+	blank := image.NewPaletted(bounds, append(color.Palette{color.Transparent}, palette.Plan9[:255]...))
+
+	// Frame 2: the actual image
+	full := image.NewPaletted(bounds, palette.Plan9)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			full.Set(x, y, img.At(x, y))
+		}
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return gif.EncodeAll(f, &gif.GIF{
+		Image:    []*image.Paletted{blank, full},
+		Delay:    []int{10, 10},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalNone},
+	})
+}
+
+func saveTIFF(filename string, img image.Image) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return tiff.Encode(f, img, nil)
+}
+
+func saveICNS(filename string, small image.Image, large image.Image) error {
+	// is32: RLE-compressed planar RGB, s8mk: 8-bit alpha mask
+	bounds := small.Bounds()
+	var r, g, b, mask []byte
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(small.At(x, y)).(color.NRGBA)
+			r = append(r, c.R)
+			g = append(g, c.G)
+			b = append(b, c.B)
+			mask = append(mask, c.A)
+		}
+	}
+	var rle []byte
+	for _, channel := range [][]byte{r, g, b} {
+		// Literal runs of at most 128 bytes
+		for len(channel) > 0 {
+			n := len(channel)
+			if n > 128 {
+				n = 128
+			}
+			rle = append(rle, byte(n-1))
+			rle = append(rle, channel[:n]...)
+			channel = channel[n:]
+		}
+	}
+
+	// ic12: PNG data
+	var pngBuf bytes.Buffer
+	if err := png.Encode(&pngBuf, large); err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	for _, elem := range []struct {
+		typ  string
+		data []byte
+	}{
+		{"is32", rle},
+		{"s8mk", mask},
+		{"ic12", pngBuf.Bytes()},
+	} {
+		body.WriteString(elem.typ)
+		binary.Write(&body, binary.BigEndian, uint32(8+len(elem.data)))
+		body.Write(elem.data)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("icns")
+	binary.Write(&out, binary.BigEndian, uint32(8+body.Len()))
+	out.Write(body.Bytes())
+	return os.WriteFile(filename, out.Bytes(), 0644)
+}
@@ -125,6 +125,80 @@ func TestLoadTestData_Invalid(t *testing.T) {
 	}
 }
 
+func TestLoadTestData_SVG(t *testing.T) {
+	path := filepath.Join("testdata", "test.svg")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		t.Skip("testdata/test.svg not found")
+	}
+
+	img, err := loadLocalIcon(path)
+	if err != nil {
+		t.Fatalf("Failed to load SVG: %v", err)
+	}
+
+	// SVG icons are rasterized at the largest icon size
+	bounds := img.Bounds()
+	if bounds.Dx() != svgRenderSize || bounds.Dy() != svgRenderSize {
+		t.Errorf("Expected %dx%d image, got %dx%d", svgRenderSize, svgRenderSize, bounds.Dx(), bounds.Dy())
+	}
+}
+
+func TestLoadTestData_GIF(t *testing.T) {
+	path := filepath.Join("testdata", "test.gif")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		t.Skip("testdata/test.gif not found, run 'go run testdata/generate_testdata.go' to create")
+	}
+
+	img, err := loadLocalIcon(path)
+	if err != nil {
+		t.Fatalf("Failed to load GIF: %v", err)
+	}
+
+	// The first frame is blank; the best frame is the full image
+	bounds := img.Bounds()
+	if bounds.Dx() != 32 || bounds.Dy() != 32 {
+		t.Errorf("Expected 32x32 image, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+	if _, _, _, a := img.At(16, 16).RGBA(); a != 0xFFFF {
+		t.Errorf("Expected opaque pixel from the second frame, got alpha %#x", a)
+	}
+}
+
+func TestLoadTestData_TIFF(t *testing.T) {
+	path := filepath.Join("testdata", "test.tiff")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		t.Skip("testdata/test.tiff not found, run 'go run testdata/generate_testdata.go' to create")
+	}
+
+	img, err := loadLocalIcon(path)
+	if err != nil {
+		t.Fatalf("Failed to load TIFF: %v", err)
+	}
+
+	bounds := img.Bounds()
+	if bounds.Dx() != 32 || bounds.Dy() != 32 {
+		t.Errorf("Expected 32x32 image, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+}
+
+func TestLoadTestData_ICNS(t *testing.T) {
+	path := filepath.Join("testdata", "test.icns")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		t.Skip("testdata/test.icns not found, run 'go run testdata/generate_testdata.go' to create")
+	}
+
+	img, err := loadLocalIcon(path)
+	if err != nil {
+		t.Fatalf("Failed to load ICNS: %v", err)
+	}
+
+	// ICNS test file contains a 16x16 RLE icon and a 64x64 PNG icon
+	bounds := img.Bounds()
+	if bounds.Dx() != 64 || bounds.Dy() != 64 {
+		t.Errorf("Expected 64x64 image, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+}
+
 func TestDetectFormat_TestDataFiles(t *testing.T) {
 	tests := []struct {
 		filename string
@@ -136,6 +210,10 @@ func TestDetectFormat_TestDataFiles(t *testing.T) {
 		{"test.webp", FormatWebP},
 		{"test.ico", FormatICO},
 		{"test_multi.ico", FormatICO},
+		{"test.svg", FormatSVG},
+		{"test.gif", FormatGIF},
+		{"test.tiff", FormatTIFF},
+		{"test.icns", FormatICNS},
 		{"invalid.bin", FormatUnknown},
 	}
 
@@ -1,5 +1,7 @@
 package fpkgen
 
+import "image/color"
+
 // EntryControl represents permission settings for an entry
 type EntryControl struct {
 	AccessPerm string // "editable", "readonly", "hidden" - who can access setting
@@ -9,17 +11,22 @@ type EntryControl struct {
 
 // Entry represents a single UI entry point
 type Entry struct {
-	Name      string        // Entry identifier (empty for default, "admin" for admin entry, etc.)
-	Title     string        // Display title in UI config
-	Protocol  string        // http or https
-	Port      string        // service_port
-	Path      string        // URL path
-	UIType    string        // "url" or "iframe"
-	AllUsers  bool          // Access permission
-	Icon      string        // Icon URL or file path
-	FileTypes []string      // Supported file types for right-click menu
-	NoDisplay bool          // Hide from desktop (only show in right-click menu)
-	Control   *EntryControl // Permission control settings
+	Name       string            // Entry identifier (empty for default, "admin" for admin entry, etc.)
+	Title      string            // Display title in UI config
+	TitleI18n  map[string]string // Localized titles (locale -> title)
+	Protocol   string            // http or https
+	Port       string            // service_port
+	Path       string            // URL path
+	UIType     string            // "url" or "iframe"
+	AllUsers   bool              // Access permission
+	Icon       string            // Icon URL or file path
+	IconStyle  IconStyle         // Icon background, padding, shape and trimming
+	SizedIcons map[int]string    // Hand-tuned icon sources per icon size (pixels -> source), overriding Icon
+	Badge      string            // Corner badge over the entry icon: text or icon source (named entries only)
+	BadgeColor color.Color       // Background of text badges (nil = derived from the entry)
+	FileTypes  []string          // Supported file types for right-click menu
+	NoDisplay  bool              // Hide from desktop (only show in right-click menu)
+	Control    *EntryControl     // Permission control settings
 }
 
 // AppConfig holds all configuration for generating an fnOS app
@@ -28,13 +35,18 @@ type AppConfig struct {
 	AppName     string // manifest.appname - Unique app identifier
 	Version     string // manifest.version - App version (e.g., "1.0.0")
 	DisplayName string // manifest.display_name - Human-readable name
-	Description string // manifest.desc - App description
+	Description string // manifest.desc - App description (Markdown or HTML)
 	Maintainer  string // manifest.maintainer - Developer/maintainer name
+	Homepage    string // manifest.maintainer_url - Project homepage
+
+	// Localized display names (locale -> text) used for entry titles
+	DisplayNameI18n map[string]string
 
 	// Container Info
 	ContainerID   string
 	ContainerName string
 	Image         string
+	ImageRef      ImageRef // Parsed Image (registry, repository, tag, digest)
 
 	// Network / UI (legacy single entry - kept for backward compatibility)
 	Protocol string // http or https
@@ -50,11 +62,14 @@ type AppConfig struct {
 	Volumes []VolumeMapping
 
 	// Environment
-	Environment []string
+	Environment []string    // KEY=VALUE, secret values redacted (see secretEnv)
+	ConfigEnv   []ConfigEnv // Environment variables editable in the config wizard
 
 	// Metadata
 	Icon          string
 	RestartPolicy string
+	TemplateSet   string            // Template set of the package files (empty = default templates)
+	Hooks         map[string]string // Lifecycle hook scripts (fnOS script name -> content)
 
 	// Labels (original watchcow labels)
 	Labels map[string]string
@@ -66,4 +81,5 @@ type VolumeMapping struct {
 	Destination string
 	ReadOnly    bool
 	Type        string // "bind" or "volume"
+	Name        string // Volume name (named volumes only)
 }
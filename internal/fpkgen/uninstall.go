@@ -0,0 +1,55 @@
+package fpkgen
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// uninstallMarker returns the file the uninstall callback of an app writes the
+// container ID to when the user uninstalls the app from App Center.
+// Returns empty string without a state directory.
+func uninstallMarker(stateDir, appName string) string {
+	return stateFile(stateDir, "uninstalled", appName)
+}
+
+// namedVolumes returns the names of the named volumes mounted into a container
+func namedVolumes(volumes []VolumeMapping) []string {
+	var names []string
+	for _, v := range volumes {
+		if v.Type == "volume" && v.Name != "" {
+			names = append(names, v.Name)
+		}
+	}
+	return names
+}
+
+// UninstalledByUser reports whether the user uninstalled the app of a container
+// from App Center, in which case it must not be installed again. A marker left
+// by a previous container is removed: recreating the container reinstalls the app.
+func (g *Generator) UninstalledByUser(appName, containerID string) bool {
+	marker := uninstallMarker(g.stateDir, appName)
+	if marker == "" {
+		return false
+	}
+	data, err := os.ReadFile(marker)
+	if err != nil {
+		return false
+	}
+	if strings.TrimSpace(string(data)) == containerID {
+		return true
+	}
+	g.ClearUninstalled(appName)
+	return false
+}
+
+// ClearUninstalled removes the uninstall marker of an app
+func (g *Generator) ClearUninstalled(appName string) {
+	marker := uninstallMarker(g.stateDir, appName)
+	if marker == "" {
+		return
+	}
+	if err := os.Remove(marker); err != nil && !os.IsNotExist(err) {
+		slog.Warn("Failed to remove uninstall marker", "app", appName, "file", marker, "error", err)
+	}
+}
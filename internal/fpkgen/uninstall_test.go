@@ -0,0 +1,148 @@
+package fpkgen
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestUninstalledByUser tests uninstall markers of the current and of previous containers
+func TestUninstalledByUser(t *testing.T) {
+	g := &Generator{stateDir: t.TempDir()}
+	marker := uninstallMarker(g.stateDir, "watchcow.app")
+	os.MkdirAll(filepath.Dir(marker), 0755)
+
+	if g.UninstalledByUser("watchcow.app", "abc123") {
+		t.Error("app without marker reported as uninstalled")
+	}
+
+	os.WriteFile(marker, []byte("abc123\n"), 0644)
+	if !g.UninstalledByUser("watchcow.app", "abc123") {
+		t.Error("marker of the current container not recognized")
+	}
+
+	// A recreated container installs the app again
+	if g.UninstalledByUser("watchcow.app", "def456") {
+		t.Error("marker of a previous container should be ignored")
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Error("stale marker should be removed")
+	}
+
+	if m := uninstallMarker("", "watchcow.app"); m != "" {
+		t.Errorf("uninstallMarker() without state dir = %q", m)
+	}
+	for _, name := range []string{"..", "."} {
+		if m := uninstallMarker(g.stateDir, name); m != "" {
+			t.Errorf("uninstallMarker(%q) = %q", name, m)
+		}
+	}
+	if m := uninstallMarker(g.stateDir, "../x"); filepath.Dir(m) != filepath.Dir(marker) {
+		t.Errorf("uninstallMarker(../x) = %q escapes the marker directory", m)
+	}
+}
+
+// TestGenerateFromConfig_UninstallWizard tests the uninstall wizard and the callback applying its choices
+func TestGenerateFromConfig_UninstallWizard(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+	engine, err := NewTemplateEngine()
+	if err != nil {
+		t.Fatalf("NewTemplateEngine() error = %v", err)
+	}
+	stateDir := t.TempDir()
+	g := &Generator{templateEngine: engine, stateDir: stateDir}
+
+	appDir := filepath.Join(t.TempDir(), "app")
+	config := &AppConfig{
+		AppName:       "watchcow.db",
+		DisplayName:   `"DB"`,
+		ContainerID:   "abc123",
+		ContainerName: "db",
+		Labels:        map[string]string{"com.docker.compose.project": "stack"},
+		Volumes: []VolumeMapping{
+			{Source: "/vol1/db", Destination: "/backup", Type: "bind"},
+			{Source: "/var/lib/docker/volumes/stack_data/_data", Destination: "/data", Type: "volume", Name: "stack_data"},
+		},
+		Hooks: map[string]string{"uninstall_callback": `echo "hook $WATCHCOW_HOOK" >> "$OUT"`},
+	}
+	if err := g.GenerateFromConfig(config, appDir); err != nil {
+		t.Fatalf("GenerateFromConfig() error = %v", err)
+	}
+
+	var wizard []struct {
+		Items []struct {
+			Type    string
+			Field   string
+			Options []struct{ Value string }
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(appDir, "wizard", "uninstall"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &wizard); err != nil {
+		t.Fatalf("wizard/uninstall is not valid JSON: %v\n%s", err, data)
+	}
+	var fields, actions []string
+	for _, item := range wizard[0].Items {
+		fields = append(fields, item.Field)
+		for _, o := range item.Options {
+			actions = append(actions, o.Value)
+		}
+	}
+	if got := strings.Join(fields, ","); got != ",wizard_container_action,wizard_remove_volumes" {
+		t.Errorf("wizard fields = %s", got)
+	}
+	if got := strings.Join(actions, ","); got != "keep,stop,remove,compose_down" {
+		t.Errorf("wizard actions = %s", got)
+	}
+
+	// Fake docker CLI recording its arguments
+	bin := t.TempDir()
+	out := filepath.Join(t.TempDir(), "out")
+	os.WriteFile(filepath.Join(bin, "docker"), []byte("#!/bin/sh\necho \"docker $*\" >> \"$OUT\"\n"), 0755)
+
+	run := func(env ...string) string {
+		t.Helper()
+		os.Remove(out)
+		cmd := exec.Command(bash, filepath.Join(appDir, "cmd", "uninstall_callback"))
+		cmd.Env = append(os.Environ(), append(env, "OUT="+out, "PATH="+bin+":"+os.Getenv("PATH"))...)
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("running cmd/uninstall_callback: %v", err)
+		}
+		data, _ := os.ReadFile(out)
+		return strings.TrimSpace(string(data))
+	}
+
+	if got := run(); got != "hook uninstall_callback" {
+		t.Errorf("keep ran %q", got)
+	}
+	if !g.UninstalledByUser("watchcow.db", "abc123") {
+		t.Error("uninstall callback did not write the marker")
+	}
+	if got := run("wizard_container_action=remove"); got != "hook uninstall_callback\ndocker rm -f abc123" {
+		t.Errorf("remove ran %q", got)
+	}
+	if got := run("wizard_container_action=compose_down", "wizard_remove_volumes=true"); got != "hook uninstall_callback\ndocker compose -p stack down\ndocker volume rm stack_data" {
+		t.Errorf("compose_down ran %q", got)
+	}
+
+	// Without a compose project and named volumes the wizard only offers the container actions
+	config.Labels, config.Volumes, config.Hooks = nil, nil, nil
+	if err := g.GenerateFromConfig(config, appDir); err != nil {
+		t.Fatalf("GenerateFromConfig() error = %v", err)
+	}
+	data, _ = os.ReadFile(filepath.Join(appDir, "wizard", "uninstall"))
+	if err := json.Unmarshal(data, &wizard); err != nil || len(wizard[0].Items) != 2 || len(wizard[0].Items[1].Options) != 3 {
+		t.Errorf("wizard/uninstall = %s, %v", data, err)
+	}
+	if got := run("wizard_container_action=stop"); got != "docker stop abc123" {
+		t.Errorf("stop ran %q", got)
+	}
+}